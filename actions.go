@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// runGit runs a git command inside a repository and returns its trimmed output.
+// Failures carry git's own message so callers can show it to the user as-is.
+func runGit(repoPath string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	return runGitContext(ctx, repoPath, args...)
+}
+
+// runGitLong is runGit without the minute's limit, for clones, bundles,
+// pushes and gc, which take as long as the repo's size needs
+func runGitLong(repoPath string, args ...string) (string, error) {
+	return runGitContext(context.Background(), repoPath, args...)
+}
+
+func runGitContext(ctx context.Context, repoPath string, args ...string) (string, error) {
+	if offline && touchesRemote(args) {
+		return "", errOffline
+	}
+	cmd := gitCommand(ctx, append([]string{"-C", repoPath}, args...)...)
+	out, err := cmd.CombinedOutput()
+	output := strings.TrimSpace(string(out))
+	if touchesRemote(args) {
+		noteRemoteResult(repoPath, output, err)
+	}
+	if err != nil {
+		if output == "" {
+			return "", err
+		}
+		return output, fmt.Errorf("%s", output)
+	}
+	return output, nil
+}
+
+// runGitZ runs a git listing given -z and returns its NUL-separated entries
+// as they are, so names with quotes, spaces or newlines come through intact
+func runGitZ(repoPath string, args ...string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cmd := gitCommand(ctx, append([]string{"-C", repoPath}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s", message)
+		}
+		return nil, err
+	}
+	entries := strings.Split(string(out), "\x00")
+	if entries[len(entries)-1] == "" {
+		entries = entries[:len(entries)-1]
+	}
+	return entries, nil
+}
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveConfig controls where archived repositories are stored
+type ArchiveConfig struct {
+	Directory string `json:"directory"` // defaults to <config dir>/archive
+}
+
+// archiveRecord is what we remember about an archived repo so it can be
+// restored later with the same layout and remotes
+type archiveRecord struct {
+	Name         string            `json:"name"`
+	OriginalPath string            `json:"original_path"`
+	Bundle       string            `json:"bundle"`
+	Untracked    string            `json:"untracked,omitempty"`
+	Branch       string            `json:"branch"`
+	Remotes      map[string]string `json:"remotes,omitempty"`
+	ArchivedAt   time.Time         `json:"archived_at"`
+	Removed      bool              `json:"removed"`
+	RestoredTo   string            `json:"restored_to,omitempty"`
+}
+
+type archiveOptions struct {
+	Remove bool
+	Force  bool
+	Yes    bool
+}
+
+func archiveDir() (string, error) {
+	userConfig, err := loadConfig()
+	if err == nil && userConfig.Archive.Directory != "" {
+		return userConfig.Archive.Directory, nil
+	}
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "archive"), nil
+}
+
+func archiveIndexPath(dir string) string {
+	return filepath.Join(dir, "archive.json")
+}
+
+func loadArchiveIndex(dir string) ([]archiveRecord, error) {
+	data, err := os.ReadFile(archiveIndexPath(dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []archiveRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func saveArchiveIndex(dir string, records []archiveRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(archiveIndexPath(dir), data, 0644)
+}
+
+func repoRemotes(repoPath string) map[string]string {
+	remotes := make(map[string]string)
+	out, err := runGit(repoPath, "remote")
+	if err != nil || out == "" {
+		return remotes
+	}
+	for _, name := range strings.Split(out, "\n") {
+		if url, err := runGit(repoPath, "remote", "get-url", name); err == nil {
+			remotes[name] = url
+		}
+	}
+	return remotes
+}
+
+// writeUntrackedTarball packs the repo's untracked (but not ignored) files.
+// Any file it can't read stops the archive, since --remove would otherwise
+// delete it without a copy.
+func writeUntrackedTarball(repoPath, target string) (count int, err error) {
+	files, err := runGitZ(repoPath, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		return 0, nil
+	}
+
+	file, err := os.Create(target)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(target)
+		}
+	}()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	for _, rel := range files {
+		if err := addTarEntry(tw, repoPath, rel); err != nil {
+			return 0, fmt.Errorf("%s: %v", rel, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return len(files), gz.Close()
+}
+
+// addTarEntry writes one untracked file or symlink into the tarball
+func addTarEntry(tw *tar.Writer, repoPath, rel string) error {
+	path := filepath.Join(repoPath, rel)
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	switch {
+	case info.Mode().IsRegular():
+	case info.Mode()&os.ModeSymlink != 0:
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("not a regular file or symlink")
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(rel)
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if link != "" {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+// stashRefPrefix holds a ref per stash entry while the bundle is written.
+// refs/stash alone only carries the latest entry; the others live in its
+// reflog, which bundles don't include.
+const stashRefPrefix = "refs/archived-stash/"
+
+// pinStashes points a ref at every stash entry, oldest first, so the bundle
+// takes them all along. It returns the refs to delete afterwards.
+func pinStashes(repoPath string) ([]string, error) {
+	out, err := runGit(repoPath, "stash", "list", "--format=%H")
+	if err != nil || out == "" {
+		return nil, err
+	}
+	hashes := strings.Split(out, "\n")
+	var refs []string
+	for i := len(hashes) - 1; i >= 0; i-- {
+		ref := fmt.Sprintf("%s%03d", stashRefPrefix, len(refs))
+		if _, err := runGit(repoPath, "update-ref", ref, hashes[i]); err != nil {
+			for _, made := range refs {
+				runGit(repoPath, "update-ref", "-d", made)
+			}
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// restoreRefs brings every branch, tag and stash entry back from the bundle.
+// The clone only made the checked-out branch local; the others are under
+// refs/remotes/origin, which go as soon as origin is re-pointed or removed.
+func restoreRefs(target, bundle string) error {
+	if _, err := runGitLong(target, "fetch", "--update-head-ok", "--", bundle,
+		"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*", "+"+stashRefPrefix+"*:"+stashRefPrefix+"*"); err != nil {
+		return err
+	}
+	out, err := runGit(target, "for-each-ref", "--format=%(refname)", stashRefPrefix)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		// bundles from before every entry was kept only have the latest
+		if heads, err := runGit(target, "bundle", "list-heads", bundle, "refs/stash"); err == nil && heads != "" {
+			if _, err := runGit(target, "fetch", "--", bundle, "refs/stash"); err != nil {
+				return err
+			}
+			return storeStash(target, "FETCH_HEAD")
+		}
+		return nil
+	}
+	// for-each-ref sorts by name, which is oldest first
+	for _, ref := range strings.Split(out, "\n") {
+		err := storeStash(target, ref)
+		runGit(target, "update-ref", "-d", ref)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeStash puts a stash commit back on the stash list with its message
+func storeStash(repoPath, rev string) error {
+	message, _ := runGit(repoPath, "log", "-1", "--format=%s", rev)
+	_, err := runGit(repoPath, "stash", "store", "-m", message, rev)
+	return err
+}
+
+// runArchive stores a repo as a verified git bundle plus a tarball of its
+// untracked files, and optionally deletes the working copy afterwards
+func runArchive(repoPath string, opts archiveOptions) error {
+	repoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, ".git")); err != nil {
+		return fmt.Errorf("%s is not a git repository", repoPath)
+	}
+	if opts.Remove && isProtected(repoPath) {
+		return protectedError(repoPath)
+	}
+
+	// Modified tracked files would be lost: the bundle only has commits
+	porcelain, err := runGit(repoPath, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return err
+	}
+	switch {
+	case porcelain != "" && opts.Remove:
+		return fmt.Errorf("%s has uncommitted changes to tracked files, which the bundle can't hold (commit or stash them before --remove)", repoPath)
+	case porcelain != "" && !opts.Force:
+		return fmt.Errorf("%s has uncommitted changes to tracked files (commit them or use --force)", repoPath)
+	}
+
+	dir, err := archiveDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	name := filepath.Base(repoPath)
+	stamp := time.Now().Format("20060102-150405")
+	record := archiveRecord{
+		Name:         name,
+		OriginalPath: repoPath,
+		Bundle:       filepath.Join(dir, fmt.Sprintf("%s-%s.bundle", name, stamp)),
+		Remotes:      repoRemotes(repoPath),
+		ArchivedAt:   time.Now(),
+	}
+	record.Branch, _ = runGit(repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+
+	stashes, err := pinStashes(repoPath)
+	if err != nil {
+		return fmt.Errorf("reading stashes: %v", err)
+	}
+	_, err = runGitLong(repoPath, "bundle", "create", record.Bundle, "--all")
+	for _, ref := range stashes {
+		runGit(repoPath, "update-ref", "-d", ref)
+	}
+	if err != nil {
+		return fmt.Errorf("creating bundle: %v", err)
+	}
+	if _, err := runGitLong(repoPath, "bundle", "verify", record.Bundle); err != nil {
+		os.Remove(record.Bundle)
+		return fmt.Errorf("bundle failed verification: %v", err)
+	}
+	fmt.Printf("✓ Bundled and verified %s\n", record.Bundle)
+
+	tarball := filepath.Join(dir, fmt.Sprintf("%s-%s-untracked.tar.gz", name, stamp))
+	count, err := writeUntrackedTarball(repoPath, tarball)
+	if err != nil {
+		return fmt.Errorf("archiving untracked files: %v", err)
+	}
+	if count > 0 {
+		record.Untracked = tarball
+		fmt.Printf("✓ Saved %d untracked files to %s\n", count, tarball)
+	}
+
+	if opts.Remove && (opts.Yes || confirm(fmt.Sprintf("Delete working copy %s?", repoPath))) {
+		if err := os.RemoveAll(repoPath); err != nil {
+			return err
+		}
+		record.Removed = true
+		fmt.Printf("✓ Removed %s\n", repoPath)
+	}
+
+	return withLock("archive "+dir, func() error {
+		records, err := loadArchiveIndex(dir)
+		if err != nil {
+			return err
+		}
+		return saveArchiveIndex(dir, append(records, record))
+	})
+}
+
+// findArchiveRecord matches a bundle path, or a repo name (latest archive wins)
+func findArchiveRecord(records []archiveRecord, ref string) int {
+	if abs, err := filepath.Abs(ref); err == nil {
+		for i, record := range records {
+			if record.Bundle == abs {
+				return i
+			}
+		}
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Name == ref {
+			return i
+		}
+	}
+	return -1
+}
+
+func extractUntrackedTarball(tarball, target string) (int, error) {
+	file, err := os.Open(tarball)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, err
+	}
+	tr := tar.NewReader(gz)
+
+	count := 0
+	var links []tar.Header
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			for _, link := range links {
+				path := filepath.Join(target, filepath.FromSlash(link.Name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					return count, err
+				}
+				os.Remove(path)
+				if err := os.Symlink(link.Linkname, path); err != nil {
+					return count, err
+				}
+			}
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		path := filepath.Join(target, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(path, target+string(os.PathSeparator)) {
+			return count, fmt.Errorf("refusing to extract %s outside the repository", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return count, err
+			}
+			continue
+		case tar.TypeSymlink:
+			// made once every file is written, so none is written through one
+			links = append(links, *header)
+			count++
+			continue
+		case tar.TypeReg:
+		default:
+			return count, fmt.Errorf("%s: unsupported entry type %q", header.Name, header.Typeflag)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return count, err
+		}
+		out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+		if err != nil {
+			return count, err
+		}
+		_, err = io.Copy(out, tr)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// runRestore clones an archived bundle back into place and puts the
+// recorded remotes and untracked files back
+func runRestore(ref, target string) error {
+	dir, err := archiveDir()
+	if err != nil {
+		return err
+	}
+	records, err := loadArchiveIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	bundle := ref
+	var record *archiveRecord
+	if i := findArchiveRecord(records, ref); i >= 0 {
+		record = &records[i]
+		bundle = record.Bundle
+	} else if _, err := os.Stat(ref); err != nil {
+		return fmt.Errorf("no archive found for %s", ref)
+	}
+
+	if target == "" {
+		if record == nil {
+			return fmt.Errorf("%s is not in the archive index, pass --to to choose where to restore it", ref)
+		}
+		target = record.OriginalPath
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("%s already exists", target)
+	}
+
+	if _, err := runGitLong(filepath.Dir(target), "clone", bundle, target); err != nil {
+		// The parent may be gone along with the working copy
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if _, err := runGitLong(filepath.Dir(target), "clone", bundle, target); err != nil {
+			return fmt.Errorf("cloning bundle: %v", err)
+		}
+	}
+	if err := restoreRefs(target, bundle); err != nil {
+		return fmt.Errorf("restoring branches, tags and stashes: %v", err)
+	}
+	fmt.Printf("✓ Restored %s\n", target)
+
+	if record != nil {
+		// The clone's origin points at the bundle; swap the real remotes back in
+		if url, ok := record.Remotes["origin"]; ok {
+			runGit(target, "remote", "set-url", "origin", url)
+		} else {
+			runGit(target, "remote", "remove", "origin")
+		}
+		for name, url := range record.Remotes {
+			if name != "origin" {
+				runGit(target, "remote", "add", name, url)
+			}
+		}
+		if len(record.Remotes) > 0 {
+			fmt.Printf("✓ Restored %d remote(s)\n", len(record.Remotes))
+		}
+
+		if record.Branch != "" && record.Branch != "HEAD" {
+			runGit(target, "checkout", record.Branch)
+		}
+
+		if record.Untracked != "" {
+			count, err := extractUntrackedTarball(record.Untracked, target)
+			if err != nil {
+				return fmt.Errorf("restoring untracked files: %v", err)
+			}
+			fmt.Printf("✓ Restored %d untracked files\n", count)
+		}
+
+		err := withLock("archive "+dir, func() error {
+			records, err := loadArchiveIndex(dir)
+			if err != nil {
+				return err
+			}
+			for i := range records {
+				if records[i].Bundle == record.Bundle {
+					records[i].Removed = false
+					records[i].RestoredTo = target
+				}
+			}
+			return saveArchiveIndex(dir, records)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	status := getGitStatusOptimized(target, filepath.Dir(target))
+	fmt.Printf("%s %s %s\n", status.Symbol, status.RelativePath, status.Message)
+	return nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// OAuth app client IDs for the device flow, stamped at build time with
+// -ldflags "-X main.githubClientID=..." or set per forge as client_id
+var (
+	githubClientID = ""
+	gitlabClientID = ""
+)
+
+// keychainService names the tool's entries in the OS keychain
+const keychainService = "git-status-dash"
+
+// deviceCode is the first response of the OAuth device flow
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// defaultScopes are the least the tool needs: creating repos, releases and
+// pull requests, and reading notifications
+func defaultScopes(forgeType string) string {
+	if forgeType == "gitlab" {
+		return "api"
+	}
+	return "repo notifications"
+}
+
+func (f ForgeConfig) clientID() string {
+	if f.ClientID != "" {
+		return f.ClientID
+	}
+	if f.Type == "gitlab" {
+		return gitlabClientID
+	}
+	return githubClientID
+}
+
+// oauthEndpoints returns where to request a device code and poll for the
+// token. Both live on the web host, not the API.
+func (f ForgeConfig) oauthEndpoints() (codeURL, tokenURL string) {
+	base := "https://" + f.host()
+	if f.Type == "gitlab" {
+		return base + "/oauth/authorize_device", base + "/oauth/token"
+	}
+	return base + "/login/device/code", base + "/login/oauth/access_token"
+}
+
+func postForm(endpoint string, form url.Values, out interface{}) error {
+	if offline {
+		return errOffline
+	}
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	client, err := httpClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	// token polling reports "authorization_pending" with a 400, so the
+	// body is decoded whatever the status
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("POST %s: HTTP %d", endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+// deviceLogin runs the OAuth device flow: it shows a code to enter in the
+// browser and polls until the user approves it
+func deviceLogin(f ForgeConfig, scopes string) (string, error) {
+	clientID := f.clientID()
+	if clientID == "" {
+		return "", fmt.Errorf("no OAuth client ID for %s; register an OAuth app with device flow enabled and pass --client-id", f.host())
+	}
+	codeURL, tokenURL := f.oauthEndpoints()
+
+	var code deviceCode
+	if err := postForm(codeURL, url.Values{"client_id": {clientID}, "scope": {scopes}}, &code); err != nil {
+		return "", err
+	}
+	if code.DeviceCode == "" {
+		return "", fmt.Errorf("%s did not start the device flow (is it enabled for this OAuth app?)", f.host())
+	}
+	fmt.Printf("Open %s and enter the code %s\n", code.VerificationURI, code.UserCode)
+	fmt.Println("Waiting for you to approve access...")
+
+	interval := time.Duration(max(code.Interval, 5)) * time.Second
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+		var token struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+		}
+		form := url.Values{
+			"client_id":   {clientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		}
+		if err := postForm(tokenURL, form, &token); err != nil {
+			return "", err
+		}
+		switch token.Error {
+		case "":
+			return token.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return "", fmt.Errorf("access was denied")
+		default:
+			return "", fmt.Errorf("login failed: %s", token.Error)
+		}
+	}
+	return "", fmt.Errorf("the code expired before it was approved")
+}
+
+// keychainSet stores secret for account with the OS keychain tools:
+// security on macOS and secret-tool (libsecret) on Linux. The secret goes
+// over stdin so it never shows up in the process list.
+func keychainSet(account, secret string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n", keychainService, account, secret))
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "store", "--label", keychainService+" "+account, "service", keychainService, "account", account)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return fmt.Errorf("no supported keychain on %s", runtime.GOOS)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %v %s", cmd.Args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// keychainGet returns the secret stored for account, or "" when there is none
+func keychainGet(account string) string {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w")
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", account)
+	default:
+		return ""
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func keychainDelete(account string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", account)
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "clear", "service", keychainService, "account", account)
+	default:
+		return fmt.Errorf("no supported keychain on %s", runtime.GOOS)
+	}
+	return cmd.Run()
+}
+
+// resolveAuthForge finds the forge "auth" refers to: a configured forge by
+// name, or a forge type. configured is false for a type that isn't in the
+// config yet.
+func resolveAuthForge(name, host string) (forge ForgeConfig, configured bool, err error) {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return ForgeConfig{}, false, err
+	}
+	if forge, ok := userConfig.Forges[name]; ok {
+		return forge, true, nil
+	}
+	if name != "github" && name != "gitlab" {
+		return ForgeConfig{}, false, fmt.Errorf("unknown forge %q (use github, gitlab or a configured forge name)", name)
+	}
+	return ForgeConfig{Type: name, Host: host}, false, nil
+}
+
+// addAuthForge saves a forge type that was logged in to under its own name,
+// so the other commands pick the login up
+func addAuthForge(name string, forge ForgeConfig) error {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if userConfig.Forges == nil {
+		userConfig.Forges = make(map[string]ForgeConfig)
+	}
+	userConfig.Forges[name] = forge
+	return saveConfig(userConfig)
+}
+
+// runAuthLogin signs in to a forge with the device flow and keeps the token
+// in the keychain
+func runAuthLogin(name, host, clientID, scopes string) error {
+	forge, configured, err := resolveAuthForge(name, host)
+	if err != nil {
+		return err
+	}
+	if clientID != "" {
+		forge.ClientID = clientID
+	}
+	if forge.clientID() == "" {
+		return fmt.Errorf("this build has no OAuth app for %s: register one with device flow enabled and pass its client ID with --client-id, or set a token with forges.%s.token_env instead", forge.host(), name)
+	}
+	if scopes == "" {
+		scopes = defaultScopes(forge.Type)
+	}
+
+	token, err := deviceLogin(forge, scopes)
+	if err != nil {
+		return err
+	}
+	if err := keychainSet(forge.host(), token); err != nil {
+		return fmt.Errorf("logged in, but could not store the token: %v", err)
+	}
+	if !configured {
+		if err := addAuthForge(name, ForgeConfig{Type: forge.Type, Host: host, ClientID: clientID}); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("✓ Logged in to %s, token stored in the keychain (scopes: %s)\n", forge.host(), scopes)
+	return nil
+}
+
+func runAuthLogout(name string) error {
+	forge, _, err := resolveAuthForge(name, "")
+	if err != nil {
+		return err
+	}
+	if err := keychainDelete(forge.host()); err != nil {
+		return fmt.Errorf("could not remove the token for %s: %v", forge.host(), err)
+	}
+	fmt.Printf("✓ Removed the %s token from the keychain\n", forge.host())
+	return nil
+}
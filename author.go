@@ -0,0 +1,24 @@
+package main
+
+import "strings"
+
+// byAuthor reports whether author matches one of the --author names.
+// Part of the name is enough and case doesn't matter, so "ada" finds
+// "Ada Lovelace".
+func byAuthor(author string, names []string) bool {
+	author = strings.ToLower(author)
+	for _, name := range names {
+		if name != "" && strings.Contains(author, strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorLabel is the "by" suffix display.show_author adds to a line
+func authorLabel(repo GitStatus) string {
+	if repo.LastAuthor == "" {
+		return ""
+	}
+	return " · by " + repo.LastAuthor
+}
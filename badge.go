@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+)
+
+// Shields.io "flat" badge layout. Widths are estimated from the average
+// Verdana 11px glyph width, which is close enough for short labels.
+const badgeTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[2]s: %[3]s">
+  <title>%[2]s: %[3]s</title>
+  <linearGradient id="s" x2="0" y2="100%%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%[4]d" height="20" fill="#555"/>
+    <rect x="%[4]d" width="%[5]d" height="20" fill="%[6]s"/>
+    <rect width="%[1]d" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[2]s</text>
+    <text x="%[7]d" y="14">%[2]s</text>
+    <text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[3]s</text>
+    <text x="%[8]d" y="14">%[3]s</text>
+  </g>
+</svg>
+`
+
+func badgeTextWidth(text string) int {
+	return len([]rune(text))*7 + 10
+}
+
+func renderBadge(label, message, color string) string {
+	label = html.EscapeString(label)
+	message = html.EscapeString(message)
+	labelWidth := badgeTextWidth(label)
+	messageWidth := badgeTextWidth(message)
+
+	return fmt.Sprintf(badgeTemplate,
+		labelWidth+messageWidth,
+		label,
+		message,
+		labelWidth,
+		messageWidth,
+		color,
+		labelWidth/2,
+		labelWidth+messageWidth/2,
+	)
+}
+
+// workspaceBadge summarizes repo hygiene: green when everything is synced,
+// yellow when only pushes/pulls are pending, red for dirty or broken repos.
+func workspaceBadge(label string, repos []GitStatus) string {
+	clean, dirty := 0, 0
+	color := "#4c1"
+	for _, repo := range repos {
+		switch repo.Symbol {
+		case "✓":
+			clean++
+			continue
+		case "✗", "⚠", "☠":
+			color = "#e05d44"
+		default:
+			if color != "#e05d44" {
+				color = "#dfb317"
+			}
+		}
+		dirty++
+	}
+
+	return renderBadge(label, fmt.Sprintf("%d clean / %d dirty", clean, dirty), color)
+}
+
+func runBadge(baseDir, label, output string) error {
+	repos := findGitReposOptimized(baseDir, config.Depth)
+	svg := workspaceBadge(label, repos)
+
+	if output == "-" {
+		_, err := fmt.Print(svg)
+		return err
+	}
+
+	if err := os.WriteFile(output, []byte(svg), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote badge for %d repositories to %s\n", len(repos), output)
+	return nil
+}
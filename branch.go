@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// detectDefaultBranch returns the remote's default branch for a repository.
+// origin/HEAD is only set when cloning from a non-empty remote, so fall back
+// to the conventional names when it is missing.
+func detectDefaultBranch(ctx context.Context, repoPath string) string {
+	if out, err := gitCommand(ctx, "-C", repoPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD").Output(); err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(string(out)), "origin/")
+	}
+
+	for _, candidate := range []string{"main", "master"} {
+		if gitCommand(ctx, "-C", repoPath, "show-ref", "--verify", "--quiet", "refs/remotes/origin/"+candidate).Run() == nil {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// OffDefaultBranch reports whether HEAD is on a branch other than the remote default
+func (s GitStatus) OffDefaultBranch() bool {
+	return s.DefaultBranch != "" && s.Branch != "" && s.Branch != "HEAD" && s.Branch != s.DefaultBranch
+}
+
+func describeBranch(repo GitStatus) string {
+	if repo.OffDefaultBranch() {
+		return fmt.Sprintf("%s (default: %s)", repo.Branch, repo.DefaultBranch)
+	}
+	return repo.Branch
+}
+
+// runSwitchDefault checks out the default branch in every repo that is
+// parked on another branch, leaving repos with uncommitted changes alone.
+func runSwitchDefault(baseDir string, dryRun, yes bool) {
+	repos := findGitReposOptimized(baseDir, config.Depth)
+
+	var targets []GitStatus
+	skipped := 0
+	for _, repo := range repos {
+		if !repo.OffDefaultBranch() {
+			continue
+		}
+		if isProtected(repo.RepoPath) {
+			fmt.Printf("- %-30s skipped: %s\n", displayName(repo), protectedMessage)
+			skipped++
+			continue
+		}
+		if repo.Dirty {
+			fmt.Printf("- %-30s skipped: uncommitted changes on %s\n", displayName(repo), repo.Branch)
+			skipped++
+			continue
+		}
+		targets = append(targets, repo)
+	}
+
+	if dryRun {
+		for _, repo := range targets {
+			fmt.Printf("~ %-30s %s → %s\n", displayName(repo), repo.Branch, repo.DefaultBranch)
+		}
+		fmt.Printf("\nWould switch %d repositories, skipped %d\n", len(targets), skipped)
+		return
+	}
+
+	commands := make([]string, len(targets))
+	for i, repo := range targets {
+		commands[i] = fmt.Sprintf("git -C %s checkout %s", repo.RepoPath, repo.DefaultBranch)
+	}
+	if !yes && !confirmBulk(fmt.Sprintf("Switch %d repositories to their default branch?", len(targets)), commands) {
+		fmt.Println("Cancelled")
+		return
+	}
+
+	switched := 0
+	for _, repo := range targets {
+		if _, err := runGit(repo.RepoPath, "checkout", repo.DefaultBranch); err != nil {
+			fmt.Printf("✗ %-30s %v\n", displayName(repo), err)
+			skipped++
+			continue
+		}
+		fmt.Printf("✓ %-30s %s → %s\n", displayName(repo), repo.Branch, repo.DefaultBranch)
+		switched++
+	}
+	fmt.Printf("\nSwitched %d repositories, skipped %d\n", switched, skipped)
+}
+
+// displayName is the repo path relative to the scanned directory
+func displayName(repo GitStatus) string {
+	if repo.RelativePath == "" {
+		return "."
+	}
+	return repo.RelativePath
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// onBranch reports whether branch matches one of the --branch patterns.
+// Patterns are globs: release/* matches release/1.2 but not release/1.2/rc.
+func onBranch(branch string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func validateBranchPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad --branch pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// splitBranchPatterns reads the patterns typed at the B prompt, separated
+// by commas or spaces
+func splitBranchPatterns(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// branchPrompt is the B prompt for the branch filter
+type branchPrompt struct {
+	text string
+}
+
+func (m model) startBranchPrompt() model {
+	m.branchPrompt = &branchPrompt{text: strings.Join(m.config.Branches, ",")}
+	return m
+}
+
+func (m model) updateBranchPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.branchPrompt = nil
+	case "enter":
+		patterns := splitBranchPatterns(m.branchPrompt.text)
+		m.branchPrompt = nil
+		if err := validateBranchPatterns(patterns); err != nil {
+			m.notice = "✗ " + err.Error()
+			return m, nil
+		}
+		m.config.Branches = patterns
+		m.notice = "Showing repos on any branch"
+		if len(patterns) > 0 {
+			m.notice = fmt.Sprintf("Only repos on %s (B to change)", strings.Join(patterns, ", "))
+		}
+		return m.refilter(), nil
+	case "ctrl+u":
+		m.branchPrompt.text = ""
+	case "backspace":
+		if text := []rune(m.branchPrompt.text); len(text) > 0 {
+			m.branchPrompt.text = string(text[:len(text)-1])
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			m.branchPrompt.text += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
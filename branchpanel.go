@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// branchInfo is one local branch and how it compares with its upstream
+type branchInfo struct {
+	Name     string
+	Upstream string
+	Ahead    int
+	Behind   int
+	Gone     bool // the upstream branch was deleted
+	Current  bool
+}
+
+// branchPanel is the "b" box listing the selected repo's local branches
+type branchPanel struct {
+	repo     GitStatus
+	branches []branchInfo
+	cursor   int
+	loaded   bool
+	err      error
+}
+
+type branchesMsg struct {
+	repoPath string
+	branches []branchInfo
+	err      error
+}
+
+func loadBranchesCmd(repoPath string) tea.Cmd {
+	return func() tea.Msg {
+		out, err := runGit(repoPath, "for-each-ref", "refs/heads",
+			"--format=%(refname:short)%09%(upstream:short)%09%(upstream:track,nobracket)%09%(HEAD)")
+		if err != nil {
+			return branchesMsg{repoPath: repoPath, err: err}
+		}
+		var branches []branchInfo
+		for _, line := range strings.Split(out, "\n") {
+			// runGit trims the empty fields off the end of the last line
+			fields := append(strings.Split(line, "\t"), "", "", "")
+			if fields[0] == "" {
+				continue
+			}
+			branch := branchInfo{Name: fields[0], Upstream: fields[1], Current: fields[3] == "*"}
+			// "ahead 1, behind 2", "behind 2", "gone" or nothing
+			for _, part := range strings.Split(fields[2], ", ") {
+				switch {
+				case part == "gone":
+					branch.Gone = true
+				case strings.HasPrefix(part, "ahead "):
+					fmt.Sscanf(part, "ahead %d", &branch.Ahead)
+				case strings.HasPrefix(part, "behind "):
+					fmt.Sscanf(part, "behind %d", &branch.Behind)
+				}
+			}
+			branches = append(branches, branch)
+		}
+		return branchesMsg{repoPath: repoPath, branches: branches}
+	}
+}
+
+// checkoutBranchCmd switches a repo to branch, refusing when tracked files
+// have uncommitted changes so nothing gets carried over or lost. Untracked
+// files don't stop it; git itself refuses if the branch would overwrite one.
+func checkoutBranchCmd(repo GitStatus, branch, baseDir string) tea.Cmd {
+	return func() tea.Msg {
+		notice := fmt.Sprintf("✓ Switched %s to %s", displayName(repo), branch)
+		if changes, err := runGit(repo.RepoPath, "status", "--porcelain", "--untracked-files=no"); err != nil {
+			notice = fmt.Sprintf("✗ Checkout failed for %s: %v", displayName(repo), err)
+		} else if changes != "" {
+			notice = fmt.Sprintf("✗ Not switching %s: commit or stash its changes first", displayName(repo))
+		} else if _, err := runGit(repo.RepoPath, "checkout", branch); err != nil {
+			lines := strings.Split(err.Error(), "\n")
+			notice = fmt.Sprintf("✗ Checkout failed for %s: %s", displayName(repo), lines[len(lines)-1])
+		}
+		return repoStatusMsg{status: getGitStatus(repo.RepoPath, baseDir, nil), notice: notice}
+	}
+}
+
+// openBranches shows the branch panel for the repo under the cursor
+func (m model) openBranches() (model, tea.Cmd) {
+	if m.cursor >= len(m.repos) {
+		return m, nil
+	}
+	repo := m.repos[m.cursor]
+	m.branches = &branchPanel{repo: repo}
+	return m, loadBranchesCmd(repo.RepoPath)
+}
+
+func (m model) updateBranches(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	panel := m.branches
+	switch msg.String() {
+	case "esc", "q", "b":
+		m.branches = nil
+	case "up", "k":
+		if panel.cursor > 0 {
+			panel.cursor--
+		}
+	case "down", "j":
+		if panel.cursor < len(panel.branches)-1 {
+			panel.cursor++
+		}
+	case "enter":
+		if panel.cursor >= len(panel.branches) {
+			return m, nil
+		}
+		branch, repo := panel.branches[panel.cursor], panel.repo
+		m.branches = nil
+		switch {
+		case branch.Current:
+			m.notice = fmt.Sprintf("%s is already on %s", displayName(repo), branch.Name)
+		case m.busy[repo.RepoPath]:
+		case isProtected(repo.RepoPath):
+			m.notice = fmt.Sprintf("✗ %s is %s", displayName(repo), protectedMessage)
+		default:
+			m.busy[repo.RepoPath] = true
+			return m, checkoutBranchCmd(repo, branch.Name, m.baseDir)
+		}
+	}
+	return m, nil
+}
+
+func (p branchPanel) view(width int) string {
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		MaxWidth(width)
+	selectedStyle := lipgloss.NewStyle().Background(lipgloss.Color("238"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	aheadStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	behindStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+
+	var b strings.Builder
+	b.WriteString("Branches of " + displayName(p.repo))
+	switch {
+	case p.err != nil:
+		b.WriteString("\n" + dimStyle.Render(fmt.Sprintf("✗ %v", p.err)))
+	case !p.loaded:
+		b.WriteString("\n" + dimStyle.Render("Loading..."))
+	}
+	nameWidth := 0
+	for _, branch := range p.branches {
+		nameWidth = max(nameWidth, len(branch.Name))
+	}
+	for i, branch := range p.branches {
+		marker := "  "
+		if branch.Current {
+			marker = "* "
+		}
+		line := marker + fmt.Sprintf("%-*s", nameWidth, branch.Name)
+		switch {
+		case branch.Gone:
+			line += dimStyle.Render("  " + branch.Upstream + " gone")
+		case branch.Upstream == "":
+			line += dimStyle.Render("  no upstream")
+		default:
+			line += dimStyle.Render("  " + branch.Upstream)
+		}
+		if branch.Ahead > 0 {
+			line += aheadStyle.Render(fmt.Sprintf(" ↑%d", branch.Ahead))
+		}
+		if branch.Behind > 0 {
+			line += behindStyle.Render(fmt.Sprintf(" ↓%d", branch.Behind))
+		}
+		if branch.Name == p.repo.DefaultBranch {
+			line += dimStyle.Render(" (default)")
+		}
+		if i == p.cursor {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString("\n" + line)
+	}
+	return boxStyle.Render(b.String())
+}
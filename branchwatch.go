@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// watchedBranch is one branch matching a watch pattern in one repo
+type watchedBranch struct {
+	Name   string
+	Local  bool
+	Remote bool
+	// Against the remote default branch: commits only on the watched
+	// branch, and commits on the default branch it doesn't have yet
+	Ahead, Behind string
+	// Against origin, when the branch exists on both sides
+	Unpushed, Unpulled string
+}
+
+// watchedBranches finds local and origin branches matching the patterns
+func watchedBranches(repo GitStatus, patterns []string) []watchedBranch {
+	args := []string{"for-each-ref", "--format=%(refname)"}
+	for _, pattern := range patterns {
+		args = append(args, "refs/heads/"+pattern, "refs/remotes/origin/"+pattern)
+	}
+	out, err := runGit(repo.RepoPath, args...)
+	if err != nil || out == "" {
+		return nil
+	}
+
+	found := make(map[string]*watchedBranch)
+	for _, ref := range strings.Split(out, "\n") {
+		var name string
+		local := strings.HasPrefix(ref, "refs/heads/")
+		if local {
+			name = strings.TrimPrefix(ref, "refs/heads/")
+		} else {
+			name = strings.TrimPrefix(ref, "refs/remotes/origin/")
+		}
+		if found[name] == nil {
+			found[name] = &watchedBranch{Name: name}
+		}
+		if local {
+			found[name].Local = true
+		} else {
+			found[name].Remote = true
+		}
+	}
+
+	branches := make([]watchedBranch, 0, len(found))
+	for _, branch := range found {
+		ref := "origin/" + branch.Name
+		if branch.Local {
+			ref = branch.Name
+		}
+		if repo.DefaultBranch != "" {
+			if counts, err := runGit(repo.RepoPath, "rev-list", "--left-right", "--count", ref+"...origin/"+repo.DefaultBranch); err == nil {
+				if fields := strings.Fields(counts); len(fields) == 2 {
+					branch.Ahead, branch.Behind = fields[0], fields[1]
+				}
+			}
+		}
+		if branch.Local && branch.Remote {
+			if counts, err := runGit(repo.RepoPath, "rev-list", "--left-right", "--count", branch.Name+"...origin/"+branch.Name); err == nil {
+				if fields := strings.Fields(counts); len(fields) == 2 {
+					branch.Unpushed, branch.Unpulled = fields[0], fields[1]
+				}
+			}
+		}
+		branches = append(branches, *branch)
+	}
+
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	return branches
+}
+
+func describeWatchedBranch(repo GitStatus, branch watchedBranch) string {
+	var parts []string
+	switch {
+	case branch.Local && !branch.Remote:
+		parts = append(parts, "local only")
+	case !branch.Local:
+		parts = append(parts, "remote only")
+	}
+	if branch.Ahead != "" {
+		parts = append(parts, fmt.Sprintf("%s ahead, %s behind %s", branch.Ahead, branch.Behind, repo.DefaultBranch))
+	}
+	if branch.Unpushed != "" && branch.Unpushed != "0" {
+		parts = append(parts, fmt.Sprintf("%s to push", branch.Unpushed))
+	}
+	if branch.Unpulled != "" && branch.Unpulled != "0" {
+		parts = append(parts, fmt.Sprintf("%s to pull", branch.Unpulled))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// runBranchWatch shows, for every repo, the branches matching the watch
+// patterns and how far they have drifted from the default branch
+func runBranchWatch(baseDir string, patterns []string) error {
+	if len(patterns) == 0 {
+		userConfig, err := loadConfig()
+		if err == nil {
+			patterns = userConfig.WatchBranches
+		}
+	}
+	if len(patterns) == 0 {
+		return fmt.Errorf("no branches to watch (set watch_branches, e.g. git-status-dash config set watch_branches 'release/*')")
+	}
+
+	repos := findGitReposOptimized(baseDir, config.Depth)
+	sort.Slice(repos, func(i, j int) bool { return repos[i].RelativePath < repos[j].RelativePath })
+
+	fmt.Printf("Watching %s across %d repositories\n\n", strings.Join(patterns, ", "), len(repos))
+
+	missing := 0
+	for _, repo := range repos {
+		name := repo.RelativePath
+		if name == "" {
+			name = "."
+		}
+
+		branches := watchedBranches(repo, patterns)
+		if len(branches) == 0 {
+			fmt.Printf("✗ %-30s no matching branch\n", name)
+			missing++
+			continue
+		}
+		for _, branch := range branches {
+			symbol := "✓"
+			if branch.Unpushed != "" && branch.Unpushed != "0" || branch.Unpulled != "" && branch.Unpulled != "0" {
+				symbol = "↕"
+			}
+			fmt.Printf("%s %-30s %-20s %s\n", symbol, name, branch.Name, describeWatchedBranch(repo, branch))
+			name = ""
+		}
+	}
+
+	fmt.Printf("\n%d of %d repositories have a matching branch\n", len(repos)-missing, len(repos))
+	return nil
+}
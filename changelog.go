@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// changelogTypes are the conventional-commit types we group by, in order
+var changelogTypes = []struct{ Type, Title string }{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"chore", "Chores"},
+	{"", "Other"},
+}
+
+var conventionalCommit = regexp.MustCompile(`^(\w+)(\([^)]*\))?(!)?:\s*(.+)$`)
+
+// commits listed when a repo has no tag at all
+const untaggedChangelogLimit = 50
+
+type changelogEntry struct {
+	Hash     string
+	Subject  string
+	Breaking bool
+}
+
+type changelogGroup struct {
+	Title   string
+	Entries []changelogEntry
+}
+
+type changelog struct {
+	RepoPath string
+	Tag      string // empty when the repo has no tags
+	Total    int
+	Groups   []changelogGroup
+}
+
+type changelogMsg struct {
+	changelog changelog
+	err       error
+}
+
+// loadChangelog groups the commits since the latest tag by conventional-commit type
+func loadChangelog(repoPath string) (changelog, error) {
+	cl := changelog{RepoPath: repoPath}
+	if tag, err := runGit(repoPath, "describe", "--tags", "--abbrev=0"); err == nil {
+		cl.Tag = tag
+	}
+
+	args := []string{"log", "--no-merges", "--format=%h%x09%s"}
+	if cl.Tag != "" {
+		args = append(args, cl.Tag+"..HEAD")
+	} else {
+		args = append(args, fmt.Sprintf("-%d", untaggedChangelogLimit))
+	}
+	out, err := runGit(repoPath, args...)
+	if err != nil {
+		return cl, err
+	}
+
+	grouped := make(map[string][]changelogEntry)
+	for _, line := range strings.Split(out, "\n") {
+		hash, subject, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		cl.Total++
+
+		commitType := ""
+		entry := changelogEntry{Hash: hash, Subject: subject}
+		if match := conventionalCommit.FindStringSubmatch(subject); match != nil {
+			commitType = strings.ToLower(match[1])
+			entry.Subject = match[4]
+			if match[2] != "" {
+				entry.Subject = strings.Trim(match[2], "()") + ": " + entry.Subject
+			}
+			entry.Breaking = match[3] == "!"
+		}
+		if !knownChangelogType(commitType) {
+			commitType = ""
+			entry.Subject = subject
+		}
+		grouped[commitType] = append(grouped[commitType], entry)
+	}
+
+	for _, t := range changelogTypes {
+		if entries := grouped[t.Type]; len(entries) > 0 {
+			cl.Groups = append(cl.Groups, changelogGroup{Title: t.Title, Entries: entries})
+		}
+	}
+	return cl, nil
+}
+
+func knownChangelogType(commitType string) bool {
+	for _, t := range changelogTypes {
+		if t.Type == commitType {
+			return true
+		}
+	}
+	return false
+}
+
+func (c changelog) heading() string {
+	if c.Tag == "" {
+		return "Unreleased (no tags yet)"
+	}
+	return fmt.Sprintf("Unreleased (since %s)", c.Tag)
+}
+
+// markdown renders the CHANGELOG snippet written by the export action
+func (c changelog) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n", c.heading())
+	for _, group := range c.Groups {
+		fmt.Fprintf(&b, "\n### %s\n\n", group.Title)
+		for _, entry := range group.Entries {
+			prefix := ""
+			if entry.Breaking {
+				prefix = "**BREAKING** "
+			}
+			fmt.Fprintf(&b, "- %s%s (%s)\n", prefix, entry.Subject, entry.Hash)
+		}
+	}
+	return b.String()
+}
+
+// preview is the detail view section, showing a few commits per group
+func (c changelog) preview(perGroup int) string {
+	if c.Total == 0 {
+		if c.Tag == "" {
+			return "Changelog: no commits yet"
+		}
+		return fmt.Sprintf("Changelog: nothing since %s", c.Tag)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s, %d commit(s)", c.heading(), c.Total)
+	for _, group := range c.Groups {
+		fmt.Fprintf(&b, "\n  %s", group.Title)
+		for i, entry := range group.Entries {
+			if i == perGroup {
+				fmt.Fprintf(&b, "\n    … %d more", len(group.Entries)-perGroup)
+				break
+			}
+			fmt.Fprintf(&b, "\n    %s %s", entry.Hash, entry.Subject)
+		}
+	}
+	return b.String()
+}
+
+func changelogSnippetPath(repoPath string) string {
+	return filepath.Join(repoPath, "CHANGELOG.next.md")
+}
+
+func loadChangelogCmd(repoPath string) tea.Cmd {
+	return func() tea.Msg {
+		cl, err := loadChangelog(repoPath)
+		return changelogMsg{changelog: cl, err: err}
+	}
+}
+
+func exportChangelogCmd(c changelog) tea.Cmd {
+	return func() tea.Msg {
+		path := changelogSnippetPath(c.RepoPath)
+		if err := os.WriteFile(path, []byte(c.markdown()), 0644); err != nil {
+			return noticeMsg(fmt.Sprintf("✗ Could not write changelog: %v", err))
+		}
+		return noticeMsg(fmt.Sprintf("✓ Wrote %s", path))
+	}
+}
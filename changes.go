@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// countChanges tallies porcelain output by kind of change. A file staged
+// and then edited again counts as both staged and modified.
+func countChanges(status *GitStatus, porcelain string) {
+	status.Modified, status.Untracked, status.Staged = 0, 0, 0
+	for _, line := range strings.Split(porcelain, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		switch {
+		case line[:2] == "??":
+			status.Untracked++
+		case line[:2] == "!!":
+		default:
+			if line[0] != ' ' {
+				status.Staged++
+			}
+			if line[1] != ' ' {
+				status.Modified++
+			}
+		}
+	}
+}
+
+// changeCounts is the compact change summary, e.g. "3M 1U 2S", or "" for
+// a clean work tree
+func changeCounts(repo GitStatus) string {
+	var parts []string
+	for _, count := range []struct {
+		n    int
+		kind string
+	}{{repo.Modified, "M"}, {repo.Untracked, "U"}, {repo.Staged, "S"}} {
+		if count.n > 0 {
+			parts = append(parts, strconv.Itoa(count.n)+count.kind)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// statusLabel is the status message with the change counts and diffstat
+// after it
+func statusLabel(repo GitStatus) string {
+	counts := changeCounts(repo)
+	if stat := diffstat(repo); stat != "" {
+		counts = strings.TrimSpace(counts + " " + stat)
+	}
+	if counts != "" {
+		return repo.Message + " · " + counts
+	}
+	return repo.Message
+}
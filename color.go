@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+// ansiColorNames maps the color names themes may use to ANSI color numbers
+var ansiColorNames = map[string]string{
+	"black": "0", "red": "1", "green": "2", "yellow": "3",
+	"blue": "4", "magenta": "5", "cyan": "6", "white": "7",
+	"gray": "8", "grey": "8",
+}
+
+// themeColor turns a theme color (a name, an ANSI number or a hex value)
+// into something lipgloss understands
+func themeColor(value string) lipgloss.Color {
+	if number, ok := ansiColorNames[value]; ok {
+		return lipgloss.Color(number)
+	}
+	return lipgloss.Color(value)
+}
+
+// activeTheme is the --theme override if given, else the configured theme
+func activeTheme() ThemeConfig {
+	if config.Theme != "" {
+		if theme, err := loadTheme(config.Theme); err == nil {
+			return *theme
+		}
+	}
+	if userConfig, err := loadConfig(); err == nil && len(userConfig.Theme.Colors) > 0 {
+		return userConfig.Theme
+	}
+	return defaultThemes["matrix"]
+}
+
+func validColorMode(mode string) error {
+	switch mode {
+	case "auto", "always", "never":
+		return nil
+	}
+	return fmt.Errorf("invalid --color value %q (use auto, always or never)", mode)
+}
+
+// colorEnabled decides whether output to f gets colors: --color wins, then
+// NO_COLOR (https://no-color.org), then whether f is a terminal
+func colorEnabled(f *os.File) bool {
+	switch config.Color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// colorRenderer renders to w with colors on; callers decide beforehand
+// whether colors are wanted at all
+func colorRenderer(w io.Writer) *lipgloss.Renderer {
+	renderer := lipgloss.NewRenderer(w, termenv.WithUnsafe())
+	if renderer.ColorProfile() == termenv.Ascii {
+		renderer.SetColorProfile(termenv.ANSI256)
+	}
+	return renderer
+}
+
+// symbolColorKey maps a status symbol to the theme color used for it
+func symbolColorKey(symbol string) string {
+	switch symbol {
+	case "✓":
+		return "success"
+	case "✗", "⚠", "☠":
+		return "error"
+	case "↑", "↓", "↕":
+		return "warning"
+	}
+	return "info"
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// commits of history searched for a common ancestor between two clones
+const compareHistoryLimit = 5000
+
+type compareSide struct {
+	Repo     GitStatus
+	Branches []string
+	Recent   []string
+	Unique   int // commits since the common ancestor
+}
+
+type repoComparison struct {
+	Left, Right compareSide
+	Base        string // common ancestor, empty for unrelated repos
+}
+
+type comparisonMsg struct {
+	comparison repoComparison
+	err        error
+}
+
+func loadCompareSide(repo GitStatus) (compareSide, []string, error) {
+	side := compareSide{Repo: repo}
+	if out, err := runGit(repo.RepoPath, "for-each-ref", "--format=%(refname:short)", "refs/heads"); err == nil && out != "" {
+		side.Branches = strings.Split(out, "\n")
+	}
+	if out, err := runGit(repo.RepoPath, "log", "-8", "--format=%h %s (%cr)"); err == nil && out != "" {
+		side.Recent = strings.Split(out, "\n")
+	}
+	history, err := runGit(repo.RepoPath, "rev-list", fmt.Sprintf("--max-count=%d", compareHistoryLimit), "HEAD")
+	if err != nil {
+		return side, nil, err
+	}
+	return side, strings.Split(history, "\n"), nil
+}
+
+// compareRepos compares two separate clones (a fork and its upstream, say).
+// They don't share an object store, so the common ancestor is the newest
+// commit of one HEAD's history that also appears in the other's.
+func compareRepos(left, right GitStatus) (repoComparison, error) {
+	var c repoComparison
+	var leftHistory, rightHistory []string
+	var err error
+	if c.Left, leftHistory, err = loadCompareSide(left); err != nil {
+		return c, err
+	}
+	if c.Right, rightHistory, err = loadCompareSide(right); err != nil {
+		return c, err
+	}
+
+	rightIndex := make(map[string]int, len(rightHistory))
+	for i, hash := range rightHistory {
+		rightIndex[hash] = i
+	}
+	for i, hash := range leftHistory {
+		if j, ok := rightIndex[hash]; ok {
+			c.Base = hash
+			c.Left.Unique, c.Right.Unique = i, j
+			break
+		}
+	}
+	return c, nil
+}
+
+func compareReposCmd(left, right GitStatus) tea.Cmd {
+	return func() tea.Msg {
+		c, err := compareRepos(left, right)
+		return comparisonMsg{comparison: c, err: err}
+	}
+}
+
+func (c repoComparison) divergence() string {
+	if c.Base == "" {
+		return "No common history (within the last 5000 commits)"
+	}
+	if c.Left.Unique == 0 && c.Right.Unique == 0 {
+		return fmt.Sprintf("Both at %s", c.Base[:7])
+	}
+	return fmt.Sprintf("Common ancestor %s: %d commit(s) only on the left, %d only on the right",
+		c.Base[:7], c.Left.Unique, c.Right.Unique)
+}
+
+func renderCompareSide(side compareSide, width int) string {
+	name := side.Repo.RelativePath
+	if name == "" {
+		name = "."
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s %s\nBranch: %s\n", name, side.Repo.Symbol, side.Repo.Message, describeBranch(side.Repo))
+	fmt.Fprintf(&b, "\nBranches (%d)\n", len(side.Branches))
+	for i, branch := range side.Branches {
+		if i == 8 {
+			fmt.Fprintf(&b, "  … %d more\n", len(side.Branches)-i)
+			break
+		}
+		fmt.Fprintf(&b, "  %s\n", branch)
+	}
+	b.WriteString("\nRecent activity\n")
+	for _, line := range side.Recent {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Width(width).
+		Render(strings.TrimRight(b.String(), "\n"))
+}
+
+// renderComparison lays the two repos out side by side
+func renderComparison(c repoComparison, termWidth int) string {
+	width := termWidth/2 - 4
+	if width < 30 {
+		width = 30
+	}
+	return c.divergence() + "\n" + lipgloss.JoinHorizontal(lipgloss.Top,
+		renderCompareSide(c.Left, width),
+		renderCompareSide(c.Right, width),
+	)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -18,6 +19,21 @@ type UserConfig struct {
 	Behavior      BehaviorConfig      `json:"behavior"`
 	Notifications NotificationConfig  `json:"notifications"`
 	SkipDirs      []string            `json:"skip_directories"`
+	Forges        map[string]ForgeConfig `json:"forges,omitempty"`
+	Email         EmailConfig         `json:"email"`
+	Network       NetworkConfig       `json:"network"`
+	Archive       ArchiveConfig       `json:"archive"`
+	Team          TeamConfig          `json:"team"`
+	Sync          SyncConfig          `json:"sync"`
+	WatchBranches []string            `json:"watch_branches,omitempty"`
+	Mirrors       map[string][]string `json:"mirrors,omitempty"`
+	IgnoreDuplicates []string         `json:"ignore_duplicates,omitempty"`
+	Protected     []string            `json:"protected,omitempty"` // absolute repo paths never modified by this tool
+	AllowedRoots  []string            `json:"allowed_roots,omitempty"` // mutating commands only run under these
+	Pinned        []string            `json:"pinned,omitempty"`        // absolute repo paths listed first in the TUI
+	Hidden        []string            `json:"hidden,omitempty"`        // absolute repo paths left out of the TUI
+	TemplateMarkers []string          `json:"template_markers,omitempty"` // extra files naming a repo's template and version
+	TemplateHosts []string            `json:"template_hosts,omitempty"`   // hosts templates may be fetched from besides github.com, gitlab.com and the forges'
 }
 
 type ThemeConfig struct {
@@ -47,12 +63,15 @@ type DisplayConfig struct {
 	ShowBranch     bool   `json:"show_branch"`
 	ShowCommit     bool   `json:"show_last_commit"`
 	ShowTimestamp  bool   `json:"show_timestamp"`
+	ShowAuthor     bool   `json:"show_author"`
+	ShowDiffstat   bool   `json:"show_diffstat"`
 	CompactMode    bool   `json:"compact_mode"`
 	TreeView       bool   `json:"tree_view"`
 	TimeFormat     string `json:"time_format"`
 	FlashOnChange  bool   `json:"flash_on_change"`
 	ShowIcons      bool   `json:"show_icons"`
 	GroupByStatus  bool   `json:"group_by_status"`
+	SplitView      bool   `json:"split_view"`
 }
 
 type FilterConfig struct {
@@ -63,6 +82,7 @@ type FilterConfig struct {
 	ShowError     bool     `json:"show_error"`
 	HiddenStates  []string `json:"hidden_states"`
 	OnlyRecent    bool     `json:"only_recent"`
+	OnlyMine      bool     `json:"only_mine"` // --mine on every scan
 	RecentDays    int      `json:"recent_days"`
 }
 
@@ -76,6 +96,11 @@ type BehaviorConfig struct {
 	SoundOnChange   bool   `json:"sound_on_change"`
 	NotifyOnChange  bool   `json:"notify_on_change"`
 	ExitOnComplete  bool   `json:"exit_on_complete"`
+	TerminalCommand    string `json:"terminal_command,omitempty"`     // {path} is replaced by the repo path
+	FileManagerCommand string `json:"file_manager_command,omitempty"` // defaults to open/explorer/xdg-open
+	EditorCommand      string `json:"editor_command,omitempty"`       // defaults to $VISUAL, then $EDITOR
+	OpenCommand        string `json:"open_command,omitempty"`         // run by ! in place of $SHELL
+	BulkConfirmThreshold int  `json:"bulk_confirm_threshold,omitempty"` // repos a bulk operation may touch before typed confirmation
 }
 
 type NotificationConfig struct {
@@ -260,7 +285,11 @@ func saveConfig(config *UserConfig) error {
 		return err
 	}
 
-	return os.WriteFile(configFile, data, 0644)
+	// The config can hold forge tokens and passwords, so it is only readable
+	// by its owner; the rename replaces a file saved with looser permissions.
+	return withLock("config", func() error {
+		return writeFileAtomic(configFile, data, 0600)
+	})
 }
 
 func getDefaultConfig() *UserConfig {
@@ -505,13 +534,117 @@ func setConfigValue(key, value string) {
 		setPerformanceConfig(config, strings.TrimPrefix(key, "performance."), value)
 	case strings.HasPrefix(key, "notifications."):
 		setNotificationConfig(config, strings.TrimPrefix(key, "notifications."), value)
+	case strings.HasPrefix(key, "email."):
+		setEmailConfig(config, strings.TrimPrefix(key, "email."), value)
+	case strings.HasPrefix(key, "network."):
+		if err := setNetworkConfig(config, strings.TrimPrefix(key, "network."), value); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+	case key == "watch_branches":
+		config.WatchBranches = nil
+		for _, pattern := range strings.Split(value, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				config.WatchBranches = append(config.WatchBranches, pattern)
+			}
+		}
+	case key == "template_markers":
+		config.TemplateMarkers = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				config.TemplateMarkers = append(config.TemplateMarkers, name)
+			}
+		}
+	case key == "template_hosts":
+		config.TemplateHosts = nil
+		for _, host := range strings.Split(value, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				config.TemplateHosts = append(config.TemplateHosts, strings.ToLower(host))
+			}
+		}
+	case strings.HasPrefix(key, "mirrors."):
+		repo := strings.TrimPrefix(key, "mirrors.")
+		if config.Mirrors == nil {
+			config.Mirrors = make(map[string][]string)
+		}
+		config.Mirrors[repo] = nil
+		for _, url := range strings.Split(value, ",") {
+			if url = strings.TrimSpace(url); url != "" {
+				config.Mirrors[repo] = append(config.Mirrors[repo], url)
+			}
+		}
+		if len(config.Mirrors[repo]) == 0 {
+			delete(config.Mirrors, repo)
+		}
+	case key == "protected":
+		paths, err := configPaths(value)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		config.Protected = paths
+	case key == "pinned":
+		paths, err := configPaths(value)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		config.Pinned = paths
+	case key == "hidden":
+		paths, err := configPaths(value)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		config.Hidden = paths
+	case key == "allowed_roots":
+		paths, err := configPaths(value)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		config.AllowedRoots = paths
+	case key == "archive.directory":
+		config.Archive.Directory = value
+	case strings.HasPrefix(key, "sync."):
+		if err := setSyncConfig(config, strings.TrimPrefix(key, "sync."), value); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+	case strings.HasPrefix(key, "team."):
+		if err := setTeamConfig(config, strings.TrimPrefix(key, "team."), value); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+	case strings.HasPrefix(key, "forges."):
+		if err := setForgeConfig(config, strings.TrimPrefix(key, "forges."), value); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
 	default:
 		fmt.Printf("Unknown config key: %s\n", key)
 		fmt.Println("Available keys:")
 		fmt.Println("  display.tree_view, display.flash_on_change, display.show_timestamp")
-		fmt.Println("  filter.show_synced, filter.only_recent, filter.recent_days")
+		fmt.Println("  display.show_author, display.show_diffstat")
+		fmt.Println("  display.time_format, display.column_width")
+		fmt.Println("  filter.show_synced, filter.only_recent, filter.recent_days, filter.only_mine")
 		fmt.Println("  behavior.refresh_interval, behavior.ttl_mode, behavior.ttl_seconds")
+		fmt.Println("  behavior.terminal_command, behavior.file_manager_command, behavior.editor_command")
+		fmt.Println("  behavior.open_command, behavior.bulk_confirm_threshold")
 		fmt.Println("  performance.workers, performance.timeout")
+		fmt.Println("  email.smtp_host, email.smtp_port, email.username, email.password_env, email.from")
+		fmt.Println("  network.proxy, network.no_proxy, network.ca_bundle (PEM file)")
+		fmt.Println("  archive.directory, watch_branches (comma-separated patterns)")
+		fmt.Println("  team.url, team.name, team.token_env, team.token")
+		fmt.Println("  sync.backend (git or webdav), sync.url, sync.username, sync.password_env, sync.host")
+		fmt.Println("  protected (comma-separated repo paths never modified)")
+		fmt.Println("  allowed_roots (comma-separated directories mutating commands may run in)")
+		fmt.Println("  pinned (comma-separated repo paths always listed first in the TUI)")
+		fmt.Println("  hidden (comma-separated repo paths left out of the TUI; see config unhide)")
+		fmt.Println("  mirrors.<repo path> (comma-separated mirror URLs)")
+		fmt.Println("  template_markers (comma-separated file names read like .template-version)")
+		fmt.Println("  template_hosts (comma-separated hosts templates may be fetched from)")
+		fmt.Println("  forges.<name>.type, forges.<name>.host, forges.<name>.token_env, forges.<name>.owner, forges.<name>.client_id")
 		return
 	}
 
@@ -531,6 +664,16 @@ func setDisplayConfig(config *UserConfig, key, value string) {
 		config.Display.FlashOnChange = value == "true"
 	case "show_timestamp":
 		config.Display.ShowTimestamp = value == "true"
+	case "show_author":
+		config.Display.ShowAuthor = value == "true"
+	case "show_diffstat":
+		config.Display.ShowDiffstat = value == "true"
+	case "time_format":
+		config.Display.TimeFormat = value
+	case "column_width":
+		if width, err := strconv.Atoi(value); err == nil {
+			config.Display.ColumnWidth = width
+		}
 	case "show_branch":
 		config.Display.ShowBranch = value == "true"
 	case "show_commit":
@@ -541,6 +684,8 @@ func setDisplayConfig(config *UserConfig, key, value string) {
 		config.Display.ShowIcons = value == "true"
 	case "group_by_status":
 		config.Display.GroupByStatus = value == "true"
+	case "split_view":
+		config.Display.SplitView = value == "true"
 	}
 }
 
@@ -558,6 +703,8 @@ func setFilterConfig(config *UserConfig, key, value string) {
 		config.Filter.ShowError = value == "true"
 	case "only_recent":
 		config.Filter.OnlyRecent = value == "true"
+	case "only_mine":
+		config.Filter.OnlyMine = value == "true"
 	case "recent_days":
 		if days, err := strconv.Atoi(value); err == nil {
 			config.Filter.RecentDays = days
@@ -587,6 +734,18 @@ func setBehaviorConfig(config *UserConfig, key, value string) {
 		config.Behavior.NotifyOnChange = value == "true"
 	case "exit_on_complete":
 		config.Behavior.ExitOnComplete = value == "true"
+	case "terminal_command":
+		config.Behavior.TerminalCommand = value
+	case "file_manager_command":
+		config.Behavior.FileManagerCommand = value
+	case "editor_command":
+		config.Behavior.EditorCommand = value
+	case "open_command":
+		config.Behavior.OpenCommand = value
+	case "bulk_confirm_threshold":
+		if threshold, err := strconv.Atoi(value); err == nil {
+			config.Behavior.BulkConfirmThreshold = threshold
+		}
 	}
 }
 
@@ -622,4 +781,118 @@ func setNotificationConfig(config *UserConfig, key, value string) {
 	case "message":
 		config.Notifications.Message = value
 	}
-}
\ No newline at end of file
+}
+func setEmailConfig(config *UserConfig, key, value string) {
+	switch key {
+	case "smtp_host":
+		config.Email.SMTPHost = value
+	case "smtp_port":
+		if port, err := strconv.Atoi(value); err == nil {
+			config.Email.SMTPPort = port
+		}
+	case "username":
+		config.Email.Username = value
+	case "password":
+		config.Email.Password = value
+	case "password_env":
+		config.Email.PasswordEnv = value
+	case "from":
+		config.Email.From = value
+	case "tls":
+		config.Email.TLS = value == "true"
+	}
+}
+
+func setSyncConfig(config *UserConfig, key, value string) error {
+	switch key {
+	case "backend":
+		if value != "" && !slices.Contains(syncBackends, value) {
+			return fmt.Errorf("unknown sync backend %q (available: %s)", value, strings.Join(syncBackends, ", "))
+		}
+		config.Sync.Backend = value
+	case "url":
+		config.Sync.URL = value
+	case "username":
+		config.Sync.Username = value
+	case "password_env":
+		config.Sync.PasswordEnv = value
+	case "host":
+		config.Sync.Host = value
+	default:
+		return fmt.Errorf("unknown sync setting %q (backend, url, username, password_env, host)", key)
+	}
+	return nil
+}
+
+func setTeamConfig(config *UserConfig, key, value string) error {
+	switch key {
+	case "url":
+		config.Team.URL = value
+	case "name":
+		config.Team.Name = value
+	case "token":
+		config.Team.Token = value
+	case "token_env":
+		config.Team.TokenEnv = value
+	default:
+		return fmt.Errorf("unknown team setting %q (url, name, token, token_env)", key)
+	}
+	return nil
+}
+
+func setNetworkConfig(config *UserConfig, key, value string) error {
+	switch key {
+	case "proxy":
+		config.Network.Proxy = value
+	case "no_proxy":
+		config.Network.NoProxy = value
+	case "ca_bundle":
+		paths, err := configPaths(value)
+		if err != nil {
+			return err
+		}
+		config.Network.CABundle = ""
+		if len(paths) > 0 {
+			config.Network.CABundle = paths[0]
+		}
+	default:
+		return fmt.Errorf("unknown network setting %q", key)
+	}
+	return nil
+}
+
+func setForgeConfig(config *UserConfig, key, value string) error {
+	name, field, ok := strings.Cut(key, ".")
+	if !ok || name == "" {
+		return fmt.Errorf("expected forges.<name>.<field>")
+	}
+
+	if config.Forges == nil {
+		config.Forges = make(map[string]ForgeConfig)
+	}
+	forge := config.Forges[name]
+
+	switch field {
+	case "type":
+		forge.Type = value
+	case "host":
+		forge.Host = value
+	case "api_url":
+		forge.APIURL = value
+	case "token":
+		forge.Token = value
+	case "token_env":
+		forge.TokenEnv = value
+	case "owner":
+		forge.Owner = value
+	case "protocol":
+		forge.Protocol = value
+	case "client_id":
+		forge.ClientID = value
+	default:
+		return fmt.Errorf("unknown forge field '%s'", field)
+	}
+
+	config.Forges[name] = forge
+	return nil
+}
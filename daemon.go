@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// The daemon is the job the "service" integration installs: a systemd user
+// timer or a launchd agent running motd --refresh every 15 minutes, which
+// keeps the saved scan motd, tmux and xbar read from warm and runs the
+// daily integrity checks.
+const (
+	systemdUnit  = "git-status-dash-refresh"
+	launchdLabel = "com.github.zkbkb.git-status-dash"
+)
+
+// Label is the launchd label, for the plist template
+func (integrationEnv) Label() string {
+	return launchdLabel
+}
+
+func launchdPlist(home string) string {
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist")
+}
+
+// daemonSupported says why the daemon can't be managed here, if it can't
+func daemonSupported() error {
+	switch runtime.GOOS {
+	case "linux":
+		if _, err := exec.LookPath("systemctl"); err != nil {
+			return fmt.Errorf("systemctl not found; schedule `git-status-dash motd --refresh <dir>` with cron instead")
+		}
+		return nil
+	case "darwin":
+		return nil
+	}
+	return fmt.Errorf("the daemon needs systemd or launchd; on %s schedule `git-status-dash motd --refresh <dir>` yourself", runtime.GOOS)
+}
+
+// serviceCommand runs systemctl or launchctl, passing its output through
+func serviceCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %v", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// daemonInstalled reports whether the unit or plist has been written
+func daemonInstalled() bool {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "darwin" {
+		return fileExists(launchdPlist(home))
+	}
+	return fileExists(filepath.Join(home, ".config", "systemd", "user", systemdUnit+".timer"))
+}
+
+// runDaemonInstall writes the unit or plist for dir, replacing an older one
+// so a changed directory or binary path takes effect, and reloads systemd
+func runDaemonInstall(root *cobra.Command, dir string) error {
+	if err := daemonSupported(); err != nil {
+		return err
+	}
+	if err := runInstallIntegrations(root, dir, []string{"service"}, false, true); err != nil {
+		return err
+	}
+	if runtime.GOOS == "linux" {
+		return serviceCommand("systemctl", "--user", "daemon-reload")
+	}
+	return nil
+}
+
+func runDaemonStart() error {
+	if err := daemonSupported(); err != nil {
+		return err
+	}
+	if !daemonInstalled() {
+		return fmt.Errorf("the daemon isn't installed; run `git-status-dash daemon install` first")
+	}
+	if runtime.GOOS == "darwin" {
+		home, _ := os.UserHomeDir()
+		if err := serviceCommand("launchctl", "load", "-w", launchdPlist(home)); err != nil {
+			return err
+		}
+	} else if err := serviceCommand("systemctl", "--user", "enable", "--now", systemdUnit+".timer"); err != nil {
+		return err
+	}
+	fmt.Println("✓ Daemon started; it rescans every 15 minutes")
+	return nil
+}
+
+func runDaemonStop() error {
+	if err := daemonSupported(); err != nil {
+		return err
+	}
+	if runtime.GOOS == "darwin" {
+		home, _ := os.UserHomeDir()
+		if err := serviceCommand("launchctl", "unload", "-w", launchdPlist(home)); err != nil {
+			return err
+		}
+	} else if err := serviceCommand("systemctl", "--user", "disable", "--now", systemdUnit+".timer"); err != nil {
+		return err
+	}
+	fmt.Println("✓ Daemon stopped")
+	return nil
+}
+
+// runDaemonStatus says whether the daemon is installed and running, and
+// how old the scan of dir it keeps warm is. It returns false when the
+// daemon isn't running.
+func runDaemonStatus(dir string) (bool, error) {
+	if err := daemonSupported(); err != nil {
+		return false, err
+	}
+	running := false
+	switch {
+	case !daemonInstalled():
+		fmt.Println("✗ Not installed (git-status-dash daemon install)")
+	case runtime.GOOS == "darwin":
+		running = exec.Command("launchctl", "list", launchdLabel).Run() == nil
+	default:
+		out, _ := exec.Command("systemctl", "--user", "is-active", systemdUnit+".timer").Output()
+		running = strings.TrimSpace(string(out)) == "active"
+	}
+	if daemonInstalled() {
+		if running {
+			fmt.Println("✓ Running")
+		} else {
+			fmt.Println("- Installed but stopped (git-status-dash daemon start)")
+		}
+	}
+
+	snapshot, err := loadSnapshot(dir)
+	switch {
+	case os.IsNotExist(err):
+		fmt.Printf("- No saved scan of %s yet\n", dir)
+	case err != nil:
+		fmt.Printf("⚠ Saved scan of %s unreadable: %v\n", dir, err)
+	case time.Since(snapshot.ScannedAt) > time.Hour:
+		fmt.Printf("⚠ Last scan of %s: %s, more than an hour ago\n", dir, relativeTime(snapshot.ScannedAt))
+	default:
+		fmt.Printf("✓ Last scan of %s: %s\n", dir, relativeTime(snapshot.ScannedAt))
+	}
+	return running, nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// config keys whose values never leave the machine
+var secretConfigKeys = map[string]bool{"token": true, "password": true, "username": true}
+
+type bundleRepo struct {
+	Path          string `json:"path"`
+	Symbol        string `json:"symbol"`
+	Message       string `json:"message"`
+	Branch        string `json:"branch"`
+	DefaultBranch string `json:"default_branch"`
+	HasRemote     bool   `json:"has_remote"`
+	HasUpstream   bool   `json:"has_upstream"`
+	Ahead         int    `json:"ahead"`
+	Behind        int    `json:"behind"`
+	DurationMS    int64  `json:"duration_ms"`
+}
+
+type bundleScan struct {
+	SchemaVersion int          `json:"schema_version"`
+	Version       string       `json:"version"`
+	Directory     string       `json:"directory"`
+	Depth         int          `json:"depth"`
+	DiscoveryMS   int64        `json:"discovery_ms"`
+	ScanMS        int64        `json:"scan_ms"`
+	Repos         []bundleRepo `json:"repos"`
+}
+
+// maskHome replaces the home directory in bundled text, which usually
+// carries the user name
+func maskHome(text string) string {
+	if home, err := os.UserHomeDir(); err == nil && home != "" && home != "/" {
+		return strings.ReplaceAll(text, home, "~")
+	}
+	return text
+}
+
+// bundledURL finds URLs in bundled text, such as a remote in git's error
+var bundledURL = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s'"<>]+`)
+
+// maskCredentials drops passwords and tokens from every URL in bundled
+// text: config values like sync.url and team.url, and remotes in git's
+// messages and the log
+func maskCredentials(text string) string {
+	return bundledURL.ReplaceAllStringFunc(text, withoutCredentials)
+}
+
+// redactSecrets replaces secret values anywhere in a decoded JSON document
+func redactSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if s, ok := child.(string); ok && secretConfigKeys[key] && s != "" {
+				v[key] = "REDACTED"
+				continue
+			}
+			v[key] = redactSecrets(child)
+		}
+	case string:
+		return maskCredentials(v)
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactSecrets(child)
+		}
+	}
+	return value
+}
+
+// bundleConfig is the config file with secrets redacted. A file that isn't
+// valid JSON is described rather than copied, since it can't be redacted.
+func bundleConfig() []byte {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return []byte(fmt.Sprintf("could not locate config: %v\n", err))
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if os.IsNotExist(err) {
+		return []byte("no config file, defaults in use\n")
+	} else if err != nil {
+		return []byte(fmt.Sprintf("could not read config: %v\n", err))
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []byte(fmt.Sprintf("config.json is not valid JSON (%v), contents left out\n", err))
+	}
+	out, _ := json.MarshalIndent(redactSecrets(doc), "", "  ")
+	return append(out, '\n')
+}
+
+// bundleEnvironment describes the platform and the git install
+func bundleEnvironment(baseDir string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "os/arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "go: %s\n", runtime.Version())
+	fmt.Fprintf(&b, "cpus: %d\n", runtime.NumCPU())
+	if out, err := gitCommand(context.Background(), "--version").Output(); err == nil {
+		fmt.Fprintf(&b, "git: %s\n", strings.TrimSpace(string(out)))
+	} else {
+		fmt.Fprintf(&b, "git: not found (%v)\n", err)
+	}
+	if configDir, err := getConfigDir(); err == nil {
+		fmt.Fprintf(&b, "config dir: %s\n", configDir)
+	}
+	fmt.Fprintf(&b, "scan dir: %s\n", baseDir)
+	for _, name := range []string{"TERM", "COLORTERM", "NO_COLOR", "LANG", "SHELL", "PAGER", "GIT_DIR", "GIT_WORK_TREE"} {
+		if value, ok := os.LookupEnv(name); ok {
+			fmt.Fprintf(&b, "%s=%s\n", name, value)
+		}
+	}
+	return []byte(b.String())
+}
+
+// bundleScanRepos scans like the report does, timing discovery and every
+// repo. Repos that fail are re-run through git so its error lands in the log.
+func bundleScanRepos(baseDir string, depth int) bundleScan {
+	scan := bundleScan{SchemaVersion: schemaVersion(), Version: version, Directory: baseDir, Depth: depth}
+
+	start := time.Now()
+	paths := make(chan string, 100)
+	go func() {
+		defer close(paths)
+		walkReposOptimized(baseDir, baseDir, 0, depth, paths)
+	}()
+	var repoPaths []string
+	for path := range paths {
+		repoPaths = append(repoPaths, path)
+	}
+	scan.DiscoveryMS = time.Since(start).Milliseconds()
+
+	start = time.Now()
+	scan.Repos = make([]bundleRepo, len(repoPaths))
+	semaphore := make(chan struct{}, min(runtime.NumCPU()*2, 16)) // same cap as the report's worker pool
+	var wg sync.WaitGroup
+	for i, path := range repoPaths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			began := time.Now()
+			status := getGitStatusOptimized(path, baseDir)
+			scan.Repos[i] = bundleRepo{
+				Path:          status.RelativePath,
+				Symbol:        status.Symbol,
+				Message:       status.Message,
+				Branch:        status.Branch,
+				DefaultBranch: status.DefaultBranch,
+				HasRemote:     status.HasRemote,
+				HasUpstream:   status.HasUpstream,
+				Ahead:         status.Ahead,
+				Behind:        status.Behind,
+				DurationMS:    time.Since(began).Milliseconds(),
+			}
+			if status.Symbol == "⚠" {
+				if _, err := runGit(path, "status", "--porcelain", "--untracked-files=no"); err != nil {
+					log.Printf("%s: git status: %v", status.RelativePath, err)
+				} else {
+					log.Printf("%s: %s (git status succeeds when run alone)", status.RelativePath, status.Message)
+				}
+			}
+		}(i, path)
+	}
+	wg.Wait()
+	scan.ScanMS = time.Since(start).Milliseconds()
+
+	sort.Slice(scan.Repos, func(i, j int) bool { return scan.Repos[i].Path < scan.Repos[j].Path })
+	return scan
+}
+
+// runDebugBundle writes a zip to attach to bug reports: the redacted config,
+// the scan result with timings, anything logged while scanning and the
+// environment. The home directory is masked throughout.
+func runDebugBundle(baseDir, output string) error {
+	abs, err := filepath.Abs(baseDir)
+	if err != nil {
+		return err
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(io.MultiWriter(os.Stderr, &logs))
+	scan := bundleScanRepos(abs, config.Depth)
+	log.SetOutput(os.Stderr)
+
+	scanJSON, err := json.MarshalIndent(scan, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"environment.txt", bundleEnvironment(abs)},
+		{"config.json", bundleConfig()},
+		{"scan.json", append(scanJSON, '\n')},
+		{"log.txt", logs.Bytes()},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte(maskCredentials(maskHome(string(file.data))))); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(output, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote %s (%d repositories, scanned in %dms)\n", output, len(scan.Repos), scan.DiscoveryMS+scan.ScanMS)
+	fmt.Println("  Secrets, credentials in URLs and your home directory are masked; check the contents before sharing.")
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// longest description shown in the detail view
+const maxDescriptionLength = 240
+
+// repoDescription says what a repo is, from its forge or its README
+type repoDescription struct {
+	RepoPath string
+	Text     string
+	Source   string // "forge" or the README's file name
+}
+
+type descriptionMsg repoDescription
+
+// descriptions caches lookups for the session so moving through the detail
+// view doesn't query the forge again
+var descriptions sync.Map
+
+// forgeDescription asks the configured forge hosting origin for the repo's
+// description. Only forges listed under "forges" are queried.
+func forgeDescription(repoPath string) string {
+	origin, err := runGit(repoPath, "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	forge, project, err := forgeForRemote(origin)
+	if err != nil {
+		return ""
+	}
+	path := "/repos/" + project
+	if forge.Type == "gitlab" {
+		path = "/projects/" + url.PathEscape(project)
+	}
+	var repo struct {
+		Description string `json:"description"`
+	}
+	if forgeRequest(forge, "GET", path, nil, &repo) != nil {
+		return ""
+	}
+	return strings.TrimSpace(repo.Description)
+}
+
+// readmeSummary is the first heading and paragraph of a README, skipping
+// badges, HTML and code blocks
+func readmeSummary(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	var heading string
+	var paragraph []string
+	inCode := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "```"):
+			inCode = !inCode
+		case inCode:
+		case line == "":
+			if len(paragraph) > 0 {
+				return joinSummary(heading, paragraph)
+			}
+		case strings.HasPrefix(line, "#"):
+			if len(paragraph) > 0 {
+				return joinSummary(heading, paragraph)
+			}
+			if heading == "" {
+				heading = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			}
+		case strings.HasPrefix(line, "[!["), strings.HasPrefix(line, "!["), strings.HasPrefix(line, "<"),
+			strings.Trim(line, "=-") == "":
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	return joinSummary(heading, paragraph)
+}
+
+func joinSummary(heading string, paragraph []string) string {
+	text := strings.Join(paragraph, " ")
+	if heading != "" && text != "" {
+		text = heading + ": " + text
+	} else if text == "" {
+		text = heading
+	}
+	return text
+}
+
+// findReadme returns the README in the repo root, preferring Markdown
+func findReadme(repoPath string) string {
+	entries, err := os.ReadDir(repoPath)
+	if err != nil {
+		return ""
+	}
+	found := ""
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		if entry.IsDir() || !strings.HasPrefix(name, "readme") {
+			continue
+		}
+		if name == "readme.md" {
+			return entry.Name()
+		}
+		if found == "" {
+			found = entry.Name()
+		}
+	}
+	return found
+}
+
+// loadDescription prefers the forge's description and falls back to the README
+func loadDescription(repoPath string) repoDescription {
+	if cached, ok := descriptions.Load(repoPath); ok {
+		return cached.(repoDescription)
+	}
+	description := repoDescription{RepoPath: repoPath}
+	if text := forgeDescription(repoPath); text != "" {
+		description.Text, description.Source = text, "forge"
+	} else if readme := findReadme(repoPath); readme != "" {
+		description.Text, description.Source = readmeSummary(filepath.Join(repoPath, readme)), readme
+	}
+	if runes := []rune(description.Text); len(runes) > maxDescriptionLength {
+		description.Text = string(runes[:maxDescriptionLength-1]) + "…"
+	}
+	descriptions.Store(repoPath, description)
+	return description
+}
+
+func loadDescriptionCmd(repoPath string) tea.Cmd {
+	return func() tea.Msg {
+		return descriptionMsg(loadDescription(repoPath))
+	}
+}
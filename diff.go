@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// emptyTree is git's empty tree, the base for repos without a commit yet
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// diffPane is the "d" overlay with a repo's uncommitted changes: the
+// --stat summary first, the whole colored diff after another d
+type diffPane struct {
+	repo   GitStatus
+	full   bool
+	loaded bool
+	view   viewport.Model
+}
+
+type diffMsg struct {
+	repoPath string
+	full     bool
+	text     string
+}
+
+// loadDiffCmd diffs the working tree, staged changes included, against
+// HEAD and lists untracked files, which git diff leaves out
+func loadDiffCmd(repoPath string, full bool) tea.Cmd {
+	return func() tea.Msg {
+		base := "HEAD"
+		if _, err := runGit(repoPath, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+			base = emptyTree
+		}
+		args := []string{"diff", base, "--stat"}
+		if full {
+			args = []string{"diff", base, "--color=always"}
+		}
+		// not runGit, whose trimming would eat the first line's indent
+		data, err := gitCommand(context.Background(), append([]string{"-C", repoPath}, args...)...).Output()
+		if err != nil {
+			return diffMsg{repoPath, full, fmt.Sprintf("✗ git diff failed: %v", err)}
+		}
+		out := strings.TrimRight(string(data), "\n")
+		var sections []string
+		if out != "" {
+			sections = append(sections, out)
+		}
+		if untracked, err := runGit(repoPath, "ls-files", "--others", "--exclude-standard"); err == nil && untracked != "" {
+			sections = append(sections, "Untracked files:\n  "+strings.ReplaceAll(untracked, "\n", "\n  "))
+		}
+		if len(sections) == 0 {
+			sections = append(sections, "No uncommitted changes")
+		}
+		return diffMsg{repoPath, full, strings.Join(sections, "\n\n")}
+	}
+}
+
+// openDiff shows the stat of the selected repo's changes
+func (m model) openDiff() (model, tea.Cmd) {
+	if m.cursor >= len(m.repos) {
+		return m, nil
+	}
+	repo := m.repos[m.cursor]
+	m.diff = &diffPane{repo: repo, view: viewport.New(m.termWidth, max(1, m.termHeight-2))}
+	m.diff.view.SetContent("Loading...")
+	return m, loadDiffCmd(repo.RepoPath, false)
+}
+
+// updateDiff scrolls the pane; d switches between the stat and the full
+// diff and esc or q closes it
+func (m model) updateDiff(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.diff = nil
+		return m, nil
+	case "d":
+		m.diff.full = !m.diff.full
+		m.diff.loaded = false
+		return m, loadDiffCmd(m.diff.repo.RepoPath, m.diff.full)
+	case "g", "home":
+		m.diff.view.GotoTop()
+		return m, nil
+	case "G", "end":
+		m.diff.view.GotoBottom()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.diff.view, cmd = m.diff.view.Update(msg)
+	return m, cmd
+}
+
+// diffView fills the screen with the pane, a title line above and the keys
+// below
+func (m model) diffView() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+
+	mode, other := "summary", "full diff"
+	if m.diff.full {
+		mode, other = "full diff", "summary"
+	}
+	title := titleStyle.Render("Uncommitted changes in "+displayName(m.diff.repo)) + dimStyle.Render("  "+mode)
+	if !m.diff.loaded {
+		title += dimStyle.Render("  loading...")
+	} else if !m.diff.view.AtBottom() || !m.diff.view.AtTop() {
+		title += dimStyle.Render(fmt.Sprintf("  %3.f%%", m.diff.view.ScrollPercent()*100))
+	}
+	help := dimStyle.Render("j/k/pgup/pgdown: scroll • d: " + other + " • esc: close")
+	return lipgloss.NewStyle().MaxWidth(m.termWidth).Render(title) + "\n" + m.diff.view.View() + "\n" + help
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// shortstatCounts picks the numbers out of `git diff --shortstat`, e.g.
+// " 3 files changed, 10 insertions(+), 2 deletions(-)"
+var shortstatCounts = regexp.MustCompile(`(\d+) (insertion|deletion)`)
+
+// addDiffstat fills in the lines added and removed by a dirty repo's
+// uncommitted changes, staged or not. It costs a git call per dirty repo,
+// so it only runs with display.show_diffstat on.
+func addDiffstat(ctx context.Context, status *GitStatus) {
+	if !status.Dirty || !displayConfig().ShowDiffstat {
+		return
+	}
+	// "." limits the diff to the directory under --subdirs
+	out, err := gitCommand(ctx, "-C", status.RepoPath, "diff", "HEAD", "--shortstat", "--", ".").Output()
+	if err != nil {
+		return
+	}
+	for _, match := range shortstatCounts.FindAllStringSubmatch(string(out), -1) {
+		n, _ := strconv.Atoi(match[1])
+		if match[2] == "insertion" {
+			status.Insertions = n
+		} else {
+			status.Deletions = n
+		}
+	}
+	status.HasDiffstat = true
+}
+
+// diffstat is the "+10/-2" summary, or "" when it wasn't measured
+func diffstat(repo GitStatus) string {
+	if !repo.HasDiffstat {
+		return ""
+	}
+	return fmt.Sprintf("+%d/-%d", repo.Insertions, repo.Deletions)
+}
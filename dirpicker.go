@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/filepicker"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// dirPickerHeight is how many directories the picker lists at once
+const dirPickerHeight = 10
+
+// dirPicker is the "D" box for switching the scan root without restarting
+type dirPicker struct {
+	picker filepicker.Model
+}
+
+// openDirPicker starts browsing in the parent of the current root, so its
+// siblings, usually the other workspaces, are one keypress away
+func (m model) openDirPicker() (model, tea.Cmd) {
+	start, err := filepath.Abs(m.baseDir)
+	if err != nil {
+		m.notice = fmt.Sprintf("✗ %v", err)
+		return m, nil
+	}
+	picker := filepicker.New()
+	picker.CurrentDirectory = filepath.Dir(start)
+	picker.DirAllowed = true
+	picker.FileAllowed = false
+	picker.ShowPermissions = false
+	picker.ShowSize = false
+	picker.AutoHeight = false
+	picker.SetHeight(dirPickerHeight)
+	// esc closes the box rather than going up, which h and backspace still do
+	picker.KeyMap.Back = key.NewBinding(key.WithKeys("h", "left", "backspace"))
+	m.dirPicker = &dirPicker{picker: picker}
+	return m, picker.Init()
+}
+
+func (m model) updateDirPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "D":
+		m.dirPicker = nil
+		return m, nil
+	case ".":
+		// the directory being browsed, rather than one inside it
+		dir := m.dirPicker.picker.CurrentDirectory
+		m.dirPicker = nil
+		return m.changeRoot(dir)
+	case "enter":
+		// filepicker sets Path to the highlighted directory as it opens it
+		m.dirPicker.picker.Path = ""
+		picker, _ := m.dirPicker.picker.Update(msg)
+		if picker.Path != "" {
+			m.dirPicker = nil
+			return m.changeRoot(picker.Path)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.dirPicker.picker, cmd = m.dirPicker.picker.Update(msg)
+	return m, cmd
+}
+
+// changeRoot switches the TUI to scan dir: everything about the old root
+// is dropped, the file watch moves over and a scan starts
+func (m model) changeRoot(dir string) (model, tea.Cmd) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		m.notice = fmt.Sprintf("✗ %s is not a directory", dir)
+		return m, nil
+	}
+	if err := checkDashboardRoot(dir); err != nil {
+		m.notice = "✗ " + err.Error()
+		return m, nil
+	}
+	if current, err := filepath.Abs(m.baseDir); err == nil && current == dir {
+		m.notice = fmt.Sprintf("Already scanning %s", dir)
+		return m, nil
+	}
+
+	m.baseDir = dir
+	m.config.Directory = dir
+	m.cache = make(map[string]GitStatus)
+	m.scanned, m.found, m.repos, m.discovered = nil, nil, nil, nil
+	m.cursor = 0
+	m.marked = make(map[string]bool)
+	m.visualFrom = ""
+	m.hidden = make(map[string]bool)
+	m.showDetail = false
+	m.compareWith = ""
+	m.triageLoaded = false
+	m.loading = true
+
+	if m.watcher != nil {
+		for _, path := range m.watcher.WatchList() {
+			m.watcher.Remove(path)
+		}
+		m.treeWatch.moveTo(dir, m.watcher)
+	}
+	m.notice = fmt.Sprintf("Switched the scan root to %s", dir)
+	if m.treeWatch != nil && !m.treeWatch.owns() {
+		m.notice = m.treeWatch.followNotice()
+	}
+	return m, scanRepos(m.baseDir, m.config.Depth, m.cache)
+}
+
+// scannedElsewhere reports whether a scan result is for a root the TUI has
+// since switched away from
+func (m model) scannedElsewhere(repos []GitStatus) bool {
+	root := filepath.Clean(m.baseDir) + string(filepath.Separator)
+	return len(repos) > 0 && !strings.HasPrefix(filepath.Clean(repos[0].RepoPath)+string(filepath.Separator), root)
+}
+
+func (p dirPicker) view(width int) string {
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		MaxWidth(width)
+	return boxStyle.Render("Scan root: " + p.picker.CurrentDirectory + "\n\n" + strings.TrimRight(p.picker.View(), "\n"))
+}
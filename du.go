@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type repoUsage struct {
+	Repo     GitStatus
+	GitDir   int64
+	Worktree int64
+}
+
+func (u repoUsage) total() int64 { return u.GitDir + u.Worktree }
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// dirSize sums regular file sizes under root. Nested repositories are
+// skipped so they aren't counted twice.
+func dirSize(root string, skipNestedRepos bool) int64 {
+	var size int64
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && skipNestedRepos {
+				if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			if path != root && d.Name() == ".git" && skipNestedRepos {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func measureUsage(repos []GitStatus) []repoUsage {
+	usage := make([]repoUsage, len(repos))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo GitStatus) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			usage[i] = repoUsage{
+				Repo:     repo,
+				GitDir:   dirSize(filepath.Join(repo.RepoPath, ".git"), false),
+				Worktree: dirSize(repo.RepoPath, true),
+			}
+		}(i, repo)
+	}
+	wg.Wait()
+	return usage
+}
+
+var duSorts = []string{"total", "git", "worktree", "path"}
+
+func sortUsage(usage []repoUsage, by string) error {
+	var less func(a, b repoUsage) bool
+	switch by {
+	case "total":
+		less = func(a, b repoUsage) bool { return a.total() > b.total() }
+	case "git":
+		less = func(a, b repoUsage) bool { return a.GitDir > b.GitDir }
+	case "worktree":
+		less = func(a, b repoUsage) bool { return a.Worktree > b.Worktree }
+	case "path":
+		less = func(a, b repoUsage) bool { return a.Repo.RelativePath < b.Repo.RelativePath }
+	default:
+		return fmt.Errorf("unknown sort %q (available: %s)", by, strings.Join(duSorts, ", "))
+	}
+	sort.SliceStable(usage, func(i, j int) bool { return less(usage[i], usage[j]) })
+	return nil
+}
+
+// usageBar draws total size relative to the largest repo: █ for .git,
+// ░ for the working tree
+func usageBar(u repoUsage, largest int64, width int) string {
+	if largest == 0 {
+		return ""
+	}
+	gitCells := int(u.GitDir * int64(width) / largest)
+	treeCells := int(u.total()*int64(width)/largest) - gitCells
+	if gitCells+treeCells == 0 && u.total() > 0 {
+		gitCells = 1
+	}
+	return strings.Repeat("█", gitCells) + strings.Repeat("░", treeCells)
+}
+
+// runDu prints per-repo disk usage with bars and totals
+func runDu(baseDir, sortBy string, top int) error {
+	if err := sortUsage(nil, sortBy); err != nil {
+		return err
+	}
+	usage := measureUsage(findGitReposOptimized(baseDir, config.Depth))
+	sortUsage(usage, sortBy)
+
+	var totalGit, totalTree, largest int64
+	for _, u := range usage {
+		totalGit += u.GitDir
+		totalTree += u.Worktree
+		if u.total() > largest {
+			largest = u.total()
+		}
+	}
+
+	shown := usage
+	if top > 0 && top < len(shown) {
+		shown = shown[:top]
+	}
+
+	fmt.Printf("%-30s %10s %10s %10s\n", "REPOSITORY", ".git", "WORKTREE", "TOTAL")
+	for _, u := range shown {
+		name := u.Repo.RelativePath
+		if name == "" {
+			name = "."
+		}
+		fmt.Printf("%-30s %10s %10s %10s %s\n", name, formatBytes(u.GitDir), formatBytes(u.Worktree),
+			formatBytes(u.total()), usageBar(u, largest, 30))
+	}
+	if len(shown) < len(usage) {
+		fmt.Printf("… %d more\n", len(usage)-len(shown))
+	}
+	fmt.Printf("\n%-30s %10s %10s %10s\n", fmt.Sprintf("%d repositories", len(usage)),
+		formatBytes(totalGit), formatBytes(totalTree), formatBytes(totalGit+totalTree))
+	fmt.Println("█ .git  ░ working tree")
+	return nil
+}
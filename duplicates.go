@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// normalizeRemoteURL reduces a remote URL to host/path so the ssh, scp-like
+// and https forms of the same repository compare equal
+func normalizeRemoteURL(remote string) string {
+	host, path := "", remote
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" {
+		host, path = u.Hostname(), u.Path
+	} else if h, rest, ok := strings.Cut(remote, ":"); ok && !strings.Contains(h, "/") {
+		host, path = h, rest
+		if _, hostOnly, ok := strings.Cut(host, "@"); ok {
+			host = hostOnly
+		}
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	return strings.ToLower(host) + "/" + path
+}
+
+type duplicateClone struct {
+	Repo       GitStatus
+	LastCommit time.Time
+}
+
+// findDuplicates groups repos by normalized origin URL, newest commit first
+func findDuplicates(repos []GitStatus, ignored map[string]bool) map[string][]duplicateClone {
+	groups := make(map[string][]duplicateClone)
+	for _, repo := range repos {
+		if ignored[configRepoPath(repo.RepoPath)] || !repo.HasRemote {
+			continue
+		}
+		origin, err := runGit(repo.RepoPath, "remote", "get-url", "origin")
+		if err != nil {
+			continue
+		}
+		clone := duplicateClone{Repo: repo}
+		if out, err := runGit(repo.RepoPath, "log", "-1", "--format=%ct"); err == nil {
+			if seconds, err := strconv.ParseInt(out, 10, 64); err == nil {
+				clone.LastCommit = time.Unix(seconds, 0)
+			}
+		}
+		key := normalizeRemoteURL(origin)
+		groups[key] = append(groups[key], clone)
+	}
+
+	for key, clones := range groups {
+		if len(clones) < 2 {
+			delete(groups, key)
+			continue
+		}
+		sort.SliceStable(clones, func(i, j int) bool {
+			if !clones[i].LastCommit.Equal(clones[j].LastCommit) {
+				return clones[i].LastCommit.After(clones[j].LastCommit)
+			}
+			return clones[i].Repo.ModTime.After(clones[j].Repo.ModTime)
+		})
+	}
+	return groups
+}
+
+// deleteBlocker explains why a clone can't be deleted without losing work
+func deleteBlocker(repo GitStatus) string {
+	switch {
+	case isProtected(repo.RepoPath):
+		return "it is protected"
+	case repo.Symbol == "⚠" || repo.Corrupt:
+		return repo.Message
+	case repo.Dirty:
+		return "it has uncommitted changes"
+	case repo.Ahead > 0 || !repo.HasUpstream:
+		return "it may have unpushed commits"
+	}
+	if out, err := runGit(repo.RepoPath, "ls-files", "--others", "--exclude-standard"); err != nil || out != "" {
+		return "it has untracked files"
+	}
+	if out, err := runGit(repo.RepoPath, "log", "--branches", "--not", "--remotes", "--oneline"); err != nil || out != "" {
+		return "another branch has commits that aren't on any remote"
+	}
+	if out, err := runGit(repo.RepoPath, "stash", "list"); err != nil || out != "" {
+		return "it has stashed changes"
+	}
+	return ""
+}
+
+// runDuplicates lists directories cloned from the same remote and, with
+// clean, offers to ignore or delete all but the freshest copy
+func runDuplicates(baseDir string, clean bool) error {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	ignored := make(map[string]bool)
+	for _, path := range userConfig.IgnoreDuplicates {
+		ignored[configRepoPath(path)] = true
+	}
+
+	groups := findDuplicates(findGitReposOptimized(baseDir, config.Depth), ignored)
+	if len(groups) == 0 {
+		fmt.Println("No duplicate clones found.")
+		return nil
+	}
+
+	var keys []string
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	configChanged := false
+	for _, key := range keys {
+		fmt.Printf("\n%s (%d clones)\n", key, len(groups[key]))
+		for i, clone := range groups[key] {
+			freshness := "never committed"
+			if !clone.LastCommit.IsZero() {
+				freshness = "last commit " + clone.LastCommit.Format("2006-01-02")
+			}
+			marker := " "
+			if i == 0 {
+				marker = "★"
+			}
+			fmt.Printf("  %s %s %-40s %s, %s\n", marker, clone.Repo.Symbol, clone.Repo.RepoPath, freshness, clone.Repo.Message)
+		}
+
+		if !clean {
+			continue
+		}
+		for _, stale := range groups[key][1:] {
+			switch strings.ToLower(prompt(fmt.Sprintf("  %s: [k]eep, [i]gnore from now on, [d]elete?", stale.Repo.RepoPath), "k")) {
+			case "i", "ignore":
+				userConfig.IgnoreDuplicates = append(userConfig.IgnoreDuplicates, configRepoPath(stale.Repo.RepoPath))
+				configChanged = true
+			case "d", "delete":
+				if reason := deleteBlocker(stale.Repo); reason != "" {
+					fmt.Printf("  ✗ Not deleting %s: %s\n", stale.Repo.RepoPath, reason)
+					continue
+				}
+				if err := os.RemoveAll(stale.Repo.RepoPath); err != nil {
+					fmt.Printf("  ✗ %v\n", err)
+					continue
+				}
+				fmt.Printf("  ✓ Deleted %s\n", stale.Repo.RepoPath)
+			}
+		}
+	}
+
+	if configChanged {
+		return saveConfig(userConfig)
+	}
+	if !clean {
+		fmt.Println("\n★ = most recent commit. Run with --clean to ignore or delete the other copies.")
+	}
+	return nil
+}
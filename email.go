@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"html"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailConfig holds the SMTP settings used by `report --email`
+type EmailConfig struct {
+	SMTPHost    string `json:"smtp_host"`
+	SMTPPort    int    `json:"smtp_port"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	PasswordEnv string `json:"password_env"`
+	From        string `json:"from"`
+	TLS         bool   `json:"tls"` // implicit TLS (port 465); STARTTLS is negotiated otherwise
+}
+
+func (e EmailConfig) password() string {
+	if e.Password != "" {
+		return e.Password
+	}
+	if e.PasswordEnv != "" {
+		return os.Getenv(e.PasswordEnv)
+	}
+	return ""
+}
+
+func reportSubject(repos []GitStatus) string {
+	pending := 0
+	for _, repo := range repos {
+		if repo.Symbol != "✓" {
+			pending++
+		}
+	}
+	if pending == 0 {
+		return "git-status-dash: all repositories synced"
+	}
+	return fmt.Sprintf("git-status-dash: %d repositories need attention", pending)
+}
+
+func renderHTMLReport(repos []GitStatus) string {
+	colors := map[string]string{
+		"✓": "#2da44e",
+		"✗": "#cf222e",
+		"⚠": "#cf222e",
+		"☠": "#cf222e",
+		"↑": "#bf8700",
+		"↓": "#bf8700",
+		"↕": "#bf8700",
+	}
+
+	var b strings.Builder
+	b.WriteString("<html><body style=\"font-family: monospace\">\n")
+	b.WriteString("<table cellpadding=\"4\">\n")
+	b.WriteString("<tr><th></th><th align=\"left\">Repository</th><th align=\"left\">Branch</th><th align=\"left\">Status</th></tr>\n")
+	for _, repo := range repos {
+		name := repo.RelativePath
+		if name == "" {
+			name = "."
+		}
+		fmt.Fprintf(&b, "<tr style=\"color: %s\"><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			colors[repo.Symbol],
+			html.EscapeString(repo.Symbol),
+			html.EscapeString(name),
+			html.EscapeString(describeBranch(repo)),
+			html.EscapeString(repo.Message),
+		)
+	}
+	b.WriteString("</table>\n")
+	if len(repos) == 0 {
+		b.WriteString("<p>All repositories are synced.</p>\n")
+	}
+	b.WriteString("</body></html>\n")
+	return b.String()
+}
+
+func buildReportEmail(from, to string, repos []GitStatus, asHTML bool) []byte {
+	var body, contentType string
+	if asHTML {
+		body = renderHTMLReport(repos)
+		contentType = "text/html; charset=UTF-8"
+	} else {
+		var buf bytes.Buffer
+		writeReport(&buf, repos, false, 0)
+		if len(repos) == 0 {
+			buf.WriteString("All repositories are synced.\n")
+		}
+		body = buf.String()
+		contentType = "text/plain; charset=UTF-8"
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", reportSubject(repos)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s\r\n", contentType)
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return msg.Bytes()
+}
+
+// emailReport sends the report through the SMTP server from the user config
+func emailReport(to string, repos []GitStatus, asHTML bool) error {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	settings := userConfig.Email
+	if settings.SMTPHost == "" {
+		return fmt.Errorf("no SMTP server configured (set email.smtp_host)")
+	}
+
+	port := settings.SMTPPort
+	if port == 0 {
+		port = 587
+		if settings.TLS {
+			port = 465
+		}
+	}
+	from := settings.From
+	if from == "" {
+		from = settings.Username
+	}
+	if from == "" {
+		return fmt.Errorf("no sender configured (set email.from)")
+	}
+
+	addr := net.JoinHostPort(settings.SMTPHost, strconv.Itoa(port))
+	msg := buildReportEmail(from, to, repos, asHTML)
+
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.password(), settings.SMTPHost)
+	}
+
+	pool, err := rootCAs()
+	if err != nil {
+		return err
+	}
+	tlsConfig := &tls.Config{ServerName: settings.SMTPHost, RootCAs: pool}
+	var conn net.Conn
+	if settings.TLS {
+		conn, err = tls.Dial("tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, settings.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	// like smtp.SendMail, upgrade plain connections when the server offers
+	// it, but trusting network.ca_bundle too
+	if ok, _ := client.Extension("STARTTLS"); ok && !settings.TLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
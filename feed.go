@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const maxFeedEntries = 200
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string `xml:"title"`
+	ID      string `xml:"id"`
+	Updated string `xml:"updated"`
+	Summary string `xml:"summary"`
+}
+
+// feedState remembers the last status seen per repo. It lives next to the
+// feed so several feeds (or ad-hoc report runs) don't steal each other's events.
+type feedState map[string]feedRepoState
+
+type feedRepoState struct {
+	Symbol  string `json:"symbol"`
+	Message string `json:"message"`
+}
+
+func feedStatePath(feedPath string) string {
+	return feedPath + ".state.json"
+}
+
+func loadFeedState(feedPath string) (feedState, bool) {
+	data, err := os.ReadFile(feedStatePath(feedPath))
+	if err != nil {
+		return feedState{}, false
+	}
+	var state feedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return feedState{}, false
+	}
+	return state, true
+}
+
+func loadFeed(feedPath string) *atomFeed {
+	feed := &atomFeed{
+		Title:  "git-status-dash status changes",
+		Author: atomAuthor{Name: "git-status-dash"},
+	}
+	if data, err := os.ReadFile(feedPath); err == nil {
+		xml.Unmarshal(data, feed)
+	}
+	if feed.ID == "" {
+		abs, _ := filepath.Abs(feedPath)
+		feed.ID = "urn:git-status-dash:feed:" + abs
+	}
+	return feed
+}
+
+// statusChangeEntries compares a scan against the previous state. The very
+// first run only records state: every repo would otherwise show up as "new".
+func statusChangeEntries(repos []GitStatus, previous feedState, hadState bool, now time.Time) []atomEntry {
+	var entries []atomEntry
+	if !hadState {
+		return entries
+	}
+
+	stamp := now.UTC().Format(time.RFC3339)
+	for _, repo := range repos {
+		name := repo.RelativePath
+		if name == "" || name == "." {
+			name = filepath.Base(repo.RepoPath)
+		}
+
+		old, known := previous[repo.RepoPath]
+		var title string
+		switch {
+		case !known:
+			title = fmt.Sprintf("%s: new repository (%s)", name, repo.Symbol)
+		case old.Symbol != repo.Symbol:
+			title = fmt.Sprintf("%s: %s → %s", name, old.Symbol, repo.Symbol)
+		default:
+			continue
+		}
+
+		entries = append(entries, atomEntry{
+			Title:   title,
+			ID:      fmt.Sprintf("urn:git-status-dash:%s:%d", repo.RepoPath, now.UnixNano()),
+			Updated: stamp,
+			Summary: fmt.Sprintf("%s\n%s", repo.RepoPath, repo.Message),
+		})
+	}
+	return entries
+}
+
+// updateFeed prepends status-change events from this scan to the Atom feed.
+// Scheduled and manual runs can overlap, so it holds the feed's lock.
+func updateFeed(feedPath string, repos []GitStatus) (added int, err error) {
+	key, _ := filepath.Abs(feedPath)
+	err = withLock("feed "+key, func() error {
+		added, err = appendFeed(feedPath, repos)
+		return err
+	})
+	return added, err
+}
+
+func appendFeed(feedPath string, repos []GitStatus) (int, error) {
+	previous, hadState := loadFeedState(feedPath)
+	now := time.Now()
+
+	entries := statusChangeEntries(repos, previous, hadState, now)
+	feed := loadFeed(feedPath)
+	if len(entries) > 0 || feed.Updated == "" {
+		feed.Updated = now.UTC().Format(time.RFC3339)
+	}
+
+	feed.Entries = append(entries, feed.Entries...)
+	if len(feed.Entries) > maxFeedEntries {
+		feed.Entries = feed.Entries[:maxFeedEntries]
+	}
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	data = append([]byte(xml.Header), data...)
+	if err := writeFileAtomic(feedPath, data, 0644); err != nil {
+		return 0, err
+	}
+
+	state := feedState{}
+	for _, repo := range repos {
+		state[repo.RepoPath] = feedRepoState{Symbol: repo.Symbol, Message: repo.Message}
+	}
+	stateData, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), writeFileAtomic(feedStatePath(feedPath), stateData, 0644)
+}
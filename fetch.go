@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+)
+
+// repoStatusMsg carries a fresh status for one repo after an action on it
+type repoStatusMsg struct {
+	status GitStatus
+	notice string
+}
+
+// fetchRepoCmd fetches every remote of a repo in the background, then
+// rescans just that repo so its ahead/behind counts are current
+func fetchRepoCmd(repo GitStatus, baseDir string) tea.Cmd {
+	return func() tea.Msg {
+		notice := fmt.Sprintf("✓ Fetched %s", displayName(repo))
+		if _, err := runGit(repo.RepoPath, "fetch", "--all", "--prune"); err != nil {
+			notice = fmt.Sprintf("✗ Fetch failed for %s: %v", displayName(repo), err)
+		}
+		return repoStatusMsg{status: getGitStatus(repo.RepoPath, baseDir, nil), notice: notice}
+	}
+}
+
+// updateRepo swaps in a rescanned repo without rescanning the workspace
+func (m model) updateRepo(status GitStatus) model {
+	for i, repo := range m.scanned {
+		if repo.RepoPath == status.RepoPath {
+			m.scanned[i] = status
+		}
+	}
+	for i, repo := range m.found {
+		if repo.RepoPath == status.RepoPath {
+			m.found[i] = status
+		}
+	}
+	return m.applySearch()
+}
+
+type fetchResult struct {
+	repoPath string
+	err      error
+	host     string // set when the repo was skipped because its host failed the precheck
+}
+
+// fetchRepos fetches every repo with as many parallel git processes as the
+// scan's worker pool uses, sending one result per repo and closing results
+// when all are done. Hosts are probed first, and repos on a host that
+// can't authenticate are skipped rather than left to prompt one by one.
+func fetchRepos(repoPaths []string, results chan<- fetchResult) {
+	hosts := checkRemoteHosts(repoPaths)
+	blocked := unreachableRepos(hosts)
+	for _, host := range hosts {
+		for _, repoPath := range host.Repos {
+			if host.Err != nil {
+				results <- fetchResult{repoPath, blocked[repoPath], host.Name}
+			} else if blocked[repoPath] != nil {
+				results <- fetchResult{repoPath: repoPath, err: blocked[repoPath]}
+			}
+		}
+	}
+
+	semaphore := make(chan struct{}, min(runtime.NumCPU()*2, 16))
+	var wg sync.WaitGroup
+	for _, repoPath := range repoPaths {
+		if blocked[repoPath] != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(repoPath string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			_, err := runGit(repoPath, "fetch", "--all", "--prune")
+			results <- fetchResult{repoPath: repoPath, err: err}
+		}(repoPath)
+	}
+	wg.Wait()
+	close(results)
+}
+
+// fetchAllState tracks a running F fetch in the TUI
+type fetchAllState struct {
+	results <-chan fetchResult
+	done    int
+	total   int
+	failed  []string
+	hosts   []string // hosts that failed the precheck
+}
+
+type fetchProgressMsg fetchResult
+type fetchAllDoneMsg struct{}
+
+func fetchAllCmd(repoPaths []string) (*fetchAllState, tea.Cmd) {
+	results := make(chan fetchResult, len(repoPaths))
+	go fetchRepos(repoPaths, results)
+	state := &fetchAllState{results: results, total: len(repoPaths)}
+	return state, state.wait()
+}
+
+// wait delivers the next finished fetch to Update
+func (f *fetchAllState) wait() tea.Cmd {
+	return func() tea.Msg {
+		result, ok := <-f.results
+		if !ok {
+			return fetchAllDoneMsg{}
+		}
+		return fetchProgressMsg(result)
+	}
+}
+
+// fetchWorkspace is --fetch outside the TUI: fetch everything before the
+// scan, counting progress on stderr
+func fetchWorkspace(baseDir string, depth int) {
+	paths := make(chan string, 100)
+	go func() {
+		defer close(paths)
+		walkReposOptimized(baseDir, baseDir, 0, depth, paths)
+	}()
+	var repoPaths []string
+	for path := range paths {
+		repoPaths = append(repoPaths, path)
+	}
+
+	interactive := term.IsTerminal(int(os.Stderr.Fd()))
+	results := make(chan fetchResult, len(repoPaths))
+	go fetchRepos(repoPaths, results)
+	done := 0
+	reported := map[string]bool{}
+	for result := range results {
+		done++
+		if result.host != "" {
+			if !reported[result.host] {
+				reported[result.host] = true
+				fmt.Fprintf(os.Stderr, "\r✗ Skipping repositories, %v\n", result.err)
+			}
+		} else if result.err != nil {
+			fmt.Fprintf(os.Stderr, "\r✗ Fetch failed for %s: %v\n", result.repoPath, result.err)
+		}
+		if interactive {
+			fmt.Fprintf(os.Stderr, "\rFetching %d/%d repositories...", done, len(repoPaths))
+		}
+	}
+	if interactive {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ForgeConfig describes a GitHub or GitLab instance the tool can talk to.
+// Tokens can live in the config file, an environment variable or, after
+// "auth login", the OS keychain.
+type ForgeConfig struct {
+	Type     string `json:"type"`    // "github" or "gitlab"
+	Host     string `json:"host"`    // github.com, gitlab.example.com, ...
+	APIURL   string `json:"api_url"` // derived from type/host when empty
+	Token    string `json:"token"`
+	TokenEnv string `json:"token_env"` // name of an env var holding the token
+	Owner    string `json:"owner"`     // org/group for new repos; empty means the user
+	Protocol string `json:"protocol"`  // "ssh" (default) or "https" for new remotes
+	ClientID string `json:"client_id"` // OAuth app used by "auth login"
+}
+
+type forgeRepo struct {
+	SSHURL   string
+	HTTPSURL string
+	WebURL   string
+}
+
+func (f ForgeConfig) host() string {
+	if f.Host != "" {
+		return f.Host
+	}
+	if f.Type == "gitlab" {
+		return "gitlab.com"
+	}
+	return "github.com"
+}
+
+func (f ForgeConfig) apiBase() string {
+	if f.APIURL != "" {
+		return strings.TrimSuffix(f.APIURL, "/")
+	}
+	switch {
+	case f.Type == "gitlab":
+		return "https://" + f.host() + "/api/v4"
+	case f.host() == "github.com":
+		return "https://api.github.com"
+	default:
+		// GitHub Enterprise Server
+		return "https://" + f.host() + "/api/v3"
+	}
+}
+
+func (f ForgeConfig) token() string {
+	token, _ := f.credentials()
+	return token
+}
+
+// credentials returns the API token and whether it came from "auth login",
+// which stores an OAuth token rather than a personal access token
+func (f ForgeConfig) credentials() (token string, oauth bool) {
+	if f.Token != "" {
+		return f.Token, false
+	}
+	if f.TokenEnv != "" {
+		return os.Getenv(f.TokenEnv), false
+	}
+	if token := keychainGet(f.host()); token != "" {
+		return token, true
+	}
+	if f.Type == "gitlab" {
+		return os.Getenv("GITLAB_TOKEN"), false
+	}
+	return os.Getenv("GITHUB_TOKEN"), false
+}
+
+func (f ForgeConfig) remoteURL(repo *forgeRepo) string {
+	if f.Protocol == "https" {
+		return repo.HTTPSURL
+	}
+	return repo.SSHURL
+}
+
+// selectForge resolves a configured forge by name. With a single forge
+// configured the name may be omitted.
+func selectForge(name string) (ForgeConfig, error) {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return ForgeConfig{}, err
+	}
+
+	if name == "" && len(userConfig.Forges) == 1 {
+		for _, forge := range userConfig.Forges {
+			return forge, nil
+		}
+	}
+
+	if forge, ok := userConfig.Forges[name]; ok {
+		return forge, nil
+	}
+
+	var names []string
+	for n := range userConfig.Forges {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return ForgeConfig{}, fmt.Errorf("no forges configured (see `git-status-dash config set forges.<name>.type github`)")
+	}
+	return ForgeConfig{}, fmt.Errorf("unknown forge '%s' (configured: %s)", name, strings.Join(names, ", "))
+}
+
+func forgeRequest(f ForgeConfig, method, path string, body, out interface{}) error {
+	if offline {
+		return errOffline
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, f.apiBase()+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if token, oauth := f.credentials(); token != "" {
+		// GitLab takes personal access tokens as PRIVATE-TOKEN but OAuth
+		// tokens only as a bearer token
+		if f.Type == "gitlab" && !oauth {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	client, err := httpClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message interface{} `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != nil {
+			return fmt.Errorf("%s %s: HTTP %d: %v", method, path, resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("%s %s: HTTP %d", method, path, resp.StatusCode)
+	}
+
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+// createForgeRepo creates an empty repository on the forge
+func createForgeRepo(f ForgeConfig, name string, private bool, description string) (*forgeRepo, error) {
+	if f.token() == "" {
+		return nil, fmt.Errorf("no API token for %s (set token or token_env in the forge config)", f.host())
+	}
+
+	switch f.Type {
+	case "gitlab":
+		visibility := "public"
+		if private {
+			visibility = "private"
+		}
+		payload := map[string]interface{}{
+			"name":        name,
+			"path":        name,
+			"visibility":  visibility,
+			"description": description,
+		}
+		if f.Owner != "" {
+			var namespace struct {
+				ID int `json:"id"`
+			}
+			if err := forgeRequest(f, "GET", "/namespaces/"+url.PathEscape(f.Owner), nil, &namespace); err != nil {
+				return nil, err
+			}
+			payload["namespace_id"] = namespace.ID
+		}
+
+		var project struct {
+			SSHURL  string `json:"ssh_url_to_repo"`
+			HTTPURL string `json:"http_url_to_repo"`
+			WebURL  string `json:"web_url"`
+		}
+		if err := forgeRequest(f, "POST", "/projects", payload, &project); err != nil {
+			return nil, err
+		}
+		return &forgeRepo{SSHURL: project.SSHURL, HTTPSURL: project.HTTPURL, WebURL: project.WebURL}, nil
+
+	case "github", "":
+		path := "/user/repos"
+		if f.Owner != "" {
+			path = "/orgs/" + url.PathEscape(f.Owner) + "/repos"
+		}
+		payload := map[string]interface{}{
+			"name":        name,
+			"private":     private,
+			"description": description,
+		}
+
+		var repo struct {
+			SSHURL   string `json:"ssh_url"`
+			CloneURL string `json:"clone_url"`
+			HTMLURL  string `json:"html_url"`
+		}
+		if err := forgeRequest(f, "POST", path, payload, &repo); err != nil {
+			return nil, err
+		}
+		return &forgeRepo{SSHURL: repo.SSHURL, HTTPSURL: repo.CloneURL, WebURL: repo.HTMLURL}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported forge type '%s'", f.Type)
+	}
+}
+
+// forgeForRemote finds the configured forge hosting a remote URL and the
+// project path on it
+func forgeForRemote(remote string) (ForgeConfig, string, error) {
+	host, project, _ := strings.Cut(normalizeRemoteURL(remote), "/")
+	if host == "" || project == "" {
+		return ForgeConfig{}, "", fmt.Errorf("%s is not a hosted remote", remote)
+	}
+	userConfig, err := loadConfig()
+	if err != nil {
+		return ForgeConfig{}, "", err
+	}
+	for _, forge := range userConfig.Forges {
+		if strings.EqualFold(forge.host(), host) {
+			return forge, project, nil
+		}
+	}
+	return ForgeConfig{}, "", fmt.Errorf("no forge configured for %s (see `git-status-dash config set forges.<name>.host %s`)", host, host)
+}
+
+// forgeProjectPath extracts "owner/repo" (or a GitLab group path) from a
+// remote URL in scp-like, ssh:// or https:// form
+func forgeProjectPath(remoteURL string) string {
+	path := remoteURL
+	if u, err := url.Parse(remoteURL); err == nil && u.Scheme != "" {
+		path = u.Path
+	} else if _, rest, ok := strings.Cut(remoteURL, ":"); ok {
+		path = rest
+	}
+	return strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+}
+
+// createForgeRelease publishes a release for an existing tag and returns its web URL
+func createForgeRelease(f ForgeConfig, project, tag, notes string) (string, error) {
+	if f.Type == "gitlab" {
+		var release struct {
+			Links struct {
+				Self string `json:"self"`
+			} `json:"_links"`
+		}
+		body := map[string]string{"tag_name": tag, "name": tag, "description": notes}
+		err := forgeRequest(f, "POST", "/projects/"+url.PathEscape(project)+"/releases", body, &release)
+		return release.Links.Self, err
+	}
+
+	var release struct {
+		HTMLURL string `json:"html_url"`
+	}
+	body := map[string]string{"tag_name": tag, "name": tag, "body": notes}
+	err := forgeRequest(f, "POST", "/repos/"+project+"/releases", body, &release)
+	return release.HTMLURL, err
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fsckResult is the outcome of the last integrity check of a repo
+type fsckResult struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Problem   string    `json:"problem,omitempty"` // first thing git fsck found, empty when intact
+}
+
+func fsckStatePath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "fsck.json"), nil
+}
+
+func loadFsckState() map[string]fsckResult {
+	state := map[string]fsckResult{}
+	path, err := fsckStatePath()
+	if err != nil {
+		return state
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// fsckCache keeps the scan from rereading fsck.json for every repo; it is
+// reread when a scheduled run has changed it
+var fsckCache struct {
+	sync.Mutex
+	modified time.Time
+	state    map[string]fsckResult
+}
+
+func cachedFsckState() map[string]fsckResult {
+	fsckCache.Lock()
+	defer fsckCache.Unlock()
+	path, err := fsckStatePath()
+	if err != nil {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil // integrity checks have never run
+	}
+	if fsckCache.state == nil || !info.ModTime().Equal(fsckCache.modified) {
+		fsckCache.state, fsckCache.modified = loadFsckState(), info.ModTime()
+	}
+	return fsckCache.state
+}
+
+// markCorrupt puts a repo whose last integrity check failed in the
+// integrity state, which outranks everything else about it. It gets a
+// symbol of its own so damage isn't mistaken for an ordinary git error.
+func markCorrupt(status *GitStatus) {
+	result, ok := cachedFsckState()[status.RepoPath]
+	if !ok || result.Problem == "" {
+		return
+	}
+	status.Corrupt = true
+	status.Symbol = "☠"
+	status.Message = "Integrity check failed: " + result.Problem
+}
+
+// fsckRepo runs git fsck and returns the first problem it reports, or ""
+func fsckRepo(repoPath string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	out, err := gitCommand(ctx, "-C", repoPath, "fsck", "--no-dangling", "--no-progress").CombinedOutput()
+	if err == nil {
+		return ""
+	}
+	if ctx.Err() != nil {
+		return "git fsck timed out"
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "Checking") {
+			return strings.TrimPrefix(line, "error: ")
+		}
+	}
+	return err.Error()
+}
+
+// fsckBatch picks the repos to check this run: never checked first, then
+// those checked longest ago, so nightly runs rotate through the workspace
+func fsckBatch(repos []GitStatus, state map[string]fsckResult, size int) []GitStatus {
+	batch := append([]GitStatus(nil), repos...)
+	sort.SliceStable(batch, func(i, j int) bool {
+		return state[batch[i].RepoPath].CheckedAt.Before(state[batch[j].RepoPath].CheckedAt)
+	})
+	if size > 0 && size < len(batch) {
+		batch = batch[:size]
+	}
+	return batch
+}
+
+// runFsck checks a batch of repos and records the results, reporting
+// whether all of them are intact
+func runFsck(baseDir string, batchSize int) (bool, error) {
+	repos := findGitReposOptimized(baseDir, config.Depth)
+	batch := fsckBatch(repos, loadFsckState(), batchSize)
+
+	results := map[string]fsckResult{}
+	intact := true
+	for _, repo := range batch {
+		problem := fsckRepo(repo.RepoPath)
+		results[repo.RepoPath] = fsckResult{CheckedAt: time.Now().UTC(), Problem: problem}
+		if problem != "" {
+			fmt.Printf("☠ %-30s %s\n", displayName(repo), problem)
+			intact = false
+		} else {
+			fmt.Printf("✓ %-30s intact\n", displayName(repo))
+		}
+	}
+
+	err := saveFsckResults(results)
+	fmt.Printf("\nChecked %d of %d repositories\n", len(batch), len(repos))
+	return intact, err
+}
+
+// saveFsckResults merges results into fsck.json
+func saveFsckResults(results map[string]fsckResult) error {
+	path, err := fsckStatePath()
+	if err != nil {
+		return err
+	}
+	return withLock("fsck", func() error {
+		state := loadFsckState()
+		for repoPath, result := range results {
+			state[repoPath] = result
+		}
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return writeFileAtomic(path, data, 0644)
+	})
+}
+
+// The daemon's refresh checks a batch of repos once a day, so the whole
+// workspace is covered over time without a cron job of its own
+const (
+	fsckDaemonInterval = 24 * time.Hour
+	fsckDaemonBatch    = 5
+)
+
+// scheduledFsck runs from the daemon's refresh: when no repo in repos has
+// been checked for fsckDaemonInterval it checks the next batch, warning
+// about damage on stderr
+func scheduledFsck(repos []GitStatus) {
+	state := loadFsckState()
+	var last time.Time
+	for _, repo := range repos {
+		if checked := state[repo.RepoPath].CheckedAt; checked.After(last) {
+			last = checked
+		}
+	}
+	if len(repos) == 0 || time.Since(last) < fsckDaemonInterval {
+		return
+	}
+	results := map[string]fsckResult{}
+	for _, repo := range fsckBatch(repos, state, fsckDaemonBatch) {
+		problem := fsckRepo(repo.RepoPath)
+		results[repo.RepoPath] = fsckResult{CheckedAt: time.Now().UTC(), Problem: problem}
+		if problem != "" {
+			fmt.Fprintf(os.Stderr, "☠ Integrity check failed for %s: %s\n", displayName(repo), problem)
+		}
+	}
+	if err := saveFsckResults(results); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Couldn't save the integrity check results: %v\n", err)
+	}
+}
+
+// runFsckReport lists when every repo was last checked and what was found,
+// reporting whether none is known to be corrupt
+func runFsckReport(baseDir string) bool {
+	repos := findGitReposOptimized(baseDir, config.Depth)
+	sortRepos(repos, "path")
+	state := loadFsckState()
+	intact := true
+	for _, repo := range repos {
+		result, ok := state[repo.RepoPath]
+		switch {
+		case !ok:
+			fmt.Printf("- %-30s never checked\n", displayName(repo))
+		case result.Problem != "":
+			fmt.Printf("☠ %-30s %s (checked %s)\n", displayName(repo), result.Problem, relativeTime(result.CheckedAt))
+			intact = false
+		default:
+			fmt.Printf("✓ %-30s intact (checked %s)\n", displayName(repo), relativeTime(result.CheckedAt))
+		}
+	}
+	return intact
+}
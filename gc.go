@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type garbageSummary struct {
+	Repo        GitStatus
+	Unreachable int   // objects unreachable once reflogs are ignored
+	ObjectBytes int64 // their size on disk
+	GarbageKiB  int64 // files in .git/objects git doesn't recognise
+	Err         error
+}
+
+func (g garbageSummary) reclaimable() int64 {
+	return g.ObjectBytes + g.GarbageKiB*1024
+}
+
+// countObjectsField reads one field of `git count-objects -v`
+func countObjectsField(out, field string) int64 {
+	for _, line := range strings.Split(out, "\n") {
+		if value, ok := strings.CutPrefix(line, field+": "); ok {
+			n, _ := strconv.ParseInt(value, 10, 64)
+			return n
+		}
+	}
+	return 0
+}
+
+// objectsDiskSize sums the on-disk size of the given objects
+func objectsDiskSize(repoPath string, hashes []string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	cmd := gitCommand(ctx, "-C", repoPath, "cat-file", "--batch-check=%(objectsize:disk)")
+	cmd.Stdin = strings.NewReader(strings.Join(hashes, "\n") + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		n, _ := strconv.ParseInt(scanner.Text(), 10, 64)
+		total += n
+	}
+	return total, nil
+}
+
+// summarizeGarbage estimates what expiring reflogs and pruning would free
+func summarizeGarbage(repo GitStatus) garbageSummary {
+	summary := garbageSummary{Repo: repo}
+
+	out, err := runGitLong(repo.RepoPath, "fsck", "--unreachable", "--no-reflogs", "--no-progress")
+	if err != nil {
+		summary.Err = err
+		return summary
+	}
+	var hashes []string
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "unreachable" {
+			hashes = append(hashes, fields[2])
+		}
+	}
+	summary.Unreachable = len(hashes)
+	if len(hashes) > 0 {
+		if summary.ObjectBytes, err = objectsDiskSize(repo.RepoPath, hashes); err != nil {
+			summary.Err = err
+			return summary
+		}
+	}
+
+	if counts, err := runGit(repo.RepoPath, "count-objects", "-v"); err == nil {
+		summary.GarbageKiB = countObjectsField(counts, "size-garbage")
+	}
+	return summary
+}
+
+// expireAndPrune drops reflog history (except the stash, whose entries live
+// in its reflog) and prunes everything that is no longer reachable
+func expireAndPrune(repoPath string) error {
+	refs, err := runGit(repoPath, "for-each-ref", "--format=%(refname)")
+	if err != nil {
+		return err
+	}
+	args := []string{"reflog", "expire", "--expire=now", "--expire-unreachable=now", "HEAD"}
+	for _, ref := range strings.Fields(refs) {
+		if ref == "refs/stash" {
+			continue
+		}
+		// Naming a ref without a reflog is an error
+		if _, err := runGit(repoPath, "reflog", "exists", ref); err == nil {
+			args = append(args, ref)
+		}
+	}
+	if _, err := runGitLong(repoPath, args...); err != nil {
+		return err
+	}
+	_, err = runGitLong(repoPath, "gc", "--prune=now", "--quiet")
+	return err
+}
+
+// runGarbage lists reclaimable space per repo and optionally cleans up
+func runGarbage(baseDir string, prune, yes bool) error {
+	repos := findGitReposOptimized(baseDir, config.Depth)
+
+	summaries := make([]garbageSummary, len(repos))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo GitStatus) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			summaries[i] = summarizeGarbage(repo)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].reclaimable() > summaries[j].reclaimable()
+	})
+
+	var total int64
+	candidates := 0
+	for _, s := range summaries {
+		name := s.Repo.RelativePath
+		if name == "" {
+			name = "."
+		}
+		if s.Err != nil {
+			fmt.Printf("⚠ %-30s %v\n", name, s.Err)
+			continue
+		}
+		if s.reclaimable() == 0 {
+			continue
+		}
+		candidates++
+		total += s.reclaimable()
+		fmt.Printf("  %-30s %6d prunable objects %10s", name, s.Unreachable, formatBytes(s.ObjectBytes))
+		if s.GarbageKiB > 0 {
+			fmt.Printf(", %s garbage", formatBytes(s.GarbageKiB*1024))
+		}
+		fmt.Println()
+	}
+
+	if candidates == 0 {
+		fmt.Println("Nothing to reclaim.")
+		return nil
+	}
+	fmt.Printf("\nYou could reclaim about %s across %d repositories by expiring reflogs and pruning.\n", formatBytes(total), candidates)
+	fmt.Println("Stash entries are kept; objects they reference are included in the estimate.")
+	if !prune {
+		fmt.Println("Run with --prune to clean up.")
+		return nil
+	}
+
+	var freed int64
+	for _, s := range summaries {
+		if s.Err != nil || s.reclaimable() == 0 {
+			continue
+		}
+		if isProtected(s.Repo.RepoPath) {
+			fmt.Printf("- %s: skipped, %s\n", s.Repo.RepoPath, protectedMessage)
+			continue
+		}
+		if !yes && !confirm(fmt.Sprintf("Expire reflogs and prune %s?", s.Repo.RepoPath)) {
+			continue
+		}
+		gitDir := filepath.Join(s.Repo.RepoPath, ".git")
+		before := dirSize(gitDir, false)
+		if err := expireAndPrune(s.Repo.RepoPath); err != nil {
+			fmt.Printf("✗ %s: %v\n", s.Repo.RepoPath, err)
+			continue
+		}
+		saved := before - dirSize(gitDir, false)
+		freed += saved
+		fmt.Printf("✓ %s: freed %s\n", s.Repo.RepoPath, formatBytes(saved))
+	}
+	fmt.Printf("\nFreed %s\n", formatBytes(freed))
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// commits loaded into the detail view, and how many of them show at once
+const (
+	gitLogLimit = 20
+	gitLogRows  = 8
+)
+
+// repoLog is the recent history shown in the detail view, one
+// "git log --oneline --decorate" line per commit
+type repoLog struct {
+	RepoPath string
+	Lines    []string
+}
+
+type repoLogMsg repoLog
+
+func loadRepoLogCmd(repoPath string) tea.Cmd {
+	return func() tea.Msg {
+		out, err := runGit(repoPath, "log", "--oneline", "--decorate", "--no-color", fmt.Sprintf("-%d", gitLogLimit))
+		history := repoLog{RepoPath: repoPath}
+		if err == nil && out != "" {
+			history.Lines = strings.Split(out, "\n")
+		}
+		return repoLogMsg(history)
+	}
+}
+
+// scrollLog moves the detail view's commit list by delta lines
+func (m model) scrollLog(delta int) model {
+	if m.gitLog == nil {
+		return m
+	}
+	m.logOffset = max(0, min(m.logOffset+delta, len(m.gitLog.Lines)-gitLogRows))
+	return m
+}
+
+// preview renders the lines from offset on, with the hash dimmed and the
+// position in the list when it doesn't fit
+func (l repoLog) preview(offset int) string {
+	hashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	if len(l.Lines) == 0 {
+		return "Recent commits\n" + dimStyle.Render("no commits yet")
+	}
+	end := min(offset+gitLogRows, len(l.Lines))
+	title := "Recent commits"
+	if len(l.Lines) > gitLogRows {
+		title += dimStyle.Render(fmt.Sprintf(" %d-%d of %d", offset+1, end, len(l.Lines)))
+	}
+	lines := []string{title}
+	for _, line := range l.Lines[offset:end] {
+		hash, rest, _ := strings.Cut(line, " ")
+		lines = append(lines, hashStyle.Render(hash)+" "+rest)
+	}
+	return strings.Join(lines, "\n")
+}
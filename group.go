@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var reportGroups = []string{"remote-host"}
+
+func validateGroupBy(by string) error {
+	for _, known := range reportGroups {
+		if by == "" || by == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown grouping %q (available: %s)", by, strings.Join(reportGroups, ", "))
+}
+
+// remoteHost is the host of the repo's primary remote (origin, else the
+// first one), "local" for filesystem remotes and "none" without a remote
+func remoteHost(repo GitStatus) string {
+	if !repo.HasRemote {
+		return "none"
+	}
+	remote, err := runGit(repo.RepoPath, "remote", "get-url", "origin")
+	if err != nil {
+		remotes, _ := runGit(repo.RepoPath, "remote")
+		if fields := strings.Fields(remotes); len(fields) > 0 {
+			remote, err = runGit(repo.RepoPath, "remote", "get-url", fields[0])
+		}
+	}
+	if err != nil || remote == "" {
+		return "none"
+	}
+	host, _, _ := strings.Cut(normalizeRemoteURL(remote), "/")
+	if host == "" {
+		return "local"
+	}
+	return host
+}
+
+// groupKey returns the group a repo belongs to for --group-by
+func groupKey(repo GitStatus, by string) string {
+	switch by {
+	case "remote-host":
+		return remoteHost(repo)
+	}
+	return ""
+}
+
+// writeGroupedReport writes the report in sections, largest group first
+func writeGroupedReport(w io.Writer, repos []GitStatus, by string, color bool, width int) {
+	groups := make(map[string][]GitStatus)
+	for _, repo := range repos {
+		key := groupKey(repo, by)
+		groups[key] = append(groups[key], repo)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(groups[keys[i]]) != len(groups[keys[j]]) {
+			return len(groups[keys[i]]) > len(groups[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+
+	for i, key := range keys {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%d)\n", key, len(groups[key]))
+		writeReport(w, groups[key], color, width)
+	}
+}
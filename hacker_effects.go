@@ -42,12 +42,21 @@ type GlitchState struct {
 
 func NewHackerEffects(width, height int) *HackerEffects {
 	h := &HackerEffects{
-		MatrixRain:    make([]MatrixColumn, width/2), // Sparse columns
 		ASCIISpinners: make(map[string]*spinner.Spinner),
 		LastUpdate:    time.Now(),
 	}
+	h.Resize(width, height)
 
-	// Initialize matrix columns
+	// Initialize ASCII spinners (monochrome only)
+	h.initSpinners()
+
+	return h
+}
+
+// Resize rebuilds the matrix columns for a new terminal size
+func (h *HackerEffects) Resize(width, height int) {
+	height = max(1, height)
+	h.MatrixRain = make([]MatrixColumn, max(0, width)/2) // Sparse columns
 	for i := range h.MatrixRain {
 		h.MatrixRain[i] = MatrixColumn{
 			X:      i * 2,
@@ -58,11 +67,6 @@ func NewHackerEffects(width, height int) *HackerEffects {
 		}
 		h.generateMatrixChars(&h.MatrixRain[i], height)
 	}
-
-	// Initialize ASCII spinners (monochrome only)
-	h.initSpinners()
-
-	return h
 }
 
 func (h *HackerEffects) initSpinners() {
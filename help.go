@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// symbolLegend explains the marks the repo list uses
+var symbolLegend = [][2]string{
+	{"✓", "clean and in sync with its upstream"},
+	{"✗", "uncommitted changes"},
+	{"↑", "commits to push, or no remote/upstream yet"},
+	{"↓", "commits to pull"},
+	{"↕", "diverged from its upstream"},
+	{"⚠", "git failed for this repo, or its remote needs credentials or is gone"},
+	{"☠", "git fsck found damage in this repo"},
+	{"⎇", "checked out on a non-default branch"},
+	{"⇄", "pinned for comparison"},
+	{"●", "selected for a batch action"},
+	{"◆", "pull requests to review or issues assigned to you"},
+}
+
+// helpFilters are the ways to narrow the list
+var helpFilters = [][2]string{
+	{"/", "fuzzy search paths, branches, status and last commit authors"},
+	{"H", "hide the selected repos for this session"},
+	{"toggle:triage", "only repos with reviews or assigned issues"},
+	{"--all", "include clean repos"},
+	{"--off-default", "only repos on a non-default branch"},
+	{"--only STATES", "only these states, e.g. dirty,behind"},
+	{"--exclude STATES", "leave these states out"},
+	{"--author NAMES", "only repos whose last commit is by these authors"},
+}
+
+// helpMoved lists bindings that changed meaning, so muscle memory doesn't
+// land on the wrong action unnoticed
+var helpMoved = [][2]string{
+	{"o", "now opens the origin in the browser"},
+	{"!", "opens a shell in the repo (was o)"},
+}
+
+// keyName is how a binding is written in the help overlay
+func keyName(key string) string {
+	switch {
+	case key == " ":
+		return "space"
+	case len(key) > 1 && strings.Contains(key, ":"):
+		return ": palette"
+	}
+	return key
+}
+
+// helpView is the full-screen "?" overlay: every action from the palette
+// with its key, the filters and the symbol legend
+func (m model) helpView() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62"))
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+
+	section := func(title string, rows [][2]string, width int) string {
+		var b strings.Builder
+		b.WriteString(titleStyle.Render(title) + "\n")
+		for _, row := range rows {
+			b.WriteString(fmt.Sprintf("  %s %s\n", keyStyle.Render(fmt.Sprintf("%-*s", width, row[0])), row[1]))
+		}
+		return b.String()
+	}
+
+	var keys [][2]string
+	for _, action := range paletteActions {
+		keys = append(keys, [2]string{keyName(action.Key), action.Title})
+	}
+	keys = append(keys, [2]string{":", "Command palette"})
+
+	left := section("Keys", keys, 10)
+	right := section("Filters", helpFilters, 17) + "\n" + section("Symbols", symbolLegend, 1) + "\n" + section("Moved keys", helpMoved, 1)
+
+	var body string
+	if lipgloss.Width(left)+lipgloss.Width(right)+4 <= m.termWidth {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, left, "    ", right)
+	} else {
+		body = left + "\n" + right
+	}
+	header := titleStyle.Render("Git Status Dashboard help") + "  " + dimStyle.Render("any key closes this help")
+	return lipgloss.NewStyle().Padding(1, 2).MaxWidth(m.termWidth).MaxHeight(m.termHeight).Render(header + "\n\n" + body)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// loadExcluded reads the repos hidden for good from the config
+func loadExcluded() map[string]bool {
+	excluded := map[string]bool{}
+	if userConfig, err := loadConfig(); err == nil {
+		for _, path := range userConfig.Hidden {
+			excluded[filepath.Clean(path)] = true
+		}
+	}
+	return excluded
+}
+
+// isHidden reports whether repo is left out of the TUI list, for this
+// session (H) or for good (x)
+func (m model) isHidden(repo GitStatus) bool {
+	return m.hidden[repo.RepoPath] || m.excluded[configRepoPath(repo.RepoPath)]
+}
+
+// excludeTargets hides the selection from the TUI for good, saving it to
+// the config's "hidden" list
+func (m model) excludeTargets() model {
+	targets := m.targets()
+	if len(targets) == 0 {
+		return m
+	}
+	userConfig, err := loadConfig()
+	if err != nil {
+		m.notice = fmt.Sprintf("✗ Couldn't load the config: %v", err)
+		return m
+	}
+	var added []string
+	for _, repo := range targets {
+		path := configRepoPath(repo.RepoPath)
+		if !m.excluded[path] {
+			added = append(added, path)
+		}
+	}
+	if len(added) == 0 {
+		m.notice = "Already hidden for good"
+		return m
+	}
+	userConfig.Hidden = append(userConfig.Hidden, added...)
+	if err := saveConfig(userConfig); err != nil {
+		m.notice = fmt.Sprintf("✗ Couldn't save the hidden list: %v", err)
+		return m
+	}
+	for _, path := range added {
+		m.excluded[path] = true
+	}
+	m.notice = fmt.Sprintf("Hid %d repositories for good (`git-status-dash config unhide` brings them back)", len(added))
+	return m.clearSelection().applySearch()
+}
+
+// runUnhide takes repos off the config's "hidden" list, or every repo with
+// all. With neither it lists the hidden repos.
+func runUnhide(paths []string, all bool) error {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 && !all {
+		if len(userConfig.Hidden) == 0 {
+			fmt.Println("No repositories are hidden.")
+			return nil
+		}
+		for _, path := range userConfig.Hidden {
+			fmt.Printf("- %s\n", path)
+		}
+		fmt.Println("\nRun `git-status-dash config unhide <path>` to show one again, or --all for every one.")
+		return nil
+	}
+
+	if all {
+		for _, path := range userConfig.Hidden {
+			fmt.Printf("✓ Unhid %s\n", path)
+		}
+		userConfig.Hidden = nil
+		return saveConfig(userConfig)
+	}
+	for _, path := range paths {
+		abs, err := configPaths(path)
+		if err != nil {
+			return err
+		}
+		if len(abs) == 0 {
+			continue
+		}
+		path = abs[0]
+		before := len(userConfig.Hidden)
+		userConfig.Hidden = slices.DeleteFunc(userConfig.Hidden, func(p string) bool { return filepath.Clean(p) == path })
+		if len(userConfig.Hidden) == before {
+			fmt.Printf("- %s wasn't hidden\n", path)
+			continue
+		}
+		fmt.Printf("✓ Unhid %s\n", path)
+	}
+	return saveConfig(userConfig)
+}
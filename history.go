@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// History is kept in SQLite through the sqlite3 command-line tool, the same
+// way everything else shells out to git: no cgo, so cross-compiled release
+// builds keep working.
+const historySchema = `CREATE TABLE IF NOT EXISTS scans (
+	scanned_at TEXT NOT NULL,
+	repo       TEXT NOT NULL,
+	state      TEXT NOT NULL,
+	branch     TEXT,
+	ahead      INTEGER,
+	behind     INTEGER,
+	dirty      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS scans_repo_time ON scans (repo, scanned_at);
+`
+
+type historyQuery struct {
+	Repo  string
+	State string
+	Since string
+	Limit int
+	CSV   bool
+}
+
+func defaultHistoryDB() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "history.db"), nil
+}
+
+func sqlQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func runSQLite(db, script string, args ...string) error {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return fmt.Errorf("history needs the sqlite3 command-line tool in PATH")
+	}
+	if err := os.MkdirAll(filepath.Dir(db), 0755); err != nil {
+		return err
+	}
+	cmd := exec.Command("sqlite3", append(args, db)...)
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// recordHistory appends one row per repo for this scan
+func recordHistory(db string, repos []GitStatus) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	var script strings.Builder
+	script.WriteString(historySchema)
+	script.WriteString("BEGIN;\n")
+	for _, repo := range repos {
+		ahead, behind := "NULL", "NULL"
+		if repo.HasUpstream {
+			ahead, behind = strconv.Itoa(repo.Ahead), strconv.Itoa(repo.Behind)
+		}
+		dirty := 0
+		if repo.Dirty {
+			dirty = 1
+		}
+		fmt.Fprintf(&script, "INSERT INTO scans VALUES (%s, %s, %s, %s, %s, %s, %d);\n",
+			sqlQuote(now), sqlQuote(repo.RepoPath), sqlQuote(repo.statusKey()),
+			sqlQuote(repo.Branch), ahead, behind, dirty)
+	}
+	script.WriteString("COMMIT;\n")
+	return runSQLite(db, script.String())
+}
+
+// parseSince accepts a duration with a day unit (7d) or Go duration (12h),
+// or a date (2006-01-02), and returns the cutoff as stored in scanned_at
+func parseSince(since string) (string, error) {
+	if days, ok := strings.CutSuffix(since, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().UTC().AddDate(0, 0, -n).Format(time.RFC3339), nil
+		}
+	}
+	if d, err := time.ParseDuration(since); err == nil {
+		return time.Now().UTC().Add(-d).Format(time.RFC3339), nil
+	}
+	if t, err := time.Parse("2006-01-02", since); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("invalid --since %q (use 7d, 12h or 2006-01-02)", since)
+}
+
+func queryHistory(db string, q historyQuery) error {
+	if _, err := os.Stat(db); err != nil {
+		return fmt.Errorf("no history at %s yet (run `git-status-dash history record` first)", db)
+	}
+
+	var where []string
+	if q.Repo != "" {
+		where = append(where, "repo LIKE "+sqlQuote("%"+q.Repo+"%"))
+	}
+	if q.State != "" {
+		where = append(where, "state = "+sqlQuote(q.State))
+	}
+	if q.Since != "" {
+		cutoff, err := parseSince(q.Since)
+		if err != nil {
+			return err
+		}
+		where = append(where, "scanned_at >= "+sqlQuote(cutoff))
+	}
+
+	query := "SELECT scanned_at, repo, state, branch, ahead, behind, dirty FROM scans"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY scanned_at DESC, repo"
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+
+	mode := "-column"
+	if q.CSV {
+		mode = "-csv"
+	}
+	return runSQLite(db, query+";\n", "-header", mode)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// the inbox keeps this many events, dropping the oldest
+const maxInboxEvents = 200
+
+// how often forge notifications are checked
+const forgePollInterval = 5 * time.Minute
+
+// inboxEvent is something that happened while the TUI was running
+type inboxEvent struct {
+	Time time.Time
+	Kind string // "status", "action" or "forge"
+	Text string
+	ID   string // forge notification id, so reloads don't repeat it
+	Read bool
+}
+
+type forgeNotificationsMsg struct {
+	events []inboxEvent
+	err    error
+}
+
+// forgePollMsg is the tick that loads forge notifications again
+type forgePollMsg struct{}
+
+// addEvent files an unread event in the inbox, skipping forge
+// notifications it already holds
+func (m model) addEvent(event inboxEvent) model {
+	if event.ID != "" {
+		for _, existing := range m.inbox {
+			if existing.ID == event.ID {
+				return m
+			}
+		}
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	m.inbox = append(m.inbox, event)
+	if len(m.inbox) > maxInboxEvents {
+		m.inbox = m.inbox[len(m.inbox)-maxInboxEvents:]
+	}
+	return m
+}
+
+// statusEvents files an event for every repo whose status changed since
+// the previous scan
+func (m model) statusEvents(repos []GitStatus) model {
+	seen := m.known != nil
+	known := make(map[string]GitStatus, len(repos))
+	for _, repo := range repos {
+		known[repo.RepoPath] = repo
+		if old, ok := m.known[repo.RepoPath]; seen && ok && old.Message != repo.Message {
+			m = m.addEvent(inboxEvent{Kind: "status", Text: fmt.Sprintf("%s: %s → %s %s", displayName(repo), old.Message, repo.Symbol, repo.Message)})
+		} else if seen && !ok {
+			m = m.addEvent(inboxEvent{Kind: "status", Text: fmt.Sprintf("%s: new repository, %s", displayName(repo), repo.Message)})
+		}
+	}
+	m.known = known
+	return m
+}
+
+// isActionResult tells outcomes of actions apart from other status line
+// notices, which aren't worth keeping
+func isActionResult(notice string) bool {
+	for _, prefix := range []string{"✓", "✗", "⚠"} {
+		if strings.HasPrefix(notice, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// inboxSummary is the unread count for the header
+func (m model) inboxSummary() string {
+	if unread := m.unreadEvents(); unread > 0 {
+		return fmt.Sprintf(" · %d unread (i)", unread)
+	}
+	return ""
+}
+
+func (m model) unreadEvents() int {
+	unread := 0
+	for _, event := range m.inbox {
+		if !event.Read {
+			unread++
+		}
+	}
+	return unread
+}
+
+// markInboxRead is called when the inbox closes
+func (m model) markInboxRead() model {
+	inbox := make([]inboxEvent, len(m.inbox))
+	for i, event := range m.inbox {
+		event.Read = true
+		inbox[i] = event
+	}
+	m.inbox = inbox
+	return m
+}
+
+// forgeNotifications fetches unread GitHub notifications or pending GitLab
+// to-dos from one forge
+func forgeNotifications(f ForgeConfig) ([]inboxEvent, error) {
+	var events []inboxEvent
+	if f.Type == "gitlab" {
+		var todos []struct {
+			ID         int       `json:"id"`
+			ActionName string    `json:"action_name"`
+			CreatedAt  time.Time `json:"created_at"`
+			Project    struct {
+				Path string `json:"path_with_namespace"`
+			} `json:"project"`
+			Target struct {
+				Title string `json:"title"`
+			} `json:"target"`
+		}
+		if err := forgeRequest(f, "GET", "/todos?state=pending&per_page=50", nil, &todos); err != nil {
+			return nil, err
+		}
+		for _, todo := range todos {
+			events = append(events, inboxEvent{
+				Time: todo.CreatedAt,
+				Kind: "forge",
+				Text: fmt.Sprintf("%s: %s (%s)", todo.Project.Path, todo.Target.Title, strings.ReplaceAll(todo.ActionName, "_", " ")),
+				ID:   fmt.Sprintf("%s/todo/%d", f.host(), todo.ID),
+			})
+		}
+		return events, nil
+	}
+
+	var notifications []struct {
+		ID        string    `json:"id"`
+		Reason    string    `json:"reason"`
+		UpdatedAt time.Time `json:"updated_at"`
+		Subject   struct {
+			Title string `json:"title"`
+		} `json:"subject"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := forgeRequest(f, "GET", "/notifications?per_page=50", nil, &notifications); err != nil {
+		return nil, err
+	}
+	for _, n := range notifications {
+		events = append(events, inboxEvent{
+			Time: n.UpdatedAt,
+			Kind: "forge",
+			Text: fmt.Sprintf("%s: %s (%s)", n.Repository.FullName, n.Subject.Title, strings.ReplaceAll(n.Reason, "_", " ")),
+			ID:   fmt.Sprintf("%s/notification/%s/%s", f.host(), n.ID, n.UpdatedAt.Format(time.RFC3339)),
+		})
+	}
+	return events, nil
+}
+
+// loadForgeNotificationsCmd collects notifications from every configured
+// forge in the background
+func loadForgeNotificationsCmd() tea.Cmd {
+	if offline {
+		return nil
+	}
+	userConfig, err := loadConfig()
+	if err != nil || len(userConfig.Forges) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		var msg forgeNotificationsMsg
+		for _, forge := range userConfig.Forges {
+			events, err := forgeNotifications(forge)
+			if err != nil && msg.err == nil {
+				msg.err = fmt.Errorf("%s: %v", forge.host(), err)
+			}
+			msg.events = append(msg.events, events...)
+		}
+		return msg
+	}
+}
+
+// inboxView lists the newest events that fit, unread ones highlighted
+func (m model) inboxView() string {
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		MaxWidth(m.termWidth)
+	unreadStyle := lipgloss.NewStyle().Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Inbox, %d unread", m.unreadEvents()))
+	if len(m.inbox) == 0 {
+		b.WriteString("\n" + dimStyle.Render("Nothing has happened yet"))
+	}
+	rows := max(3, m.termHeight/2)
+	for i := len(m.inbox) - 1; i >= 0 && i >= len(m.inbox)-rows; i-- {
+		event := m.inbox[i]
+		when := event.Time.Local().Format("15:04")
+		if event.Time.Local().YearDay() != time.Now().YearDay() {
+			when = event.Time.Local().Format("Jan 2 15:04")
+		}
+		line := fmt.Sprintf("%s %-6s %s", when, event.Kind, event.Text)
+		if event.Read {
+			line = dimStyle.Render("  " + line)
+		} else {
+			line = unreadStyle.Render("• " + line)
+		}
+		b.WriteString("\n" + line)
+	}
+	return boxStyle.Render(b.String())
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Locks are files under <config dir>/locks holding the owner's pid and start
+// time. A lock is written to a temporary file and hard-linked into place,
+// which fails if the lock exists, so only one process holds a given lock and
+// nobody reads a half-written one. One left behind by a process that died is
+// taken over.
+
+// lockPath is where the lock for key lives. Keys are hashed so paths can
+// be used as keys.
+func lockPath(key string) (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(configDir, "locks", hex.EncodeToString(sum[:8])+".lock"), nil
+}
+
+// processAlive reports whether pid is still running. Windows can't be
+// signalled, so a pid it still finds counts as alive.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM) || runtime.GOOS == "windows"
+}
+
+// processStartTime identifies when pid started, so a lock isn't kept alive
+// by another process that was given the same pid. It is "" when it can't
+// be told, and then only the pid is compared.
+func processStartTime(pid int) string {
+	if runtime.GOOS == "linux" {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			return ""
+		}
+		// the fields after the command name, which may hold spaces and
+		// parentheses itself; the start time is the 22nd field overall
+		stat := string(data)
+		fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+		if len(fields) < 20 {
+			return ""
+		}
+		return fields[19]
+	}
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(string(out)), "_")
+}
+
+var ownLockContents = sync.OnceValue(func() string {
+	return fmt.Sprintf("%d %s\n", os.Getpid(), processStartTime(os.Getpid()))
+})
+
+// lockHolder reads a lock's contents, reporting its pid and whether that
+// process still holds it
+func lockHolder(contents string) (pid int, held bool) {
+	fields := strings.Fields(contents)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil || !processAlive(pid) {
+		return pid, false
+	}
+	if len(fields) > 1 {
+		if started := processStartTime(pid); started != "" && started != fields[1] {
+			return pid, false // the pid was reused
+		}
+	}
+	return pid, true
+}
+
+// createLock writes our lock next to path and links it into place, failing
+// with an os.IsExist error when the lock is taken
+func createLock(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".lock-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(ownLockContents())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Link(tmp.Name(), path)
+}
+
+// removeStaleLock takes a dead owner's lock away. The lock is renamed out
+// of the way first and then compared with what was found stale: if another
+// process took it over in the meantime, its fresh lock is put back.
+func removeStaleLock(path string, stale []byte) {
+	moved := fmt.Sprintf("%s.%d.stale", path, os.Getpid())
+	if err := os.Rename(path, moved); err != nil {
+		return // someone else got there first
+	}
+	if data, err := os.ReadFile(moved); err == nil && !bytes.Equal(data, stale) {
+		os.Link(moved, path)
+	}
+	os.Remove(moved)
+}
+
+// tryLock takes the lock at path, or says which pid holds it. A lock this
+// process already holds is not taken again.
+func tryLock(path string) (acquired bool, owner int, err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, 0, err
+	}
+	for attempt := 0; attempt < 3; attempt++ {
+		err := createLock(path)
+		if err == nil {
+			return true, os.Getpid(), nil
+		}
+		if !os.IsExist(err) {
+			return false, 0, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue // released in the meantime
+		}
+		if pid, held := lockHolder(string(data)); held {
+			return false, pid, nil
+		}
+		removeStaleLock(path, data)
+	}
+	return false, 0, fmt.Errorf("could not take lock %s", path)
+}
+
+// lockMutexes keep goroutines of this process out of each other's way per
+// lock path; the lock file only tells processes apart
+var lockMutexes sync.Map
+
+// withLock runs fn holding the lock for key, waiting up to ten seconds for
+// another instance, or another goroutine of this one, to finish with it
+func withLock(key string, fn func() error) error {
+	path, err := lockPath(key)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	mutex, _ := lockMutexes.LoadOrStore(path, &sync.Mutex{})
+	for !mutex.(*sync.Mutex).TryLock() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("still busy writing, try again later")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer mutex.(*sync.Mutex).Unlock()
+	for {
+		acquired, owner, err := tryLock(path)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("another git-status-dash (pid %d) is still writing, try again later", owner)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	defer os.Remove(path)
+	return fn()
+}
+
+// treeWatch keeps two TUIs on the same tree from both watching it. The one
+// holding the tree's lock watches the repos and touches an events file after
+// each change; the others only watch that file and rescan when it changes,
+// and one of them takes over when the owner exits.
+type treeWatch struct {
+	lock   string
+	events string
+	owner  int // pid holding the lock, ours when we watch the tree
+}
+
+type treeWatchFreedMsg struct{}
+
+// joinTreeWatch takes the watch on baseDir, or follows the instance that has
+// it. It returns nil when locks can't be used, and the TUI then watches on
+// its own as before.
+func joinTreeWatch(baseDir string, watcher *fsnotify.Watcher) *treeWatch {
+	if watcher == nil {
+		return nil
+	}
+	abs, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil
+	}
+	path, err := lockPath("watch " + abs)
+	if err != nil {
+		return nil
+	}
+	_, owner, err := tryLock(path)
+	if err != nil {
+		return nil
+	}
+	w := &treeWatch{lock: path, events: strings.TrimSuffix(path, ".lock") + ".events", owner: owner}
+	if !w.owns() {
+		watcher.Add(filepath.Dir(path))
+	}
+	return w
+}
+
+// owns reports whether this instance watches the tree itself
+func (w *treeWatch) owns() bool {
+	return w == nil || w.owner == os.Getpid()
+}
+
+// relay tells the following instances that the tree changed
+func (w *treeWatch) relay() {
+	if w != nil && w.owns() {
+		os.WriteFile(w.events, []byte(time.Now().Format(time.RFC3339Nano)+"\n"), 0644)
+	}
+}
+
+// followed is what a follower does about an event in the locks directory:
+// rescan, try to take over, or nothing (nil)
+func (w *treeWatch) followed(event fsnotify.Event) tea.Msg {
+	switch {
+	case event.Name == w.events && event.Op&(fsnotify.Write|fsnotify.Create) != 0:
+		return fileChangeMsg(event.Name)
+	case event.Name == w.lock && event.Op&fsnotify.Remove != 0:
+		return treeWatchFreedMsg{}
+	}
+	return nil
+}
+
+// takeOver claims the watch after its owner let go, reporting whether this
+// instance got it
+func (w *treeWatch) takeOver() bool {
+	_, owner, err := tryLock(w.lock)
+	if err != nil {
+		return false
+	}
+	w.owner = owner
+	return w.owns()
+}
+
+// release gives up the watch so a follower can take it
+func (w *treeWatch) release() {
+	if w != nil && w.owns() {
+		os.Remove(w.lock)
+	}
+}
+
+// moveTo gives up the watch on the current tree and joins the one on
+// baseDir, in place so the release deferred at startup still covers it
+func (w *treeWatch) moveTo(baseDir string, watcher *fsnotify.Watcher) {
+	if w == nil {
+		return
+	}
+	w.release()
+	if next := joinTreeWatch(baseDir, watcher); next != nil {
+		*w = *next
+		return
+	}
+	*w = treeWatch{owner: os.Getpid()} // no lock for the new tree, watch it alone
+}
+
+// followNotice explains why the tree isn't watched by this instance
+func (w *treeWatch) followNotice() string {
+	if w.owns() {
+		return ""
+	}
+	return fmt.Sprintf("Another git-status-dash (pid %d) is watching this tree, following its changes", w.owner)
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// integrationEnv is what the integration templates are filled in with
+type integrationEnv struct {
+	Binary string // git-status-dash as found in PATH, so package upgrades don't break it
+	Dir    string // workspace the integrations watch
+	Shell  string // bash, zsh or fish, from $SHELL
+	Home   string
+	Config string // git-status-dash's config directory
+	root   *cobra.Command
+}
+
+// integrationFile is one file an integration writes. Block files are
+// someone else's (a shell rc file, tmux.conf): only the marked block in
+// them is added or replaced.
+type integrationFile struct {
+	Path    string
+	Content string
+	Mode    os.FileMode
+	Block   bool
+}
+
+type integration struct {
+	Name        string
+	Description string
+	OS          []string // runtime.GOOS values it applies to, all when empty
+	Files       func(env integrationEnv) ([]integrationFile, error)
+	Next        string // what to do once it is installed, if anything
+}
+
+const (
+	blockStart = "# >>> git-status-dash >>>"
+	blockEnd   = "# <<< git-status-dash <<<"
+)
+
+var shellInitTemplate = map[string]string{
+	"bash": `# gsd: git-status-dash on {{.Dir}} unless told otherwise
+gsd() { if [ $# -eq 0 ]; then {{sh .Binary}} {{sh .Dir}}; else {{sh .Binary}} "$@"; fi; }
+complete -o default -F __start_git-status-dash gsd 2>/dev/null
+`,
+	"zsh": `# gsd: git-status-dash on {{.Dir}} unless told otherwise
+gsd() { if (( $# == 0 )); then {{sh .Binary}} {{sh .Dir}}; else {{sh .Binary}} "$@"; fi }
+fpath=({{sh .Config}}/completions $fpath)
+`,
+	"fish": `# gsd: git-status-dash on {{.Dir}} unless told otherwise
+function gsd --wraps git-status-dash
+    if test (count $argv) -eq 0
+        {{fish .Binary}} {{fish .Dir}}
+    else
+        {{fish .Binary}} $argv
+    end
+end
+`,
+}
+
+const tmuxTemplate = `# git-status-dash: repo counts in the status bar, from the last saved scan
+set -ag status-right {{tmuxJob (printf "%s motd --summary --color=never %s" (sh .Binary) (sh .Dir))}}
+set -g status-interval 60
+`
+
+const xbarTemplate = `#!/bin/sh
+# <xbar.title>git-status-dash</xbar.title>
+# <xbar.desc>Repos needing attention under {{.Dir}}</xbar.desc>
+out=$({{sh .Binary}} motd --color=never -n 20 {{sh .Dir}})
+echo "$out" | sed -n 2p
+echo "---"
+echo "$out" | sed -n '3,$p'
+echo "---"
+echo {{sh (printf "Open dashboard | shell=%q param1=%q terminal=true" .Binary .Dir)}}
+echo {{sh (printf "Refresh | shell=%q param1=motd param2=--refresh param3=%q terminal=false refresh=true" .Binary .Dir)}}
+`
+
+const systemdServiceTemplate = `[Unit]
+Description=Save a git-status-dash scan of {{specifiers .Dir}} for motd, tmux and xbar
+
+[Service]
+Type=oneshot
+ExecStart={{systemd .Binary}} motd --refresh {{systemd .Dir}}
+`
+
+const systemdTimerTemplate = `[Unit]
+Description=Rescan {{specifiers .Dir}} with git-status-dash every 15 minutes
+
+[Timer]
+OnStartupSec=1min
+OnUnitActiveSec=15min
+
+[Install]
+WantedBy=timers.target
+`
+
+const launchdTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{.Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{xml .Binary}}</string>
+		<string>motd</string>
+		<string>--refresh</string>
+		<string>{{xml .Dir}}</string>
+	</array>
+	<key>StartInterval</key>
+	<integer>900</integer>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>/dev/null</string>
+</dict>
+</plist>
+`
+
+// rcFile is the file a shell reads at startup
+func (env integrationEnv) rcFile() string {
+	switch env.Shell {
+	case "zsh":
+		return filepath.Join(env.Home, ".zshrc")
+	case "fish":
+		return filepath.Join(env.Home, ".config", "fish", "conf.d", "git-status-dash.fish")
+	}
+	return filepath.Join(env.Home, ".bashrc")
+}
+
+var integrations = []integration{
+	{
+		Name:        "shell",
+		Description: "gsd function running git-status-dash on the workspace, sourced from your shell's rc file",
+		OS:          []string{"linux", "darwin", "freebsd"},
+		Files: func(env integrationEnv) ([]integrationFile, error) {
+			text, err := renderIntegration(shellInitTemplate[env.Shell], env)
+			if err != nil {
+				return nil, err
+			}
+			if env.Shell == "fish" {
+				return []integrationFile{{Path: env.rcFile(), Content: text, Mode: 0644}}, nil
+			}
+			initFile := filepath.Join(env.Config, "shell", "init."+env.Shell)
+			return []integrationFile{
+				{Path: initFile, Content: text, Mode: 0644},
+				{Path: env.rcFile(), Content: fmt.Sprintf("[ -f %s ] && . %s\n", shellQuote(initFile), shellQuote(initFile)), Mode: 0644, Block: true},
+			}, nil
+		},
+		Next: "Open a new shell to use gsd",
+	},
+	{
+		Name:        "completions",
+		Description: "tab completion for your shell",
+		Files: func(env integrationEnv) ([]integrationFile, error) {
+			var b bytes.Buffer
+			var path string
+			var err error
+			switch {
+			case runtime.GOOS == "windows":
+				path = filepath.Join(env.Config, "completions", "git-status-dash.ps1")
+				err = env.root.GenPowerShellCompletionWithDesc(&b)
+			case env.Shell == "zsh":
+				// the shell integration puts this directory on $fpath
+				path = filepath.Join(env.Config, "completions", "_git-status-dash")
+				err = env.root.GenZshCompletion(&b)
+			case env.Shell == "fish":
+				path = filepath.Join(env.Home, ".config", "fish", "completions", "git-status-dash.fish")
+				err = env.root.GenFishCompletion(&b, true)
+			default:
+				// loaded on demand by bash-completion
+				dataHome := os.Getenv("XDG_DATA_HOME")
+				if dataHome == "" {
+					dataHome = filepath.Join(env.Home, ".local", "share")
+				}
+				path = filepath.Join(dataHome, "bash-completion", "completions", "git-status-dash")
+				err = env.root.GenBashCompletionV2(&b, true)
+			}
+			if err != nil {
+				return nil, err
+			}
+			files := []integrationFile{{Path: path, Content: b.String(), Mode: 0644}}
+			if runtime.GOOS == "windows" {
+				profile := filepath.Join(env.Home, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1")
+				files = append(files, integrationFile{Path: profile, Content: fmt.Sprintf(". '%s'\n", strings.ReplaceAll(path, "'", "''")), Mode: 0644, Block: true})
+			}
+			return files, nil
+		},
+	},
+	{
+		Name:        "tmux",
+		Description: "repo counts in the tmux status bar",
+		OS:          []string{"linux", "darwin", "freebsd"},
+		Files: func(env integrationEnv) ([]integrationFile, error) {
+			text, err := renderIntegration(tmuxTemplate, env)
+			if err != nil {
+				return nil, err
+			}
+			snippet := filepath.Join(env.Config, "tmux.conf")
+			tmuxConf := filepath.Join(env.Home, ".tmux.conf")
+			if xdg := filepath.Join(env.Home, ".config", "tmux", "tmux.conf"); fileExists(xdg) {
+				tmuxConf = xdg
+			}
+			return []integrationFile{
+				{Path: snippet, Content: text, Mode: 0644},
+				{Path: tmuxConf, Content: "source-file " + tmuxQuote(snippet) + "\n", Mode: 0644, Block: true},
+			}, nil
+		},
+		Next: "Reload tmux with `tmux source-file ~/.tmux.conf`; install the refresh service to keep the counts current",
+	},
+	{
+		Name:        "xbar",
+		Description: "menu bar plugin for xbar (or SwiftBar) listing the repos needing attention",
+		OS:          []string{"darwin"},
+		Files: func(env integrationEnv) ([]integrationFile, error) {
+			text, err := renderIntegration(xbarTemplate, env)
+			if err != nil {
+				return nil, err
+			}
+			path := filepath.Join(env.Home, "Library", "Application Support", "xbar", "plugins", "git-status-dash.5m.sh")
+			return []integrationFile{{Path: path, Content: text, Mode: 0755}}, nil
+		},
+	},
+	{
+		Name:        "service",
+		Description: "background job rescanning the workspace every 15 minutes for motd, tmux and xbar",
+		OS:          []string{"linux", "darwin"},
+		Files: func(env integrationEnv) ([]integrationFile, error) {
+			if runtime.GOOS == "darwin" {
+				text, err := renderIntegration(launchdTemplate, env)
+				return []integrationFile{{Path: launchdPlist(env.Home), Content: text, Mode: 0644}}, err
+			}
+			service, err := renderIntegration(systemdServiceTemplate, env)
+			if err != nil {
+				return nil, err
+			}
+			timer, err := renderIntegration(systemdTimerTemplate, env)
+			units := filepath.Join(env.Home, ".config", "systemd", "user")
+			return []integrationFile{
+				{Path: filepath.Join(units, systemdUnit+".service"), Content: service, Mode: 0644},
+				{Path: filepath.Join(units, systemdUnit+".timer"), Content: timer, Mode: 0644},
+			}, err
+		},
+		Next: "Start it with `git-status-dash daemon start`",
+	},
+}
+
+// integrationFuncs quote paths for the files the templates produce, so a
+// workspace or binary path can't break out of its argument
+var integrationFuncs = template.FuncMap{
+	"sh":         shellQuote,
+	"fish":       fishQuote,
+	"tmuxJob":    tmuxJob,
+	"systemd":    systemdQuote,
+	"specifiers": func(s string) string { return strings.ReplaceAll(s, "%", "%%") },
+	"xml": func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	},
+}
+
+// shellQuote makes s a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote makes s a single fish word; fish single quotes do take \' and \\
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// tmuxQuote makes s a double-quoted string in a tmux config file
+func tmuxQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`).Replace(s) + `"`
+}
+
+// tmuxJob is a status line entry running the shell command cmd, with its #
+// doubled so the status line formats leave it alone
+func tmuxJob(cmd string) string {
+	return tmuxQuote(" #(" + strings.ReplaceAll(cmd, "#", "##") + ")")
+}
+
+// systemdQuote makes s a single argument on a systemd Exec line
+func systemdQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(s) + `"`
+}
+
+func renderIntegration(text string, env integrationEnv) (string, error) {
+	tmpl, err := template.New("integration").Funcs(integrationFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, env)
+	return b.String(), err
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// withBlock puts content between the markers in existing, replacing the
+// block a previous install left there
+func withBlock(existing, content string) string {
+	block := blockStart + "\n" + content + blockEnd + "\n"
+	start := strings.Index(existing, blockStart)
+	end := strings.Index(existing, blockEnd)
+	if start >= 0 && end > start {
+		return existing[:start] + block + strings.TrimPrefix(existing[end+len(blockEnd):], "\n")
+	}
+	if existing != "" && !strings.HasSuffix(existing, "\n") {
+		existing += "\n"
+	}
+	if existing != "" {
+		existing += "\n"
+	}
+	return existing + block
+}
+
+func newIntegrationEnv(root *cobra.Command, dir string) (integrationEnv, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return integrationEnv{}, err
+	}
+	configDir, err := getConfigDir()
+	if err != nil {
+		return integrationEnv{}, err
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return integrationEnv{}, err
+	}
+	binary, err := exec.LookPath("git-status-dash")
+	if err != nil {
+		if binary, err = os.Executable(); err != nil {
+			return integrationEnv{}, err
+		}
+	}
+	for _, path := range []string{dir, binary, home, configDir} {
+		if strings.ContainsAny(path, "\n\r") {
+			return integrationEnv{}, fmt.Errorf("%q has a line break, which the integration files can't hold", path)
+		}
+	}
+	shell := filepath.Base(os.Getenv("SHELL"))
+	if shell != "zsh" && shell != "fish" {
+		shell = "bash"
+	}
+	return integrationEnv{Binary: binary, Dir: dir, Shell: shell, Home: home, Config: configDir, root: root}, nil
+}
+
+// runInstallIntegrations writes the named integrations, or every one that
+// applies to this OS. Files that already exist are left alone unless force
+// is set; marked blocks in rc files are always brought up to date.
+func runInstallIntegrations(root *cobra.Command, dir string, names []string, dryRun, force bool) error {
+	env, err := newIntegrationEnv(root, dir)
+	if err != nil {
+		return err
+	}
+
+	var chosen []integration
+	for _, name := range names {
+		found := false
+		for _, candidate := range integrations {
+			if candidate.Name == name {
+				chosen, found = append(chosen, candidate), true
+			}
+		}
+		if !found {
+			available := make([]string, len(integrations))
+			for i, candidate := range integrations {
+				available[i] = candidate.Name
+			}
+			return fmt.Errorf("unknown integration %q (available: %s)", name, strings.Join(available, ", "))
+		}
+	}
+	if len(names) == 0 {
+		chosen = integrations
+	}
+
+	failed := false
+	for _, item := range chosen {
+		if len(item.OS) > 0 && !slices.Contains(item.OS, runtime.GOOS) {
+			if len(names) > 0 {
+				fmt.Printf("✗ %-12s not available on %s\n", item.Name, runtime.GOOS)
+				failed = true
+			}
+			continue
+		}
+		files, err := item.Files(env)
+		if err != nil {
+			fmt.Printf("✗ %-12s %v\n", item.Name, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s — %s\n", item.Name, item.Description)
+		for _, file := range files {
+			if err := installIntegrationFile(file, dryRun, force); err != nil {
+				fmt.Printf("  ✗ %s: %v\n", file.Path, err)
+				failed = true
+			}
+		}
+		if item.Next != "" && !dryRun {
+			fmt.Printf("  %s\n", item.Next)
+		}
+	}
+	if failed {
+		return fmt.Errorf("some integrations weren't installed")
+	}
+	return nil
+}
+
+// followLinks is the file path ends up at after its symlinks, which may not
+// exist yet
+func followLinks(path string) string {
+	for hops := 0; hops < 40; hops++ {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return path
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return path
+}
+
+func installIntegrationFile(file integrationFile, dryRun, force bool) error {
+	existing, readErr := os.ReadFile(file.Path)
+	content := file.Content
+	switch {
+	case file.Block:
+		content = withBlock(string(existing), file.Content)
+		if readErr == nil && content == string(existing) {
+			fmt.Printf("  - %s (up to date)\n", file.Path)
+			return nil
+		}
+	case readErr == nil && string(existing) == content:
+		fmt.Printf("  - %s (up to date)\n", file.Path)
+		return nil
+	case readErr == nil && !force:
+		fmt.Printf("  - %s (exists and differs, --force replaces it)\n", file.Path)
+		return nil
+	}
+	if dryRun {
+		fmt.Printf("  ~ would write %s\n", file.Path)
+		return nil
+	}
+	// dotfile managers symlink rc files into place; write where the link
+	// points rather than replacing the link with a file
+	path := followLinks(file.Path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	mode := file.Mode
+	if info, err := os.Stat(path); err == nil && file.Block {
+		mode = info.Mode().Perm() // keep an rc file's own permissions
+	}
+	if err := writeFileAtomic(path, []byte(content), mode); err != nil {
+		return err
+	}
+	fmt.Printf("  ✓ %s\n", file.Path)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit renders one test case per repo. Dirty and diverged repos are
+// failures, repos that couldn't be read are errors, everything else passes.
+func writeJUnit(w io.Writer, suiteName string, repos []GitStatus) error {
+	suite := junitTestSuite{Name: suiteName, Tests: len(repos)}
+	for _, repo := range repos {
+		name := repo.RelativePath
+		if name == "" {
+			name = "."
+		}
+		testCase := junitTestCase{
+			Name:      name,
+			ClassName: "git-status-dash",
+			SystemOut: fmt.Sprintf("path: %s, branch: %s", repo.RepoPath, describeBranch(repo)),
+		}
+
+		switch key := repo.statusKey(); key {
+		case "error":
+			testCase.Error = &junitProblem{Message: repo.Message, Type: key, Text: repo.Message}
+			suite.Errors++
+		case "dirty", "diverged":
+			testCase.Failure = &junitProblem{Message: repo.Message, Type: key, Text: repo.Message}
+			suite.Failures++
+		}
+		if repo.Dirty && testCase.Failure == nil && testCase.Error == nil {
+			testCase.Failure = &junitProblem{Message: repo.Message + " (uncommitted changes)", Type: "dirty", Text: repo.Message}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// defaultTopLimit is how many repos --top shows without --limit
+const defaultTopLimit = 10
+
+// topSort is the order --top ranks a state's repos in: the largest counts
+// for ahead and behind, the most recently touched for everything else
+func topSort(state string) string {
+	switch state {
+	case "ahead", "behind":
+		return state
+	}
+	return "mtime"
+}
+
+// applyTop turns --top STATE into the filter, sort and limit it stands for
+func applyTop(cfg *Config) error {
+	if cfg.Top == "" {
+		return nil
+	}
+	if len(cfg.Only) > 0 {
+		return fmt.Errorf("--top and --only can't be combined; --top already picks the state")
+	}
+	if err := validateStates([]string{cfg.Top}); err != nil {
+		return err
+	}
+	cfg.Only = []string{cfg.Top}
+	if cfg.Sort == "" {
+		cfg.Sort = topSort(cfg.Top)
+	}
+	if cfg.Limit == 0 {
+		cfg.Limit = defaultTopLimit
+	}
+	return nil
+}
+
+// limitRepos keeps the first limit repos of an already sorted list and
+// says how many were cut. A limit of 0 keeps everything.
+func limitRepos(repos []GitStatus, limit int) ([]GitStatus, int) {
+	if limit <= 0 || len(repos) <= limit {
+		return repos, 0
+	}
+	return repos[:limit], len(repos) - limit
+}
+
+// writeMoreLine notes the repos --limit left out of a report
+func writeMoreLine(w io.Writer, hidden int) {
+	if hidden > 0 {
+		fmt.Fprintf(w, "… and %d more\n", hidden)
+	}
+}
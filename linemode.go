@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// terminals known to lack the alternate screen the TUI draws on
+var limitedTerms = map[string]bool{"": true, "dumb": true, "unknown": true, "vt52": true, "vt100": true, "vt102": true}
+
+// limitedTerminal explains why the full-screen TUI won't work here, or
+// returns "" when it should. An unset locale is taken as fine since plenty
+// of UTF-8 terminals never set one; only an explicit non-UTF-8 one counts.
+func limitedTerminal() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	if name := os.Getenv("TERM"); limitedTerms[name] {
+		if name == "" {
+			return "TERM is not set"
+		}
+		return fmt.Sprintf("TERM=%s has no alternate screen", name)
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			lower := strings.ToLower(value)
+			if !strings.Contains(lower, "utf-8") && !strings.Contains(lower, "utf8") {
+				return fmt.Sprintf("%s=%s is not a UTF-8 locale", name, value)
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// plainSymbols spells out the status symbols in notices for terminals that
+// can't show them
+var plainSymbols = strings.NewReplacer("✓", "ok:", "✗", "error:", "⚠", "warning:", "☠", "damaged:", "↑", "ahead", "↓", "behind")
+
+// readAnswer prints question and reads a line, reporting false at end of input
+func readAnswer(question string) (string, bool) {
+	fmt.Printf("%s: ", question)
+	answer, err := stdinReader.ReadString('\n')
+	if err == io.EOF && answer == "" {
+		fmt.Println()
+		return "", false
+	}
+	return strings.TrimSpace(answer), true
+}
+
+// runLineMode is the dashboard for terminals the TUI can't drive: a
+// numbered list in plain ASCII, rescanned after every command
+func runLineMode(reason string) {
+	if reason != "" {
+		fmt.Printf("Line mode: %s (use --tui to force the full-screen dashboard)\n\n", reason)
+	}
+	cfg := config
+	sortBy := config.Sort
+	if sortBy == "" {
+		sortBy = "path"
+	}
+	for {
+		repos := findGitReposOptimized(config.Directory, config.Depth)
+		visible := filterRepos(repos, cfg)
+		sortRepos(visible, sortBy)
+		printLineList(visible, len(repos))
+		if unreadable := scanUnreadable.list(); len(unreadable) > 0 {
+			fmt.Printf("warning: %s\n", unreadableSummary(len(unreadable)))
+		}
+
+		answer, ok := readAnswer("\nNumber for details, a: toggle all, r: refresh, q: quit")
+		if !ok {
+			return
+		}
+		switch answer {
+		case "q", "quit", "exit":
+			return
+		case "a":
+			cfg.All = !cfg.All
+		case "", "r":
+		default:
+			n, err := strconv.Atoi(answer)
+			if err != nil || n < 1 || n > len(visible) {
+				fmt.Printf("No repository numbered %q\n", answer)
+				continue
+			}
+			if !lineModeRepo(visible[n-1]) {
+				return
+			}
+		}
+		fmt.Println()
+	}
+}
+
+func printLineList(repos []GitStatus, total int) {
+	fmt.Printf("%d of %d repositories shown\n", len(repos), total)
+	width := 0
+	for _, repo := range repos {
+		width = max(width, len(displayName(repo)))
+	}
+	for i, repo := range repos {
+		line := fmt.Sprintf("%3d  %-11s  %-*s  %s", i+1, repo.statusKey(), width, displayName(repo), repo.Message)
+		if repo.OffDefaultBranch() {
+			line += fmt.Sprintf(" (on %s)", repo.Branch)
+		}
+		fmt.Println(line)
+	}
+}
+
+// lineModeRepo shows one repo and runs actions on it until the user goes
+// back; false means input ended
+func lineModeRepo(repo GitStatus) bool {
+	for {
+		fmt.Printf("\n%s\n", displayName(repo))
+		fmt.Printf("  path:    %s\n", repo.RepoPath)
+		fmt.Printf("  status:  %s, %s\n", repo.statusKey(), repo.Message)
+		if repo.Branch != "" {
+			fmt.Printf("  branch:  %s (default %s)\n", repo.Branch, repo.DefaultBranch)
+		}
+		if repo.HasUpstream {
+			fmt.Printf("  ahead %d, behind %d\n", repo.Ahead, repo.Behind)
+		}
+
+		answer, ok := readAnswer("f: fetch, p: pull, e: editor, !: shell, o/pr/issues/pipeline: browser, b: back")
+		if !ok {
+			return false
+		}
+		var notice string
+		switch answer {
+		case "", "b", "back":
+			return true
+		case "f":
+			msg := fetchRepoCmd(repo, config.Directory)().(repoStatusMsg)
+			repo, notice = msg.status, msg.notice
+		case "p":
+			switch {
+			case repo.Symbol != "↓":
+				notice = "Nothing to pull (only repos that are behind can be fast-forwarded)"
+			case isProtected(repo.RepoPath):
+				notice = fmt.Sprintf("✗ %s is %s", displayName(repo), protectedMessage)
+			default:
+				msg := pullRepoCmd(repo, config.Directory)().(repoStatusMsg)
+				repo, notice = msg.status, msg.notice
+			}
+		case "e":
+			args, err := editorCommand(repo.RepoPath)
+			if err != nil {
+				notice = fmt.Sprintf("✗ Could not open editor: %v", err)
+				break
+			}
+			repo, notice = runInRepo(repo, "editor", args)
+		case "!":
+			repo, notice = runInRepo(repo, "shell", shellCommand(repo.RepoPath))
+		case "o", "pr", "issues", "pipeline":
+			link, err := forgePageURL(repo, strings.TrimPrefix(answer, "o"))
+			if err == nil {
+				err = startDetached(repo.RepoPath, browserCommand(link))
+			}
+			notice = fmt.Sprintf("✓ Opened %s", link)
+			if err != nil {
+				notice = fmt.Sprintf("✗ Could not open the browser: %v", err)
+			}
+		default:
+			notice = fmt.Sprintf("Unknown command %q", answer)
+		}
+		fmt.Println(plainSymbols.Replace(notice))
+	}
+}
+
+// runInRepo runs an interactive program in the repo and rescans it after
+func runInRepo(repo GitStatus, what string, args []string) (GitStatus, string) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = repo.RepoPath
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	notice := fmt.Sprintf("✓ Closed %s for %s", what, displayName(repo))
+	if err := cmd.Run(); err != nil {
+		notice = fmt.Sprintf("✗ %s failed for %s: %v", what, displayName(repo), err)
+	}
+	return getGitStatus(repo.RepoPath, config.Directory, nil), notice
+}
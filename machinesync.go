@@ -0,0 +1,537 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SyncConfig says where each of your machines publishes its status for the
+// others to read. Snapshots are encrypted with a key only your machines
+// have, so the backend never sees repo names or paths.
+type SyncConfig struct {
+	Backend     string `json:"backend,omitempty"` // git (any repo you can push to) or webdav
+	URL         string `json:"url,omitempty"`
+	Username    string `json:"username,omitempty"` // webdav only
+	PasswordEnv string `json:"password_env,omitempty"`
+	Host        string `json:"host,omitempty"` // this machine's name, defaults to the hostname
+}
+
+var syncBackends = []string{"git", "webdav"}
+
+func (s SyncConfig) host() string {
+	if s.Host != "" {
+		return s.Host
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+// machineStatus is one machine's last scan, as published to the backend
+type machineStatus struct {
+	Host      string      `json:"host"`
+	Root      string      `json:"root"`
+	ScannedAt time.Time   `json:"scanned_at"`
+	Repos     []GitStatus `json:"repos"`
+}
+
+// syncFileSuffix marks the encrypted snapshots among whatever else the
+// backend holds
+const syncFileSuffix = ".gsd"
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func syncFileName(host string) string {
+	return unsafeFileChars.ReplaceAllString(host, "-") + syncFileSuffix
+}
+
+// The key is 32 random bytes in the config directory, hex encoded. It's made
+// by `sync init` on the first machine and copied to the others.
+func syncKeyPath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "sync.key"), nil
+}
+
+func loadSyncKey() ([]byte, error) {
+	path, err := syncKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no sync key on this machine; run `git-status-dash sync init`")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSyncKey(strings.TrimSpace(string(data)))
+}
+
+func decodeSyncKey(text string) ([]byte, error) {
+	key, err := hex.DecodeString(text)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("a sync key is 64 hex digits")
+	}
+	return key, nil
+}
+
+// sealSnapshot encrypts with AES-256-GCM. The file name is authenticated
+// too, so one machine's snapshot can't be passed off as another's.
+func sealSnapshot(key []byte, name string, plain []byte) ([]byte, error) {
+	aead, err := syncCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, []byte(name)), nil
+}
+
+func openSnapshot(key []byte, name string, sealed []byte) ([]byte, error) {
+	aead, err := syncCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("%s is too short", name)
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("%s can't be decrypted; was it written with another key?", name)
+	}
+	return plain, nil
+}
+
+func syncCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// syncBackend stores the encrypted snapshots, one file per machine
+type syncBackend interface {
+	put(name string, data []byte) error
+	getAll() (map[string][]byte, error)
+}
+
+func newSyncBackend(s SyncConfig) (syncBackend, error) {
+	if s.URL == "" {
+		return nil, fmt.Errorf("sync.url isn't set")
+	}
+	switch s.Backend {
+	case "git":
+		configDir, err := getConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		return gitSyncBackend{url: s.URL, dir: filepath.Join(configDir, "sync")}, nil
+	case "webdav":
+		return webdavSyncBackend{url: strings.TrimSuffix(s.URL, "/") + "/", username: s.Username, password: os.Getenv(s.PasswordEnv)}, nil
+	case "":
+		return nil, fmt.Errorf("sync isn't set up; see `git-status-dash sync init`")
+	}
+	return nil, fmt.Errorf("unknown sync.backend %q (available: %s)", s.Backend, strings.Join(syncBackends, ", "))
+}
+
+// gitSyncBackend keeps the snapshots in a repo, on its main branch, through
+// a clone in the config directory. Every machine writes only its own file,
+// so pushes never conflict, they only race.
+type gitSyncBackend struct {
+	url, dir string
+}
+
+// update brings the clone to the remote's main, dropping anything local
+func (g gitSyncBackend) update() error {
+	if !fileExists(filepath.Join(g.dir, ".git")) {
+		if err := os.MkdirAll(filepath.Dir(g.dir), 0700); err != nil {
+			return err
+		}
+		if out, err := runGitLong(filepath.Dir(g.dir), "clone", "--quiet", g.url, g.dir); err != nil {
+			return fmt.Errorf("cloning %s: %s", g.url, lastLine(out))
+		}
+	}
+	if out, err := runGit(g.dir, "fetch", "--quiet", "origin"); err != nil {
+		if err == errOffline {
+			return err
+		}
+		return fmt.Errorf("fetching %s: %s", g.url, lastLine(out))
+	}
+	if _, err := runGit(g.dir, "rev-parse", "--verify", "--quiet", "origin/main"); err != nil {
+		return nil // nothing pushed yet
+	}
+	_, err := runGit(g.dir, "reset", "--quiet", "--hard", "origin/main")
+	return err
+}
+
+func (g gitSyncBackend) put(name string, data []byte) error {
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if err = g.update(); err != nil {
+			return err
+		}
+		if err = os.WriteFile(filepath.Join(g.dir, name), data, 0600); err != nil {
+			return err
+		}
+		if out, err := runGit(g.dir, "add", name); err != nil {
+			return fmt.Errorf("%s", lastLine(out))
+		}
+		if out, err := runGit(g.dir, "-c", "user.name=git-status-dash", "-c", "user.email=git-status-dash@localhost",
+			"commit", "--quiet", "-m", "Update "+name); err != nil {
+			return fmt.Errorf("%s", lastLine(out))
+		}
+		var out string
+		if out, err = runGit(g.dir, "push", "--quiet", "origin", "HEAD:main"); err == nil || err == errOffline {
+			return err
+		}
+		err = fmt.Errorf("pushing to %s: %s", g.url, lastLine(out))
+	}
+	return err
+}
+
+func (g gitSyncBackend) getAll() (map[string][]byte, error) {
+	if err := g.update(); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(g.dir, "*"+syncFileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	all := map[string][]byte{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		all[filepath.Base(file)] = data
+	}
+	return all, nil
+}
+
+// webdavSyncBackend keeps the snapshots in a WebDAV collection (Nextcloud,
+// a NAS, or an S3 bucket behind a gateway such as rclone serve webdav)
+type webdavSyncBackend struct {
+	url, username, password string
+}
+
+func (d webdavSyncBackend) do(method, name string, body []byte, header map[string]string) (*http.Response, error) {
+	if offline {
+		return nil, errOffline
+	}
+	client, err := httpClient()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, d.url+url.PathEscape(name), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if d.username != "" {
+		req.SetBasicAuth(d.username, d.password)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s", method, d.url+name, resp.Status)
+	}
+	return resp, nil
+}
+
+func (d webdavSyncBackend) put(name string, data []byte) error {
+	resp, err := d.do(http.MethodPut, name, data, map[string]string{"Content-Type": "application/octet-stream"})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (d webdavSyncBackend) getAll() (map[string][]byte, error) {
+	resp, err := d.do("PROPFIND", "", []byte(`<?xml version="1.0"?><propfind xmlns="DAV:"><prop><resourcetype/></prop></propfind>`),
+		map[string]string{"Depth": "1", "Content-Type": "application/xml"})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var listing struct {
+		Responses []struct {
+			Href string `xml:"href"`
+		} `xml:"response"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("reading the WebDAV listing: %v", err)
+	}
+
+	all := map[string][]byte{}
+	for _, r := range listing.Responses {
+		href, err := url.PathUnescape(r.Href)
+		if err != nil || !strings.HasSuffix(href, syncFileSuffix) {
+			continue
+		}
+		name := path.Base(href)
+		resp, err := d.do(http.MethodGet, name, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxTeamReport))
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		all[name] = data
+	}
+	return all, nil
+}
+
+// readSyncKeyFile reads a key installed with --key-file, "-" meaning
+// stdin, so the key stays out of argv and the shell history
+func readSyncKeyFile(keyFile string) (string, error) {
+	var data []byte
+	var err error
+	if keyFile == "-" {
+		data, err = io.ReadAll(io.LimitReader(os.Stdin, 1024))
+	} else {
+		data, err = os.ReadFile(keyFile)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// runSyncInit makes this machine's sync key, or installs the one made on
+// another machine, read from keyFile
+func runSyncInit(keyFile string) error {
+	path, err := syncKeyPath()
+	if err != nil {
+		return err
+	}
+	key := ""
+	if keyFile != "" {
+		if key, err = readSyncKeyFile(keyFile); err != nil {
+			return err
+		}
+	}
+	if key == "" {
+		if fileExists(path) {
+			return fmt.Errorf("this machine already has a sync key (%s); copy it to the others with `sync init --key-file`", path)
+		}
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		key = hex.EncodeToString(secret)
+	} else if _, err := decodeSyncKey(key); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, []byte(key+"\n"), 0600); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Sync key saved to %s\n\n", path)
+	if keyFile == "" {
+		fmt.Printf("Your key:\n  %s\n\n", key)
+		fmt.Println("On your other machines, paste it into `git-status-dash sync init --key-file -`,")
+		fmt.Printf("or copy %s over and pass its path to --key-file.\n\n", path)
+	}
+	fmt.Println("Then on every machine point them at the same place, e.g.:")
+	fmt.Println("  git-status-dash config set sync.backend git")
+	fmt.Println("  git-status-dash config set sync.url git@github.com:you/status-sync.git")
+	return nil
+}
+
+// publishStatus encrypts a scan of root and publishes it as this machine's
+func publishStatus(s SyncConfig, root string, repos []GitStatus) error {
+	key, err := loadSyncKey()
+	if err != nil {
+		return err
+	}
+	backend, err := newSyncBackend(s)
+	if err != nil {
+		return err
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(machineStatus{Host: s.host(), Root: root, ScannedAt: time.Now().UTC(), Repos: repos})
+	if err != nil {
+		return err
+	}
+	name := syncFileName(s.host())
+	sealed, err := sealSnapshot(key, name, plain)
+	if err != nil {
+		return err
+	}
+	return backend.put(name, sealed)
+}
+
+// otherMachines reads every machine's snapshot but this one's, most
+// recently scanned first. Snapshots that can't be decrypted or read, say
+// from a machine still on a rotated key, are skipped with a warning.
+func otherMachines(s SyncConfig) (machines []machineStatus, warnings []string, err error) {
+	key, err := loadSyncKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	backend, err := newSyncBackend(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := backend.getAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	machines, warnings = decodeSnapshots(key, files, syncFileName(s.host()))
+	return machines, warnings, nil
+}
+
+// decodeSnapshots opens every snapshot but skip's, most recently scanned
+// first, with a warning for each one that can't be read
+func decodeSnapshots(key []byte, files map[string][]byte, skip string) ([]machineStatus, []string) {
+	var machines []machineStatus
+	var warnings []string
+	for name, sealed := range files {
+		if name == skip {
+			continue
+		}
+		plain, err := openSnapshot(key, name, sealed)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("skipped %v", err))
+			continue
+		}
+		var machine machineStatus
+		if err := json.Unmarshal(plain, &machine); err != nil {
+			warnings = append(warnings, fmt.Sprintf("skipped %s: %v", name, err))
+			continue
+		}
+		machines = append(machines, machine)
+	}
+	sort.Slice(machines, func(i, j int) bool { return machines[i].ScannedAt.After(machines[j].ScannedAt) })
+	sort.Strings(warnings)
+	return machines, warnings
+}
+
+// machineSummary is the one line about another machine, e.g. "desktop has
+// 3 unpushed repos, 1 dirty (~/code, 20 minutes ago)"
+func machineSummary(m machineStatus) string {
+	unpushed, dirty := 0, 0
+	for _, repo := range m.Repos {
+		if repo.Ahead > 0 || (repo.HasRemote && !repo.HasUpstream) {
+			unpushed++
+		}
+		if repo.Dirty {
+			dirty++
+		}
+	}
+	where := fmt.Sprintf("(%s, %s)", m.Root, relativeTime(m.ScannedAt))
+	if unpushed == 0 && dirty == 0 {
+		return fmt.Sprintf("%s has nothing unpushed %s", m.Host, where)
+	}
+	return fmt.Sprintf("%s has %d unpushed repos, %d dirty %s", m.Host, unpushed, dirty, where)
+}
+
+// runSyncPush scans dir and publishes it
+func runSyncPush(dir string) error {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	repos := findGitReposOptimized(dir, config.Depth)
+	if err := publishStatus(userConfig.Sync, dir, repos); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Published the status of %d repositories as %s\n", len(repos), userConfig.Sync.host())
+	return nil
+}
+
+// runSyncStatus prints what the other machines published, and each of
+// their repos with unpushed or uncommitted work when verbose
+func runSyncStatus(verbose bool) error {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	machines, warnings, err := otherMachines(userConfig.Sync)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", warning)
+	}
+	if len(machines) == 0 {
+		fmt.Println("No other machine has published its status yet.")
+		return nil
+	}
+	for _, machine := range machines {
+		fmt.Printf("- %s\n", machineSummary(machine))
+		if !verbose {
+			continue
+		}
+		for _, repo := range machine.Repos {
+			if pendingWork(repo) {
+				fmt.Printf("    %s %-30s %s\n", repo.Symbol, displayName(repo), statusLabel(repo))
+			}
+		}
+	}
+	return nil
+}
+
+// how often the TUI footer reads the other machines' status again
+const machinesPollInterval = 5 * time.Minute
+
+// machinesMsg carries the other machines' summaries to the TUI footer
+type machinesMsg struct {
+	lines    []string
+	warnings []string // snapshots that were skipped
+	err      error
+}
+
+// machinesPollMsg is the tick that reads the other machines again
+type machinesPollMsg struct{}
+
+// loadMachinesCmd reads the other machines' status when sync is set up
+func loadMachinesCmd() tea.Cmd {
+	userConfig, err := loadConfig()
+	if err != nil || userConfig.Sync.Backend == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		machines, warnings, err := otherMachines(userConfig.Sync)
+		var lines []string
+		for _, machine := range machines {
+			lines = append(lines, machineSummary(machine))
+		}
+		return machinesMsg{lines: lines, warnings: warnings, err: err}
+	}
+}
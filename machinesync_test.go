@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSyncKey(fill byte) []byte {
+	return bytes.Repeat([]byte{fill}, 32)
+}
+
+func TestSealOpenSnapshot(t *testing.T) {
+	key := testSyncKey(1)
+	plain := []byte(`{"host":"laptop"}`)
+	sealed, err := sealSnapshot(key, "laptop.gsd", plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, plain) {
+		t.Fatal("sealed snapshot holds the plain text")
+	}
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		key     []byte
+		file    string
+		sealed  []byte
+		wantErr string
+	}{
+		{"round trip", key, "laptop.gsd", sealed, ""},
+		{"other key", testSyncKey(2), "laptop.gsd", sealed, "written with another key"},
+		{"renamed file", key, "desktop.gsd", sealed, "written with another key"},
+		{"tampered", key, "laptop.gsd", tampered, "written with another key"},
+		{"truncated", key, "laptop.gsd", sealed[:4], "too short"},
+		{"bad key size", key[:5], "laptop.gsd", sealed, "invalid key size"},
+	}
+	for _, tt := range tests {
+		got, err := openSnapshot(tt.key, tt.file, tt.sealed)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr == "" && !bytes.Equal(got, plain):
+			t.Errorf("%s: opened %q, want %q", tt.name, got, plain)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: error %v, want one mentioning %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSealSnapshotNonce(t *testing.T) {
+	key := testSyncKey(1)
+	first, _ := sealSnapshot(key, "a.gsd", []byte("same"))
+	second, _ := sealSnapshot(key, "a.gsd", []byte("same"))
+	if bytes.Equal(first, second) {
+		t.Error("sealing the same snapshot twice gave the same bytes")
+	}
+}
+
+func TestDecodeSyncKey(t *testing.T) {
+	tests := []struct {
+		text string
+		ok   bool
+	}{
+		{strings.Repeat("ab", 32), true},
+		{strings.Repeat("ab", 16), false},
+		{strings.Repeat("zz", 32), false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, err := decodeSyncKey(tt.text); (err == nil) != tt.ok {
+			t.Errorf("decodeSyncKey(%q) error = %v, want ok %v", tt.text, err, tt.ok)
+		}
+	}
+}
+
+func TestDecodeSnapshots(t *testing.T) {
+	key := testSyncKey(1)
+	seal := func(name string, machine machineStatus) []byte {
+		data, err := json.Marshal(machine)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sealed, err := sealSnapshot(key, name, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+	older := machineStatus{Host: "desktop", Root: "/code", ScannedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	newer := machineStatus{Host: "server", Root: "/srv", ScannedAt: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)}
+	notJSON, _ := sealSnapshot(key, "broken.gsd", []byte("not json"))
+	otherKey, _ := sealSnapshot(testSyncKey(9), "stranger.gsd", []byte("{}"))
+
+	tests := []struct {
+		name      string
+		files     map[string][]byte
+		skip      string
+		wantHosts []string
+		warnings  int
+	}{
+		{"none", nil, "", nil, 0},
+		{
+			"newest first",
+			map[string][]byte{"desktop.gsd": seal("desktop.gsd", older), "server.gsd": seal("server.gsd", newer)},
+			"", []string{"server", "desktop"}, 0,
+		},
+		{
+			"own snapshot skipped",
+			map[string][]byte{"desktop.gsd": seal("desktop.gsd", older), "server.gsd": seal("server.gsd", newer)},
+			"server.gsd", []string{"desktop"}, 0,
+		},
+		{
+			"bad snapshots skipped with a warning",
+			map[string][]byte{"desktop.gsd": seal("desktop.gsd", older), "broken.gsd": notJSON, "stranger.gsd": otherKey},
+			"", []string{"desktop"}, 2,
+		},
+		{
+			"snapshot under another machine's name",
+			map[string][]byte{"server.gsd": seal("desktop.gsd", older)},
+			"", nil, 1,
+		},
+	}
+	for _, tt := range tests {
+		machines, warnings := decodeSnapshots(key, tt.files, tt.skip)
+		var hosts []string
+		for _, machine := range machines {
+			hosts = append(hosts, machine.Host)
+		}
+		if !reflect.DeepEqual(hosts, tt.wantHosts) {
+			t.Errorf("%s: hosts %v, want %v", tt.name, hosts, tt.wantHosts)
+		}
+		if len(warnings) != tt.warnings {
+			t.Errorf("%s: warnings %q, want %d", tt.name, warnings, tt.warnings)
+		}
+	}
+}
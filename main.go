@@ -5,36 +5,88 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/fsnotify/fsnotify"
+	"github.com/muesli/termenv"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 type GitStatus struct {
-	Symbol       string
-	Message      string
-	Branch       string
-	LastCommit   string
-	RepoPath     string
-	RelativePath string
-	ModTime      time.Time
+	Symbol        string
+	Message       string
+	Branch        string
+	DefaultBranch string
+	Dirty         bool
+	HasRemote     bool
+	HasUpstream   bool
+	AuthRequired  bool // the remote refused the last fetch, pull or push for lack of credentials
+	Corrupt       bool // the last scheduled git fsck found a problem
+	RemoteGone    bool // check-remotes found origin deleted or long unreachable
+	Ahead         int
+	Behind        int
+	LatestTag     string
+	SinceTag      int
+	LastCommit    string
+	LastCommitAt  time.Time
+	LastAuthor    string
+	Modified      int // files changed in the work tree
+	Untracked     int // only counted where the scan looks for untracked files
+	Staged        int
+	Insertions    int // lines added by uncommitted changes, with display.show_diffstat
+	Deletions     int
+	HasDiffstat   bool
+	RepoPath      string
+	RelativePath  string
+	ModTime       time.Time
 }
 
 type Config struct {
-	Directory string
-	Report    bool
-	All       bool
-	TUI       bool
-	Depth     int
-	Theme     string
+	Directory  string
+	Report     bool
+	All        bool
+	TUI        bool
+	LineMode   bool
+	Depth      int
+	Theme      string
+	OffDefault bool
+	Feed       string
+	Email      string
+	EmailHTML  bool
+	Porcelain  string
+	Quiet      bool
+	Color      string
+	Columns    []string
+	Sort       string
+	Progress   string
+	NoPager    bool
+	Output     string
+	Format     string
+	GroupBy    string
+	Layout     string
+	Strict     bool
+	Fetch      bool
+	Only       []string
+	Exclude    []string
+	Where      string
+	Branches   []string
+	Authors    []string
+	Subdirs    bool
+	Limit      int
+	Top        string
+	where      func(GitStatus) bool // --where, compiled
+	pinned     map[string]bool      // repos the TUI shows even when clean
 }
 
 type model struct {
@@ -43,16 +95,71 @@ type model struct {
 	loading      bool
 	baseDir      string
 	showDetail   bool
+	splitView    bool // display.split_view: details beside the list instead of in a popup, toggled with |
 	config       Config
 	cache        map[string]GitStatus
 	animations   *AnimationState
 	watcher      *fsnotify.Watcher
+	treeWatch    *treeWatch // whether this instance or another one watches the tree
 	lastUpdate   time.Time
 	updateCount  int
 	hackerFX     *HackerEffects
 	matrixMode   bool
 	termWidth    int
 	termHeight   int
+	list         viewport.Model // scroll position of the repo list
+	prPrompt     *prPrompt
+	stashPrompt  *stashPrompt
+	bulkConfirm  *bulkConfirm  // typed confirmation before a large batch fetch or pull
+	branchPrompt *branchPrompt // B: the branch filter being typed
+	showHelp     bool
+	triage       map[string]triageCounts // forge work waiting on the user, by repo path
+	triageLoaded bool
+	triageOnly   bool
+	tab          int // index into statusTabs
+	grouping     bool // display.group_by_status, toggled from the palette
+	groupSizes   map[string]int  // repos per statusGroups section, collapsed ones included
+	collapsed    map[string]bool // sections folded with z
+	treeView     bool            // display.tree_view, toggled from the palette
+	showAuthor   bool            // display.show_author, toggled from the palette
+	dirSizes     map[string]int  // repos under each directory in the tree view
+	collapsedDirs map[string]bool
+	inbox        []inboxEvent
+	known        map[string]GitStatus // last scan, to spot status changes for the inbox
+	showInbox    bool
+	forgeEvents  bool // forge notifications are being polled
+	changelog    *changelog
+	description  *repoDescription
+	gitLog       *repoLog
+	stashes      *repoStashes
+	tracking     *repoTracking
+	diff         *diffPane // "d" overlay with the selected repo's uncommitted changes
+	branches     *branchPanel
+	dirPicker    *dirPicker // D: choosing a new scan root
+	logOffset    int // first commit shown in the detail view, scrolled with j/k
+	notice       string
+	compareWith  string // repo path pinned with "=" for the comparison view
+	comparison   *repoComparison
+	showCompare  bool
+	palette      *commandPalette
+	recording    bool
+	macro        []string // action keys recorded with Q, replayed with @
+	scanned      []GitStatus // every repo from the last scan; repos is the visible part
+	found        []GitStatus // the last scan before --all and --off-default filtered it
+	search       string
+	searching    bool
+	sortBy       string // one of tuiSorts, cycled with s
+	pinned       map[string]bool // absolute paths of the repos listed first, toggled with *
+	unreadable   int    // directories the last scan couldn't read
+	foreign      int64  // repos the last scan left out for --mine
+	machines     machinesMsg // what your other machines published, with sync set up
+	busy         map[string]bool // repos with a fetch or pull running
+	discovered   []string        // every repo path from the last scan, before filtering
+	fetchAll     *fetchAllState
+	marked       map[string]bool // repos marked with space
+	visualFrom   string          // repo where a v selection started
+	hidden       map[string]bool // repos hidden with H until the TUI restarts
+	excluded     map[string]bool // absolute paths of the repos hidden for good with x
 }
 
 var config Config
@@ -65,6 +172,18 @@ func main() {
 and displays their status with beautiful TUI or report output.`,
 		Args: cobra.MaximumNArgs(1),
 		Run:  run,
+		Version: version,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if err := validColorMode(config.Color); err != nil {
+				log.Fatal(err)
+			}
+			if err := validateSchema(outputSchema); err != nil {
+				log.Fatal(err)
+			}
+			applyNetworkConfig()
+			applyScanConfig()
+			offline = offline || detectOffline()
+		},
 	}
 
 	// Config commands
@@ -158,15 +277,713 @@ and displays their status with beautiful TUI or report output.`,
 		},
 	}
 
-	configCmd.AddCommand(initCmd, showCmd, themesCmd, setThemeCmd, autoCmd, downloadCmd, sourcesCmd, importCmd, setCmd)
+	unhideCmd := &cobra.Command{
+		Use:   "unhide [path...]",
+		Short: "Show repos hidden with x in the TUI again, or list them",
+		Run: func(cmd *cobra.Command, args []string) {
+			all, _ := cmd.Flags().GetBool("all")
+			if err := runUnhide(args, all); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	unhideCmd.Flags().Bool("all", false, "Unhide every hidden repo")
+
+	configCmd.AddCommand(initCmd, showCmd, themesCmd, setThemeCmd, autoCmd, downloadCmd, sourcesCmd, importCmd, setCmd, unhideCmd)
 	rootCmd.AddCommand(configCmd)
 
+	switchDefaultCmd := &cobra.Command{
+		Use:   "switch-default [directory]",
+		Short: "Check out the default branch in clean repos that are on another branch",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			yes, _ := cmd.Flags().GetBool("yes")
+			dir := resolveDirectory(args)
+			if err := checkMutationRoot(dir); err != nil && !dryRun {
+				log.Fatal(err)
+			}
+			runSwitchDefault(dir, dryRun, yes)
+		},
+	}
+	addRootOverrideFlag(switchDefaultCmd)
+	switchDefaultCmd.Flags().Bool("dry-run", false, "Only list the repositories that would be switched")
+	switchDefaultCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation, however many repos are affected")
+	rootCmd.AddCommand(switchDefaultCmd)
+
+	renamedDefaultCmd := &cobra.Command{
+		Use:   "renamed-default [directory]",
+		Short: "Find repos whose remote renamed its default branch and rename the local one to match",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			yes, _ := cmd.Flags().GetBool("yes")
+			dir := resolveDirectory(args)
+			if err := checkMutationRoot(dir); err != nil && !dryRun {
+				log.Fatal(err)
+			}
+			runRenamedDefault(dir, dryRun, yes)
+		},
+	}
+	addRootOverrideFlag(renamedDefaultCmd)
+	renamedDefaultCmd.Flags().Bool("dry-run", false, "Only list the repositories whose default branch was renamed")
+	renamedDefaultCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation, however many repos are affected")
+	rootCmd.AddCommand(renamedDefaultCmd)
+
+	remapRemotesCmd := &cobra.Command{
+		Use:   "remap-remotes [directory]",
+		Short: "Find origins that redirect after a repo or org was renamed and update their URLs",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			yes, _ := cmd.Flags().GetBool("yes")
+			dir := resolveDirectory(args)
+			if err := checkMutationRoot(dir); err != nil && !dryRun {
+				log.Fatal(err)
+			}
+			runRemapRemotes(dir, dryRun, yes)
+		},
+	}
+	addRootOverrideFlag(remapRemotesCmd)
+	remapRemotesCmd.Flags().Bool("dry-run", false, "Only list the remotes that moved")
+	remapRemotesCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation, however many repos are affected")
+	rootCmd.AddCommand(remapRemotesCmd)
+
+	badgeCmd := &cobra.Command{
+		Use:   "badge [directory]",
+		Short: "Write a shields.io-style SVG badge summarizing workspace health",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			label, _ := cmd.Flags().GetString("label")
+			output, _ := cmd.Flags().GetString("output")
+			if err := runBadge(resolveDirectory(args), label, output); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	badgeCmd.Flags().StringP("output", "o", "git-status.svg", "File to write the badge to (- for stdout)")
+	badgeCmd.Flags().String("label", "repos", "Text on the left side of the badge")
+	rootCmd.AddCommand(badgeCmd)
+
+	var resetOpts resetOptions
+	resetWorkspaceCmd := &cobra.Command{
+		Use:   "reset-workspace [directory]",
+		Short: "Fetch and fast-forward every repo, optionally back onto its default branch",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := resolveDirectory(args)
+			if err := checkMutationRoot(dir); err != nil && !resetOpts.DryRun {
+				log.Fatal(err)
+			}
+			runResetWorkspace(dir, resetOpts)
+		},
+	}
+	addRootOverrideFlag(resetWorkspaceCmd)
+	resetWorkspaceCmd.Flags().BoolVar(&resetOpts.ToDefault, "to-default", false, "Check out the remote default branch before fast-forwarding")
+	resetWorkspaceCmd.Flags().BoolVar(&resetOpts.OnlyClean, "only-clean", false, "Also skip repositories with unpushed commits (dirty ones are always skipped)")
+	resetWorkspaceCmd.Flags().BoolVar(&resetOpts.DryRun, "dry-run", false, "Only list what would be reset")
+	resetWorkspaceCmd.Flags().BoolVarP(&resetOpts.Yes, "yes", "y", false, "Don't ask for confirmation, however many repos are affected")
+	rootCmd.AddCommand(resetWorkspaceCmd)
+
+	var newOpts newRepoOptions
+	newCmd := &cobra.Command{
+		Use:   "new <name>",
+		Short: "Create a repository from a template and add it to the dashboard",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			newOpts.CreateRemote = cmd.Flags().Changed("forge") || newOpts.CreateRemote
+			if err := runNewRepo(resolveDirectory(nil), args[0], newOpts); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	newCmd.Flags().StringVar(&newOpts.Template, "template", "", "Template from the config templates/ directory")
+	newCmd.Flags().BoolVar(&newOpts.CreateRemote, "remote", false, "Create the remote repository on the configured forge")
+	newCmd.Flags().StringVar(&newOpts.Forge, "forge", "", "Forge to create the remote on (implies --remote)")
+	newCmd.Flags().BoolVar(&newOpts.Private, "private", true, "Create the remote as a private repository")
+	newCmd.Flags().StringVar(&newOpts.Description, "description", "", "Description for the remote repository")
+	rootCmd.AddCommand(newCmd)
+
+	var publishOpts publishOptions
+	publishCmd := &cobra.Command{
+		Use:   "publish [directory]",
+		Short: "Create remotes on the configured forge for repos without one, then push",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := resolveDirectory(args)
+			if err := checkMutationRoot(dir); err != nil {
+				log.Fatal(err)
+			}
+			if err := runPublish(dir, publishOpts); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addRootOverrideFlag(publishCmd)
+	publishCmd.Flags().StringVar(&publishOpts.Forge, "forge", "", "Forge to create the remotes on")
+	publishCmd.Flags().BoolVarP(&publishOpts.Yes, "yes", "y", false, "Accept the default name and visibility without prompting")
+	publishCmd.Flags().BoolVar(&publishOpts.Public, "public", false, "Default to public visibility instead of private")
+	rootCmd.AddCommand(publishCmd)
+
+	var archiveOpts archiveOptions
+	archiveCmd := &cobra.Command{
+		Use:   "archive <repo>",
+		Short: "Archive a repo as a verified git bundle plus its untracked files",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := checkMutationRoot(args[0]); err != nil && archiveOpts.Remove {
+				log.Fatal(err)
+			}
+			if err := runArchive(args[0], archiveOpts); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	archiveCmd.Flags().BoolVar(&archiveOpts.Remove, "remove", false, "Delete the working copy once the bundle is verified")
+	archiveCmd.Flags().BoolVar(&archiveOpts.Force, "force", false, "Archive even with uncommitted changes to tracked files (never together with --remove)")
+	archiveCmd.Flags().BoolVarP(&archiveOpts.Yes, "yes", "y", false, "Don't ask before deleting the working copy")
+	addRootOverrideFlag(archiveCmd)
+	rootCmd.AddCommand(archiveCmd)
+
+	var restoreTo string
+	restoreCmd := &cobra.Command{
+		Use:   "restore <bundle|name>",
+		Short: "Restore an archived repo from its bundle",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runRestore(args[0], restoreTo); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	restoreCmd.Flags().StringVar(&restoreTo, "to", "", "Where to restore the repo (defaults to its original path)")
+	rootCmd.AddCommand(restoreCmd)
+
+	exportWorkspaceCmd := &cobra.Command{
+		Use:   "export-workspace [directory]",
+		Short: "Write a manifest of every repo, its remotes and the config, to set up another machine",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			output, _ := cmd.Flags().GetString("output")
+			if err := runExportWorkspace(resolveDirectory(args), output); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	exportWorkspaceCmd.Flags().StringP("output", "o", "workspace.json", "File to write the manifest to (- for stdout)")
+	rootCmd.AddCommand(exportWorkspaceCmd)
+
+	importWorkspaceCmd := &cobra.Command{
+		Use:   "import-workspace <manifest> [directory]",
+		Short: "Clone every repo from an exported manifest into the same layout",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			yes, _ := cmd.Flags().GetBool("yes")
+			withConfig, _ := cmd.Flags().GetBool("config")
+			dir := ""
+			if len(args) == 2 {
+				dir = args[1]
+			}
+			if err := runImportWorkspace(args[0], dir, dryRun, yes, withConfig); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	importWorkspaceCmd.Flags().Bool("dry-run", false, "Only list what would be cloned")
+	importWorkspaceCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation, however many repos are cloned")
+	importWorkspaceCmd.Flags().Bool("config", false, "Also replace the local config with the exported one, paths moved to the new root")
+	rootCmd.AddCommand(importWorkspaceCmd)
+
+	var watchPatterns []string
+	branchWatchCmd := &cobra.Command{
+		Use:   "branch-watch [directory]",
+		Short: "Show watched branches (e.g. release/*) across all repos",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runBranchWatch(resolveDirectory(args), watchPatterns); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	branchWatchCmd.Flags().StringSliceVarP(&watchPatterns, "branch", "b", nil, "Branch pattern to watch (overrides watch_branches in config)")
+	rootCmd.AddCommand(branchWatchCmd)
+
+	var releaseOpts releaseOptions
+	releaseCmd := &cobra.Command{
+		Use:   "release <repo>...",
+		Short: "Tag and push a release in one or more repos",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, repo := range args {
+				if err := checkMutationRoot(repo); err != nil {
+					log.Fatal(err)
+				}
+			}
+			if err := runRelease(args, releaseOpts); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addRootOverrideFlag(releaseCmd)
+	releaseCmd.Flags().StringVar(&releaseOpts.Version, "version", "", "Version to tag (prompted per repo when empty)")
+	releaseCmd.Flags().StringVarP(&releaseOpts.Message, "message", "m", "", "Tag message (default \"Release <version>\")")
+	releaseCmd.Flags().BoolVarP(&releaseOpts.Sign, "sign", "s", false, "Create a GPG-signed tag")
+	releaseCmd.Flags().BoolVar(&releaseOpts.ForgeRelease, "forge-release", false, "Also create a release on the forge with the changelog as notes")
+	releaseCmd.Flags().StringVar(&releaseOpts.Forge, "forge", "", "Configured forge to create the release on")
+	releaseCmd.Flags().BoolVarP(&releaseOpts.Yes, "yes", "y", false, "Don't ask for confirmation")
+	rootCmd.AddCommand(releaseCmd)
+
+	var mirrorSync, mirrorYes bool
+	mirrorsCmd := &cobra.Command{
+		Use:   "mirrors [directory]",
+		Short: "Check that mirror remotes have the same branches and tags as their source",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := resolveDirectory(args)
+			if err := checkMutationRoot(dir); err != nil && mirrorSync {
+				log.Fatal(err)
+			}
+			if err := runMirrors(dir, mirrorSync, mirrorYes); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addRootOverrideFlag(mirrorsCmd)
+	mirrorsCmd.Flags().BoolVar(&mirrorSync, "sync", false, "Offer to force-push the source's branches and tags to drifted mirrors")
+	mirrorsCmd.Flags().BoolVarP(&mirrorYes, "yes", "y", false, "Sync without asking")
+	rootCmd.AddCommand(mirrorsCmd)
+
+	templateDriftCmd := &cobra.Command{
+		Use:   "template-drift [directory]",
+		Short: "Flag repos made from a template that are behind the template's latest release",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			changes, _ := cmd.Flags().GetBool("changes")
+			behind, err := runTemplateDrift(resolveDirectory(args), changes)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if behind {
+				os.Exit(1)
+			}
+		},
+	}
+	templateDriftCmd.Flags().Bool("changes", false, "List the template's releases and commits each repo is missing")
+	rootCmd.AddCommand(templateDriftCmd)
+
+	var duplicatesClean bool
+	duplicatesCmd := &cobra.Command{
+		Use:   "duplicates [directory]",
+		Short: "Find directories cloned from the same remote",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := resolveDirectory(args)
+			if err := checkMutationRoot(dir); err != nil && duplicatesClean {
+				log.Fatal(err)
+			}
+			if err := runDuplicates(dir, duplicatesClean); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addRootOverrideFlag(duplicatesCmd)
+	duplicatesCmd.Flags().BoolVar(&duplicatesClean, "clean", false, "Offer to ignore or delete the stale copies")
+	rootCmd.AddCommand(duplicatesCmd)
+
+	var historyDB string
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Record scans in a SQLite database and query them",
+	}
+	historyCmd.PersistentFlags().StringVar(&historyDB, "db", "", "History database (default <config dir>/history.db)")
+	resolveHistoryDB := func() string {
+		if historyDB != "" {
+			return historyDB
+		}
+		db, err := defaultHistoryDB()
+		if err != nil {
+			log.Fatal(err)
+		}
+		return db
+	}
+	historyCmd.AddCommand(&cobra.Command{
+		Use:   "record [directory]",
+		Short: "Append the current status of every repo to the history database",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			repos := findGitReposOptimized(resolveDirectory(args), config.Depth)
+			if err := recordHistory(resolveHistoryDB(), repos); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("✓ Recorded %d repositories\n", len(repos))
+		},
+	})
+	var historyQ historyQuery
+	historyQueryCmd := &cobra.Command{
+		Use:   "query",
+		Short: "Show recorded scans, newest first",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := queryHistory(resolveHistoryDB(), historyQ); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	historyQueryCmd.Flags().StringVar(&historyQ.Repo, "repo", "", "Only repos whose path contains this text")
+	historyQueryCmd.Flags().StringVar(&historyQ.State, "state", "", "Only this state (clean, dirty, ahead, behind, diverged, no-remote, no-upstream, error)")
+	historyQueryCmd.Flags().StringVar(&historyQ.Since, "since", "", "Only scans since a date (2006-01-02) or duration ago (7d, 12h)")
+	historyQueryCmd.Flags().IntVar(&historyQ.Limit, "limit", 100, "Maximum rows (0 for all)")
+	historyQueryCmd.Flags().BoolVar(&historyQ.CSV, "csv", false, "Print CSV instead of columns")
+	historyCmd.AddCommand(historyQueryCmd)
+	rootCmd.AddCommand(historyCmd)
+
+	var authHost, authClientID, authScopes string
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Sign in to GitHub or GitLab without creating tokens by hand",
+	}
+	authLoginCmd := &cobra.Command{
+		Use:   "login <github|gitlab|forge>",
+		Short: "Authorize in the browser (OAuth device flow) and store the token in the keychain",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runAuthLogin(args[0], authHost, authClientID, authScopes); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	authLoginCmd.Flags().StringVar(&authHost, "host", "", "Forge host for a new forge, e.g. gitlab.example.com")
+	authLoginCmd.Flags().StringVar(&authClientID, "client-id", "", "OAuth app client ID (default forges.<name>.client_id)")
+	authLoginCmd.Flags().StringVar(&authScopes, "scopes", "", "Scopes to request (default \"repo notifications\" on GitHub, \"api\" on GitLab)")
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(&cobra.Command{
+		Use:   "logout <github|gitlab|forge>",
+		Short: "Remove the forge's token from the keychain",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runAuthLogout(args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	})
+	authCmd.AddCommand(&cobra.Command{
+		Use:   "check [directory]",
+		Short: "Check that every remote host in the workspace can be fetched from without a prompt",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if !runAuthCheck(resolveDirectory(args)) {
+				os.Exit(1)
+			}
+		},
+	})
+	rootCmd.AddCommand(authCmd)
+
+	fsckCmd := &cobra.Command{
+		Use:   "fsck [directory]",
+		Short: "Run git fsck on the repos checked longest ago and flag any that are damaged",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			batch, _ := cmd.Flags().GetInt("batch")
+			report, _ := cmd.Flags().GetBool("report")
+			dir := resolveDirectory(args)
+			if report {
+				if !runFsckReport(dir) {
+					os.Exit(1)
+				}
+				return
+			}
+			intact, err := runFsck(dir, batch)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !intact {
+				os.Exit(1)
+			}
+		},
+	}
+	fsckCmd.Flags().Int("batch", 5, "How many repos to check this run (0 checks all of them)")
+	fsckCmd.Flags().Bool("report", false, "Only list each repo's last result without checking anything")
+	rootCmd.AddCommand(fsckCmd)
+
+	var motdCount, motdWidth int
+	motdCmd := &cobra.Command{
+		Use:   "motd [directory]",
+		Short: "Print a short status banner for login scripts from the last saved scan",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			refresh, _ := cmd.Flags().GetBool("refresh")
+			summary, _ := cmd.Flags().GetBool("summary")
+			if err := runMotd(resolveDirectory(args), motdCount, motdWidth, refresh, summary); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	motdCmd.Flags().IntVarP(&motdCount, "count", "n", 5, "How many repos needing attention to list")
+	motdCmd.Flags().IntVar(&motdWidth, "width", 72, "Cut lines to this many columns")
+	motdCmd.Flags().Bool("refresh", false, "Scan first and save the result (slow; meant for cron)")
+	motdCmd.Flags().Bool("summary", false, "Print only the counts, on one line (for status bars)")
+	rootCmd.AddCommand(motdCmd)
+
+	installIntegrationsCmd := &cobra.Command{
+		Use:   "install-integrations [name...]",
+		Short: "Install the shell function, completions, tmux segment, xbar plugin and refresh service",
+		Long: `Install optional integrations for the current user: shell (a gsd function),
+completions, tmux (a status bar segment), xbar (macOS menu bar plugin) and
+service (a systemd timer or launchd agent rescanning the workspace). With no
+names, every integration that applies to this OS is installed. They watch
+the directory given with -d, or the current one.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			force, _ := cmd.Flags().GetBool("force")
+			if err := runInstallIntegrations(cmd.Root(), resolveDirectory(nil), args, dryRun, force); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	installIntegrationsCmd.Flags().Bool("dry-run", false, "Show what would be written without writing anything")
+	installIntegrationsCmd.Flags().Bool("force", false, "Replace files that exist and differ")
+	rootCmd.AddCommand(installIntegrationsCmd)
+
+	daemonCmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Manage the background job that keeps the saved scan warm (systemd or launchd)",
+	}
+	daemonInstallCmd := &cobra.Command{
+		Use:   "install [directory]",
+		Short: "Write the user systemd timer or launchd agent for a directory",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDaemonInstall(cmd.Root(), resolveDirectory(args)); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	daemonStartCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Enable and start the daemon",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDaemonStart(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	daemonStopCmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop and disable the daemon",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDaemonStop(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	daemonStatusCmd := &cobra.Command{
+		Use:   "status [directory]",
+		Short: "Show whether the daemon is running and how old the saved scan is",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			running, err := runDaemonStatus(resolveDirectory(args))
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !running {
+				os.Exit(1)
+			}
+		},
+	}
+	daemonCmd.AddCommand(daemonInstallCmd, daemonStartCmd, daemonStopCmd, daemonStatusCmd)
+	rootCmd.AddCommand(daemonCmd)
+
+	teamCmd := &cobra.Command{
+		Use:   "team",
+		Short: "Share unpushed and dirty work with teammates on a common dashboard",
+	}
+	teamServeCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the team dashboard and accept teammates' pushed scans",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			addr, _ := cmd.Flags().GetString("addr")
+			cert, _ := cmd.Flags().GetString("cert")
+			key, _ := cmd.Flags().GetString("key")
+			if (cert == "") != (key == "") {
+				log.Fatal("--cert and --key go together")
+			}
+			if err := runTeamServe(addr, cert, key); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	teamServeCmd.Flags().String("addr", ":8420", "Address to listen on")
+	teamServeCmd.Flags().String("cert", "", "TLS certificate file")
+	teamServeCmd.Flags().String("key", "", "TLS key file")
+	teamAddCmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Let a teammate push to the dashboard served here, printing their token",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTeamAdd(args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	teamPushCmd := &cobra.Command{
+		Use:   "push [directory]",
+		Short: "Scan and push the result to team.url (the daemon does this on every refresh)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTeamPush(resolveDirectory(args)); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	teamCmd.AddCommand(teamServeCmd, teamAddCmd, teamPushCmd)
+	rootCmd.AddCommand(teamCmd)
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Share this machine's status with your other machines, end-to-end encrypted",
+	}
+	syncInitCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Make the sync key, or install the one from your first machine with --key-file",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			keyFile, _ := cmd.Flags().GetString("key-file")
+			if err := runSyncInit(keyFile); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	syncInitCmd.Flags().String("key-file", "", "File holding the key made by `sync init` on another machine, - for stdin")
+	syncPushCmd := &cobra.Command{
+		Use:   "push [directory]",
+		Short: "Scan and publish this machine's status (the daemon does this on every refresh)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runSyncPush(resolveDirectory(args)); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	syncStatusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show what your other machines have unpushed",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			if err := runSyncStatus(verbose); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	syncStatusCmd.Flags().BoolP("verbose", "v", false, "List each repo with unpushed or uncommitted work")
+	syncCmd.AddCommand(syncInitCmd, syncPushCmd, syncStatusCmd)
+	rootCmd.AddCommand(syncCmd)
+
+	checkRemotesCmd := &cobra.Command{
+		Use:   "check-remotes [directory]",
+		Short: "Check that every repo's origin still exists and flag those that are gone",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			report, _ := cmd.Flags().GetBool("report")
+			dir := resolveDirectory(args)
+			if report {
+				if !runRemoteReport(dir) {
+					os.Exit(1)
+				}
+				return
+			}
+			ok, err := runRemoteCheck(dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !ok {
+				os.Exit(1)
+			}
+		},
+	}
+	checkRemotesCmd.Flags().Bool("report", false, "Only list each repo's last result without contacting any remote")
+	rootCmd.AddCommand(checkRemotesCmd)
+
+	var duSort string
+	var duTop int
+	duCmd := &cobra.Command{
+		Use:   "du [directory]",
+		Short: "Show working tree and .git disk usage per repo",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDu(resolveDirectory(args), duSort, duTop); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	duCmd.Flags().StringVar(&duSort, "sort", "total", "Sort by total, git, worktree or path")
+	duCmd.Flags().IntVar(&duTop, "top", 0, "Only show the first N repos")
+	rootCmd.AddCommand(duCmd)
+
+	var gcPrune, gcYes bool
+	gcCmd := &cobra.Command{
+		Use:   "gc [directory]",
+		Short: "Estimate space held by reflogs and unreachable objects, and reclaim it",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := resolveDirectory(args)
+			if err := checkMutationRoot(dir); err != nil && gcPrune {
+				log.Fatal(err)
+			}
+			if err := runGarbage(dir, gcPrune, gcYes); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addRootOverrideFlag(gcCmd)
+	gcCmd.Flags().BoolVar(&gcPrune, "prune", false, "Expire reflogs and prune unreachable objects (asks per repo)")
+	gcCmd.Flags().BoolVarP(&gcYes, "yes", "y", false, "Prune without asking")
+	rootCmd.AddCommand(gcCmd)
+
+	var bundleOutput string
+	debugBundleCmd := &cobra.Command{
+		Use:   "debug-bundle [directory]",
+		Short: "Write a sanitized zip of config, scan results, timings and environment for bug reports",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if bundleOutput == "" {
+				bundleOutput = "git-status-dash-debug-" + time.Now().Format("20060102-150405") + ".zip"
+			}
+			if err := runDebugBundle(resolveDirectory(args), bundleOutput); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	debugBundleCmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "Zip file to write (default git-status-dash-debug-<time>.zip)")
+	rootCmd.AddCommand(debugBundleCmd)
+
+	reportCmd := &cobra.Command{
+		Use:   "report [directory]",
+		Short: "Print a one-off status report (same as --report)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			config.Report = true
+			run(cmd, args)
+		},
+	}
+	addReportFlags(reportCmd.Flags())
+	rootCmd.AddCommand(reportCmd)
+
 	rootCmd.Flags().BoolVarP(&config.Report, "report", "r", false, "Generate a brief report")
-	rootCmd.Flags().StringVarP(&config.Directory, "directory", "d", "", "Specify the directory to scan")
-	rootCmd.Flags().BoolVarP(&config.All, "all", "a", false, "Show all repositories, including synced ones")
+	rootCmd.PersistentFlags().StringVarP(&config.Directory, "directory", "d", "", "Specify the directory to scan")
 	rootCmd.Flags().BoolVarP(&config.TUI, "tui", "t", false, "Interactive TUI interface")
-	rootCmd.Flags().IntVar(&config.Depth, "depth", -1, "Limit recursion depth when scanning repos")
-	rootCmd.Flags().StringVar(&config.Theme, "theme", "", "Override theme for this run")
+	rootCmd.Flags().BoolVar(&config.LineMode, "line-mode", false, "Use the plain numbered-list interface instead of the full-screen TUI")
+	addRootOverrideFlag(rootCmd)
+	rootCmd.PersistentFlags().IntVar(&config.Depth, "depth", -1, "Limit recursion depth when scanning repos")
+	rootCmd.PersistentFlags().StringVar(&outputSchema, "schema", "", "JSON output layout to emit (v1); defaults to the latest")
+	rootCmd.PersistentFlags().StringVar(&config.Color, "color", "auto", "Colorize output: auto, always or never (NO_COLOR is respected)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Don't fetch or call forge APIs; implied when no network interface is up")
+	rootCmd.PersistentFlags().BoolVar(&onlyMine, "mine", false, "Only scan repos owned by you whose .git you can write to (for shared machines)")
+	addReportFlags(rootCmd.Flags())
 
 	rootCmd.SetHelpTemplate(`Git Status Dashboard
 
@@ -186,24 +1003,99 @@ Status Information:
   ↕ Diverged (need to merge or rebase)
   ✗ Uncommitted changes
   ⚠ Error accessing repository
+  ⎇ Checked out on a branch other than the remote default
 `)
 
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal(err)
 	}
-}
-
-func run(cmd *cobra.Command, args []string) {
-	if config.Directory == "" {
-		if len(args) > 0 {
-			config.Directory = args[0]
-		} else {
-			var err error
-			config.Directory, err = os.Getwd()
-			if err != nil {
-				log.Fatal(err)
-			}
+}
+
+// addReportFlags registers the flags shared by the root command and `report`
+func addReportFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&config.All, "all", "a", false, "Show all repositories, including synced ones")
+	flags.StringVar(&config.Theme, "theme", "", "Override theme for this run")
+	flags.BoolVar(&config.OffDefault, "off-default", false, "Only show repositories checked out on a non-default branch")
+	flags.StringVar(&config.Feed, "feed", "", "Append status-change events to an Atom feed file (report mode)")
+	flags.StringVar(&config.Email, "email", "", "Email the report to this address using the SMTP settings in config")
+	flags.BoolVar(&config.EmailHTML, "email-html", false, "Send the emailed report as HTML instead of plain text")
+	flags.StringVar(&config.Porcelain, "porcelain", "", "Print a stable, tab-separated report for scripts (format: v1)")
+	flags.Lookup("porcelain").NoOptDefVal = porcelainV1
+	flags.BoolVarP(&config.Quiet, "quiet", "q", false, "Print a one-line count per status and nothing else")
+	flags.StringSliceVar(&config.Columns, "columns", nil, "Report columns in order: symbol,path,status,changes,diffstat,branch,commit,author,activity,ahead,behind,tag,since-tag")
+	flags.StringVar(&config.Format, "format", "text", "Report format: text or junit (one test case per repo)")
+	flags.StringVarP(&config.Output, "output", "o", "", "Write the report to a file instead of stdout (never colored)")
+	flags.BoolVar(&config.NoPager, "no-pager", false, "Don't pipe long reports through $PAGER")
+	flags.StringVar(&config.Progress, "progress", "", "Emit scan progress on stderr in the given format (json)")
+	flags.StringVar(&config.Layout, "layout", "auto", "Report layout: auto (fit the terminal), wide, normal or narrow")
+	flags.StringVar(&config.GroupBy, "group-by", "", "Group the report into sections: remote-host")
+	flags.StringSliceVar(&config.Only, "only", nil, "Only show repos in these states: "+strings.Join(filterStates, ","))
+	flags.StringSliceVar(&config.Exclude, "exclude", nil, "Hide repos in these states (same names as --only)")
+	flags.StringVar(&config.Where, "where", "", "Only show repos matching an expression, e.g. 'dirty || (ahead > 3 && branch != \"main\")'")
+	flags.StringSliceVar(&config.Authors, "author", nil, "Only show repos whose last commit is by one of these authors (part of the name is enough)")
+	flags.BoolVar(&config.Subdirs, "subdirs", false, "Treat the top-level directories of one repo (a monorepo) as the entries to show")
+	flags.StringSliceVar(&config.Branches, "branch", nil, "Only show repos on a branch matching these globs, e.g. release/* (B in the TUI)")
+	flags.IntVar(&config.Limit, "limit", 0, "Show at most this many repos, the most urgent first unless --sort says otherwise")
+	flags.StringVar(&config.Top, "top", "", "Show the repos in one state that most need attention, e.g. --top behind (10 unless --limit is given)")
+	flags.BoolVar(&config.Fetch, "fetch", false, "Fetch every repo before showing its status")
+	flags.BoolVar(&config.Strict, "strict", false, "Fail when a directory can't be read instead of skipping it")
+	flags.StringVar(&config.Sort, "sort", "", "Sort the report by path, mtime, status, ahead, behind or since-tag (most commits since the last tag first)")
+}
+
+func run(cmd *cobra.Command, args []string) {
+	config.Directory = resolveDirectory(args)
+
+	if err := validateColumns(config.Columns); err != nil {
+		log.Fatal(err)
+	}
+	if err := validateSort(config.Sort); err != nil {
+		log.Fatal(err)
+	}
+	if err := validateLayout(config.Layout); err != nil {
+		log.Fatal(err)
+	}
+	if err := validateGroupBy(config.GroupBy); err != nil {
+		log.Fatal(err)
+	}
+	if err := validateStates(append(append([]string(nil), config.Only...), config.Exclude...)); err != nil {
+		log.Fatal(err)
+	}
+	if err := applyTop(&config); err != nil {
+		log.Fatal(err)
+	}
+	if config.Limit < 0 {
+		log.Fatal("--limit must be 0 or more")
+	}
+	if config.Limit > 0 && config.Sort == "" {
+		config.Sort = "status"
+	}
+	if err := validateBranchPatterns(config.Branches); err != nil {
+		log.Fatal(err)
+	}
+	if config.Subdirs {
+		if err := validateSubdirsRoot(config.Directory); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if config.Where != "" {
+		where, err := compileWhere(config.Where)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.where = where
+	}
+	if config.Format != "text" && config.Format != "junit" {
+		log.Fatalf("unknown format %q (available: text, junit)", config.Format)
+	}
+	if config.Progress != "" {
+		reporter, err := newProgressReporter(os.Stderr, config.Progress, config.Directory)
+		if err != nil {
+			log.Fatal(err)
 		}
+		scanProgress = reporter
+	}
+	if config.Color == "never" || config.Color == "auto" && os.Getenv("NO_COLOR") != "" {
+		lipgloss.SetColorProfile(termenv.Ascii)
 	}
 
 	if config.Depth == -1 {
@@ -211,17 +1103,77 @@ func run(cmd *cobra.Command, args []string) {
 	}
 
 	// Default to TUI unless --report is specified
+	if config.Porcelain != "" || config.Quiet || config.Output != "" || config.Format != "text" {
+		config.Report = true
+	}
 	if !config.Report {
 		config.TUI = true
 	}
 
 	if config.TUI {
+		if err := checkDashboardRoot(config.Directory); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if config.TUI && config.LineMode {
+		runLineMode("")
+	} else if reason := limitedTerminal(); config.TUI && reason != "" && !cmd.Flags().Changed("tui") {
+		runLineMode(reason)
+	} else if config.TUI {
 		runTUI()
 	} else {
 		runReport()
 	}
 }
 
+// resolveDirectory picks the scan root from -d, the positional argument or the cwd
+func resolveDirectory(args []string) string {
+	if config.Directory != "" {
+		return config.Directory
+	}
+	if len(args) > 0 {
+		return args[0]
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return dir
+}
+
+// filterRepos applies the --all, --off-default, --only, --exclude and
+// --where flags to a scan result. --only and --where pick the repos to
+// show, clean ones included. Pinned repos are shown even when clean.
+func filterRepos(repos []GitStatus, cfg Config) []GitStatus {
+	var filtered []GitStatus
+	for _, repo := range repos {
+		if !cfg.All && repo.Symbol == "✓" && !cfg.pinned[configRepoPath(repo.RepoPath)] && !cfg.OffDefault && len(cfg.Only) == 0 && cfg.where == nil && len(cfg.Branches) == 0 && len(cfg.Authors) == 0 {
+			continue
+		}
+		if len(cfg.Branches) > 0 && !onBranch(repo.Branch, cfg.Branches) {
+			continue
+		}
+		if len(cfg.Authors) > 0 && !byAuthor(repo.LastAuthor, cfg.Authors) {
+			continue
+		}
+		if cfg.OffDefault && !repo.OffDefaultBranch() {
+			continue
+		}
+		if len(cfg.Only) > 0 && !repoInStates(repo, cfg.Only) {
+			continue
+		}
+		if repoInStates(repo, cfg.Exclude) {
+			continue
+		}
+		if cfg.where != nil && !cfg.where(repo) {
+			continue
+		}
+		filtered = append(filtered, repo)
+	}
+	return filtered
+}
+
 func runTUI() {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -237,16 +1189,37 @@ func runTUI() {
 		cache:       make(map[string]GitStatus),
 		animations:  NewAnimationState(),
 		watcher:     watcher,
+		treeWatch:   joinTreeWatch(config.Directory, watcher),
 		lastUpdate:  time.Now(),
 		updateCount: 0,
 		hackerFX:    NewHackerEffects(80, 24), // Default terminal size
 		matrixMode:  false,
 		termWidth:   80,
 		termHeight:  24,
+		sortBy:      "mtime",
+		busy:        make(map[string]bool),
+		marked:      make(map[string]bool),
+		hidden:      make(map[string]bool),
+		collapsed:   make(map[string]bool),
+		collapsedDirs: make(map[string]bool),
+		pinned:      loadPinned(),
+		excluded:    loadExcluded(),
+	}
+	m.config.pinned = m.pinned
+	if userConfig, err := loadConfig(); err == nil {
+		m.grouping = userConfig.Display.GroupByStatus
+		m.treeView = userConfig.Display.TreeView
+		m.splitView = userConfig.Display.SplitView
+		m.showAuthor = userConfig.Display.ShowAuthor
+	}
+	if m.treeWatch != nil {
+		m.notice = m.treeWatch.followNotice()
+		defer m.treeWatch.release()
 	}
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
+		m.treeWatch.release()
 		log.Fatal(err)
 	}
 
@@ -255,42 +1228,6 @@ func runTUI() {
 	}
 }
 
-func runReport() {
-	repos := findGitReposOptimized(config.Directory, config.Depth)
-	
-	fmt.Printf("Found %d repositories, loading......\n", len(repos))
-
-	reposToShow := repos
-	if !config.All {
-		var unsynced []GitStatus
-		for _, repo := range repos {
-			if repo.Symbol != "✓" {
-				unsynced = append(unsynced, repo)
-			}
-		}
-		reposToShow = unsynced
-	}
-
-	for _, repo := range reposToShow {
-		repoName := repo.RelativePath
-		if repoName == "" {
-			repoName = "."
-		}
-		line := fmt.Sprintf("%s %-30s %s", repo.Symbol, repoName, repo.Message)
-		
-		switch repo.Symbol {
-		case "✓":
-			fmt.Printf("\033[32m%s\033[0m\n", line)
-		case "✗", "⚠":
-			fmt.Printf("\033[31m%s\033[0m\n", line)
-		case "↑", "↓", "↕":
-			fmt.Printf("\033[33m%s\033[0m\n", line)
-		default:
-			fmt.Println(line)
-		}
-	}
-}
-
 func (m model) Init() tea.Cmd {
 	commands := []tea.Cmd{
 		scanRepos(m.baseDir, m.config.Depth, m.cache),
@@ -300,6 +1237,7 @@ func (m model) Init() tea.Cmd {
 		tea.Tick(time.Millisecond*16, func(t time.Time) tea.Msg {
 			return animationTickMsg(t)
 		}),
+		loadMachinesCmd(),
 	}
 
 	// Set up file watching
@@ -317,38 +1255,55 @@ func (m model) watchForChanges() tea.Cmd {
 			return nil
 		}
 
-		select {
-		case event, ok := <-m.watcher.Events:
-			if !ok {
-				return nil
-			}
-			// Trigger rescan on git-related file changes
-			if strings.Contains(event.Name, ".git") || 
-			   strings.HasSuffix(event.Name, ".go") ||
-			   strings.HasSuffix(event.Name, ".js") ||
-			   strings.HasSuffix(event.Name, ".py") {
-				return fileChangeMsg(event.Name)
-			}
-		case err, ok := <-m.watcher.Errors:
-			if !ok {
-				return nil
+		for {
+			select {
+			case event, ok := <-m.watcher.Events:
+				if !ok {
+					return nil
+				}
+				if !m.treeWatch.owns() {
+					if msg := m.treeWatch.followed(event); msg != nil {
+						return msg
+					}
+					continue
+				}
+				// Trigger rescan on git-related file changes
+				if strings.Contains(event.Name, ".git") || 
+				   strings.HasSuffix(event.Name, ".go") ||
+				   strings.HasSuffix(event.Name, ".js") ||
+				   strings.HasSuffix(event.Name, ".py") {
+					return fileChangeMsg(event.Name)
+				}
+				// New directories may be freshly created repos
+				if event.Op&fsnotify.Create != 0 {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						return fileChangeMsg(event.Name)
+					}
+				}
+			case err, ok := <-m.watcher.Errors:
+				if !ok {
+					return nil
+				}
+				log.Printf("Watcher error: %v", err)
 			}
-			log.Printf("Watcher error: %v", err)
 		}
-		return nil
 	}
 }
 
 func (m model) setupWatchers() {
-	if m.watcher == nil {
+	if m.watcher == nil || !m.treeWatch.owns() {
 		return
 	}
 
+	// Watch the scan root so repos created there show up right away
+	m.watcher.Add(m.baseDir)
+
 	// Watch all git repositories
-	for _, repo := range m.repos {
+	for _, repo := range m.scanned {
 		gitDir := filepath.Join(repo.RepoPath, ".git")
 		m.watcher.Add(gitDir)
 		m.watcher.Add(repo.RepoPath) // Watch the repo root too
+		m.watcher.Add(filepath.Dir(repo.RepoPath)) // And its parent for new siblings
 	}
 }
 
@@ -356,6 +1311,7 @@ type reposFoundMsg []GitStatus
 type tickMsg time.Time
 type fileChangeMsg string
 type animationTickMsg time.Time
+type noticeMsg string
 
 func scanRepos(baseDir string, depth int, cache map[string]GitStatus) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
@@ -365,10 +1321,15 @@ func scanRepos(baseDir string, depth int, cache map[string]GitStatus) tea.Cmd {
 }
 
 func findGitRepos(baseDir string, maxDepth int, cache map[string]GitStatus) []GitStatus {
+	if config.Subdirs {
+		return scanSubdirs(baseDir)
+	}
 	var repos []GitStatus
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
+	scanUnreadable.reset()
+	scanForeign.Store(0)
 	walkWithDepth(baseDir, baseDir, 0, maxDepth, &repos, &mu, &wg, cache)
 	wg.Wait()
 
@@ -387,6 +1348,7 @@ func walkWithDepth(currentPath, baseDir string, currentDepth, maxDepth int, repo
 
 	entries, err := os.ReadDir(currentPath)
 	if err != nil {
+		scanUnreadable.add(currentPath, err)
 		return
 	}
 
@@ -399,6 +1361,9 @@ func walkWithDepth(currentPath, baseDir string, currentDepth, maxDepth int, repo
 
 		if entry.Name() == ".git" {
 			repoPath := currentPath
+			if skipForeign(repoPath) {
+				return
+			}
 			wg.Add(1)
 			go func(rp string) {
 				defer wg.Done()
@@ -420,6 +1385,9 @@ func walkWithDepth(currentPath, baseDir string, currentDepth, maxDepth int, repo
 }
 
 func getGitStatus(repoPath, baseDir string, cache map[string]GitStatus) GitStatus {
+	if config.Subdirs {
+		return rescanSubdir(repoPath, baseDir)
+	}
 	// Remove cache for now to fix race condition
 	// TODO: Add proper mutex if we want caching
 
@@ -443,31 +1411,85 @@ func getGitStatus(repoPath, baseDir string, cache map[string]GitStatus) GitStatu
 		ModTime:      modTime,
 	}
 
-	statusCmd := exec.CommandContext(ctx, "git", "-C", repoPath, "status", "--porcelain")
+	statusCmd := gitCommand(ctx, "-C", repoPath, "status", "--porcelain")
 	statusOut, err := statusCmd.Output()
 	if err != nil {
 		return status
 	}
 
-	aheadCmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-list", "--count", "@{u}..HEAD")
+	aheadCmd := gitCommand(ctx, "-C", repoPath, "rev-list", "--count", "@{u}..HEAD")
 	aheadOut, _ := aheadCmd.Output()
 	ahead := strings.TrimSpace(string(aheadOut))
 
-	behindCmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-list", "--count", "HEAD..@{u}")
+	behindCmd := gitCommand(ctx, "-C", repoPath, "rev-list", "--count", "HEAD..@{u}")
 	behindOut, _ := behindCmd.Output()
 	behind := strings.TrimSpace(string(behindOut))
 
-	branchCmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	branchCmd := gitCommand(ctx, "-C", repoPath, "rev-parse", "--abbrev-ref", "HEAD")
 	branchOut, _ := branchCmd.Output()
 	status.Branch = strings.TrimSpace(string(branchOut))
+	status.DefaultBranch = detectDefaultBranch(ctx, repoPath)
+
+	remoteCmd := gitCommand(ctx, "-C", repoPath, "remote")
+	remoteOut, _ := remoteCmd.Output()
+	status.HasRemote = strings.TrimSpace(string(remoteOut)) != ""
 
-	commitCmd := exec.CommandContext(ctx, "git", "-C", repoPath, "log", "-1", "--pretty=%h %cr %an")
+	commitCmd := gitCommand(ctx, "-C", repoPath, "log", "-1", "--pretty="+lastCommitFormat)
 	commitOut, _ := commitCmd.Output()
-	status.LastCommit = strings.TrimSpace(string(commitOut))
+	status.LastCommit, status.LastAuthor, status.LastCommitAt = parseLastCommit(string(commitOut))
 
-	statusStr := strings.TrimSpace(string(statusOut))
-	
-	if statusStr == "" && ahead == "0" && behind == "0" {
+	classifyStatus(&status, string(statusOut), ahead, behind)
+	addDiffstat(ctx, &status)
+
+	return status
+}
+
+// lastCommitFormat prefixes the human-readable summary with a unix timestamp
+// and sets the author off with a unit separator, since names have spaces
+const lastCommitFormat = "%ct %h %cr%x1f%an"
+
+// parseLastCommit splits lastCommitFormat output into the summary shown in
+// the details ("abc1234 2 days ago Ada"), the author and the commit time
+func parseLastCommit(out string) (string, string, time.Time) {
+	stamp, summary, _ := strings.Cut(strings.TrimSpace(out), " ")
+	summary, author, _ := strings.Cut(summary, "\x1f")
+	if author != "" {
+		summary += " " + author
+	}
+	seconds, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return summary, author, time.Time{}
+	}
+	return summary, author, time.Unix(seconds, 0)
+}
+
+// classifyStatus derives the symbol and message from porcelain output and ahead/behind counts.
+// Empty counts mean rev-list failed because the branch has no upstream.
+func classifyStatus(status *GitStatus, porcelain, ahead, behind string) {
+	statusStr := strings.TrimSpace(porcelain)
+	status.Dirty = statusStr != ""
+	countChanges(status, porcelain)
+	status.HasUpstream = status.HasRemote && ahead != "" && behind != ""
+	status.Ahead, _ = strconv.Atoi(ahead)
+	status.Behind, _ = strconv.Atoi(behind)
+
+	if !status.HasRemote {
+		if status.Dirty {
+			status.Symbol = "✗"
+			status.Message = "Uncommitted changes (no remote configured)"
+		} else {
+			status.Symbol = "↑"
+			status.Message = "No remote configured"
+		}
+	} else if ahead == "" || behind == "" {
+		if status.Dirty {
+			status.Symbol = "✗"
+			status.Message = "Uncommitted changes (no upstream branch)"
+		} else {
+			status.Symbol = "↑"
+			status.Message = "No upstream branch"
+		}
+	} else if statusStr == "" && ahead == "0" && behind == "0" {
 		status.Symbol = "✓"
 		status.Message = "Up to date"
 	} else if ahead != "0" && behind != "0" {
@@ -483,17 +1505,98 @@ func getGitStatus(repoPath, baseDir string, cache map[string]GitStatus) GitStatu
 		status.Symbol = "✗"
 		status.Message = "Uncommitted changes"
 	}
+	markAuthRequired(status)
+	markRemoteGone(status)
+	markCorrupt(status)
+}
 
-	return status
+// detailCmd loads the extra detail view sections for the selected repo
+func (m model) detailCmd() tea.Cmd {
+	if (!m.showDetail && !m.split()) || m.cursor >= len(m.repos) {
+		return nil
+	}
+	repoPath := m.repos[m.cursor].RepoPath
+	return tea.Batch(loadChangelogCmd(repoPath), loadDescriptionCmd(repoPath), loadRepoLogCmd(repoPath), loadStashesCmd(repoPath), loadTrackingCmd(repoPath))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if next, ok := next.(model); ok {
+		if next.notice != m.notice && isActionResult(next.notice) {
+			next = next.addEvent(inboxEvent{Kind: "action", Text: next.notice})
+		}
+		return next.scrollToCursor(), cmd
+	}
+	return next, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, isKey := msg.(tea.KeyMsg); m.dirPicker != nil && !isKey {
+		// directory listings it asked for
+		m.dirPicker.picker, _ = m.dirPicker.picker.Update(msg)
+	}
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.showHelp {
+			m.showHelp = false
+			return m, nil
+		}
+		if m.showInbox {
+			m.showInbox = false
+			return m.markInboxRead(), nil
+		}
+		if m.diff != nil {
+			return m.updateDiff(msg)
+		}
+		if m.branches != nil {
+			return m.updateBranches(msg)
+		}
+		if m.dirPicker != nil {
+			return m.updateDirPicker(msg)
+		}
+		if m.bulkConfirm != nil {
+			return m.updateBulkConfirm(msg)
+		}
+		if m.palette != nil {
+			return m.updatePalette(msg)
+		}
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		if m.prPrompt != nil {
+			return m.updatePullRequest(msg)
+		}
+		if m.stashPrompt != nil {
+			return m.updateStashPrompt(msg)
+		}
+		if m.branchPrompt != nil {
+			return m.updateBranchPrompt(msg)
+		}
+		key := msg.String()
+		if alias, ok := keyAliases[key]; ok {
+			key = alias
+		}
+		if m.recording && recordable(key) {
+			m.macro = append(m.macro, key)
+		}
 		switch msg.String() {
+		case ":":
+			m.palette = &commandPalette{}
+		case "/":
+			m.searching = true
+		case "s":
+			m.sortBy = nextSort(m.sortBy)
+			m = m.applySearch()
+		case "Q":
+			return m.toggleRecording(), nil
+		case "@":
+			return m.playMacro()
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		case "up", "k":
+			if m.showDetail && msg.String() == "k" {
+				return m.scrollLog(-1), nil
+			}
 			oldCursor := m.cursor
 			if m.cursor > 0 {
 				m.cursor--
@@ -503,7 +1606,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.animations.AddStatusChangeParticles(0, m.cursor, "nav")
 				}
 			}
+			return m, m.detailCmd()
 		case "down", "j":
+			if m.showDetail && msg.String() == "j" {
+				return m.scrollLog(1), nil
+			}
 			oldCursor := m.cursor
 			if m.cursor < len(m.repos)-1 {
 				m.cursor++
@@ -513,13 +1620,151 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.animations.AddStatusChangeParticles(0, m.cursor, "nav")
 				}
 			}
-		case "enter", " ":
+			return m, m.detailCmd()
+		case "pgup", "pgdown":
+			m = m.pageCursor(msg.String() == "pgdown")
+			return m, m.detailCmd()
+		case " ":
+			m = m.toggleMark()
+			return m, m.detailCmd()
+		case "v":
+			m = m.toggleVisual()
+		case "H":
+			m = m.hideTargets()
+		case "x":
+			m = m.excludeTargets()
+		case "enter":
 			m.showDetail = !m.showDetail
 			if m.showDetail && len(m.repos) > 0 {
 				m.animations.AddStatusChangeParticles(15, 5, m.repos[m.cursor].Symbol)
 			}
+			return m, m.detailCmd()
+		case "|":
+			return m.toggleSplit()
+		case "B":
+			m = m.startBranchPrompt()
+		case "a":
+			m.config.All = !m.config.All
+			m.notice = fmt.Sprintf("Showing all repos: %t", m.config.All)
+			m = m.refilter()
+		case "D":
+			return m.openDirPicker()
+		case "*":
+			m = m.togglePin()
+			return m, m.detailCmd()
+		case "w":
+			// Export the changelog preview of the repo in the detail view
+			if m.showDetail && m.changelog != nil && m.cursor < len(m.repos) && m.changelog.RepoPath == m.repos[m.cursor].RepoPath {
+				return m, exportChangelogCmd(*m.changelog)
+			}
+		case "=":
+			// Pin the selected repo as the other side of comparisons
+			if m.cursor < len(m.repos) {
+				if m.compareWith == m.repos[m.cursor].RepoPath {
+					m.compareWith = ""
+					m.notice = "Unpinned comparison repo"
+				} else {
+					m.compareWith = m.repos[m.cursor].RepoPath
+					m.notice = fmt.Sprintf("Pinned %s for comparison, select another repo and press c", m.repos[m.cursor].RelativePath)
+				}
+			}
+		case "c":
+			if m.compareWith == "" || m.cursor >= len(m.repos) || m.repos[m.cursor].RepoPath == m.compareWith {
+				m.notice = "Pin a repo with = first, then select a different one to compare"
+				break
+			}
+			for _, pinned := range m.scanned {
+				if pinned.RepoPath == m.compareWith {
+					m.showCompare = true
+					m.comparison = nil
+					return m, compareReposCmd(m.repos[m.cursor], pinned)
+				}
+			}
+		case "f":
+			if offline {
+				return m.offlineNotice(), nil
+			}
+			return m.confirmFetch()
+		case "F":
+			if offline {
+				return m.offlineNotice(), nil
+			}
+			if m.fetchAll == nil && len(m.discovered) > 0 {
+				var cmd tea.Cmd
+				m.fetchAll, cmd = fetchAllCmd(m.discovered)
+				return m, cmd
+			}
+		case "p":
+			if offline {
+				return m.offlineNotice(), nil
+			}
+			return m.confirmPull()
+		case "e":
+			if m.cursor < len(m.repos) {
+				return m, editRepoCmd(m.repos[m.cursor], m.baseDir)
+			}
+		case "d":
+			return m.openDiff()
+		case "b":
+			return m.openBranches()
+		case "S", "U":
+			return m.startStash(msg.String() == "U"), nil
+		case "?":
+			m.showHelp = true
+		case "i":
+			m.showInbox = true
+		case "1", "2", "3", "4", "5":
+			return m.switchTab(int(msg.String()[0] - '1')), nil
+		case "tab":
+			return m.switchTab(m.tab + 1), nil
+		case "z", "h", "left":
+			if m.treeView {
+				return m.collapseDir(), nil
+			}
+			return m.collapseGroup(), nil
+		case "l", "right":
+			if m.treeView {
+				return m.expandDir(), nil
+			}
+			return m.expandGroup(), nil
+		case "Z":
+			if m.treeView {
+				return m.toggleAllDirs(), nil
+			}
+			return m.toggleAllGroups(), nil
+		case "shift+tab":
+			return m.switchTab(m.tab - 1), nil
+		case "P":
+			return m.startPullRequest(), nil
+		case "!":
+			if m.cursor < len(m.repos) {
+				return m, shellRepoCmd(m.repos[m.cursor], m.baseDir)
+			}
+		case "o":
+			if m.cursor < len(m.repos) {
+				return m, browseRepoCmd(m.repos[m.cursor], "")
+			}
+		case "R", "T":
+			var cmds []tea.Cmd
+			for _, repo := range m.targets() {
+				if msg.String() == "R" {
+					cmds = append(cmds, revealCmd(repo.RepoPath))
+				} else {
+					cmds = append(cmds, openTerminalCmd(repo.RepoPath))
+				}
+			}
+			return m, tea.Batch(cmds...)
 		case "esc":
+			if m.visualFrom != "" || len(m.marked) > 0 {
+				m = m.clearSelection()
+				return m, nil
+			}
+			if !m.showDetail && !m.showCompare && m.search != "" {
+				m.search = ""
+				m = m.applySearch()
+			}
 			m.showDetail = false
+			m.showCompare = false
 		case "m":
 			// Toggle matrix mode
 			m.matrixMode = !m.matrixMode
@@ -530,11 +1775,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.lastUpdate = time.Now()
 			// Clear cache to force fresh data
 			m.cache = make(map[string]GitStatus)
+			m.triageLoaded = false
 			return m, scanRepos(m.baseDir, m.config.Depth, m.cache)
 		}
 
 	case reposFoundMsg:
 		repos := []GitStatus(msg)
+		if m.scannedElsewhere(repos) {
+			return m, nil // a scan of the root before D switched it
+		}
 		
 		// Check for status changes and trigger particles
 		for i, newRepo := range repos {
@@ -547,17 +1796,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			_ = i
 		}
 		
-		if !m.config.All {
-			var unsynced []GitStatus
-			for _, repo := range repos {
-				if repo.Symbol != "✓" {
-					unsynced = append(unsynced, repo)
-				}
-			}
-			m.repos = unsynced
-		} else {
-			m.repos = repos
+		m = m.statusEvents(repos)
+		go saveSnapshot(m.baseDir, repos)
+		m.discovered = nil
+		for _, repo := range repos {
+			m.discovered = append(m.discovered, repo.RepoPath)
 		}
+		m.unreadable = len(scanUnreadable.list())
+		m.foreign = scanForeign.Load()
+		m.found = repos
+		m.scanned = filterRepos(repos, m.config)
+		m = m.applySearch()
 		m.loading = false
 		m.lastUpdate = time.Now()
 		m.updateCount++
@@ -567,7 +1816,90 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			go m.setupWatchers()
 		}
 
+		var cmds []tea.Cmd
+		// forge review requests and assigned issues, at startup and on r
+		if !m.triageLoaded {
+			m.triageLoaded = true
+			cmds = append(cmds, loadTriageCmd(repos))
+		}
+		if m.split() {
+			cmds = append(cmds, m.detailCmd())
+		}
+		if !m.forgeEvents {
+			m.forgeEvents = true
+			cmds = append(cmds, loadForgeNotificationsCmd())
+		}
+
+		// --fetch: fetch once the first scan has found the repos
+		if m.config.Fetch && !offline {
+			m.config.Fetch = false
+			var cmd tea.Cmd
+			m.fetchAll, cmd = fetchAllCmd(m.discovered)
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
+
+	case forgeNotificationsMsg:
+		for _, event := range msg.events {
+			m = m.addEvent(event)
+		}
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("⚠ Could not load notifications from %v", msg.err)
+		}
+		return m, tea.Tick(forgePollInterval, func(time.Time) tea.Msg {
+			return forgePollMsg{}
+		})
+
+	case forgePollMsg:
+		// nil when offline or no forge is configured any more, which ends
+		// the polling
+		return m, loadForgeNotificationsCmd()
+
+	case triageMsg:
+		m.triage = msg.counts
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("⚠ Could not load reviews and assigned issues from %v", msg.err)
+		}
+		m = m.applySearch()
+
+	case fetchProgressMsg:
+		if m.fetchAll != nil {
+			m.fetchAll.done++
+			if msg.err != nil {
+				m.fetchAll.failed = append(m.fetchAll.failed, msg.repoPath)
+			}
+			if msg.host != "" && !slices.Contains(m.fetchAll.hosts, msg.host) {
+				m.fetchAll.hosts = append(m.fetchAll.hosts, msg.host)
+			}
+			return m, m.fetchAll.wait()
+		}
+
+	case pullPrecheckMsg:
+		return m.startPulls(msg)
+
+	case fetchAllDoneMsg:
+		if m.fetchAll != nil {
+			m.notice = fmt.Sprintf("✓ Fetched %d repositories", m.fetchAll.total-len(m.fetchAll.failed))
+			if len(m.fetchAll.failed) > 0 {
+				m.notice = fmt.Sprintf("⚠ Fetched %d repositories, %d failed", m.fetchAll.total-len(m.fetchAll.failed), len(m.fetchAll.failed))
+			}
+			if len(m.fetchAll.hosts) > 0 {
+				m.notice += fmt.Sprintf(" (can't reach or log in to %s, see `auth check`)", strings.Join(m.fetchAll.hosts, ", "))
+			}
+			m.fetchAll = nil
+			m.loading = true
+			return m, scanRepos(m.baseDir, m.config.Depth, m.cache)
+		}
+
+	case treeWatchFreedMsg:
+		if m.treeWatch.takeOver() {
+			m.notice = "The other git-status-dash exited, watching this tree now"
+			go m.setupWatchers()
+		}
+		return m, m.watchForChanges()
+
 	case fileChangeMsg:
+		m.treeWatch.relay()
 		// File changed, trigger refresh
 		if time.Since(m.lastUpdate) > 2*time.Second { // Debounce
 			m.loading = true
@@ -579,6 +1911,88 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, m.watchForChanges()
 
+	case changelogMsg:
+		if msg.err == nil {
+			m.changelog = &msg.changelog
+		}
+
+	case repoLogMsg:
+		if m.gitLog == nil || m.gitLog.RepoPath != msg.RepoPath {
+			m.logOffset = 0
+		}
+		entries := repoLog(msg)
+		m.gitLog = &entries
+
+	case branchesMsg:
+		if m.branches != nil && m.branches.repo.RepoPath == msg.repoPath {
+			m.branches.loaded = true
+			m.branches.branches, m.branches.err = msg.branches, msg.err
+			for i, branch := range msg.branches {
+				if branch.Current {
+					m.branches.cursor = i
+				}
+			}
+		}
+
+	case trackingMsg:
+		tracking := repoTracking(msg)
+		m.tracking = &tracking
+
+	case stashMsg:
+		stashes := repoStashes(msg)
+		m.stashes = &stashes
+
+	case diffMsg:
+		if m.diff != nil && m.diff.repo.RepoPath == msg.repoPath && m.diff.full == msg.full {
+			m.diff.loaded = true
+			m.diff.view.SetContent(msg.text)
+			m.diff.view.GotoTop()
+		}
+
+	case descriptionMsg:
+		description := repoDescription(msg)
+		m.description = &description
+
+	case machinesMsg:
+		m.machines = msg
+		return m, tea.Tick(machinesPollInterval, func(time.Time) tea.Msg {
+			return machinesPollMsg{}
+		})
+
+	case machinesPollMsg:
+		return m, loadMachinesCmd()
+
+	case noticeMsg:
+		m.notice = string(msg)
+
+	case repoStatusMsg:
+		delete(m.busy, msg.status.RepoPath)
+		m = m.updateRepo(msg.status)
+		m.notice = msg.notice
+		return m, m.detailCmd() // stashes, log and tracking may have changed
+
+	case comparisonMsg:
+		if msg.err != nil {
+			m.showCompare = false
+			m.notice = fmt.Sprintf("✗ Compare failed: %v", msg.err)
+		} else {
+			m.comparison = &msg.comparison
+		}
+
+	case tea.WindowSizeMsg:
+		if msg.Width != m.termWidth || msg.Height != m.termHeight {
+			m.hackerFX.Resize(msg.Width, msg.Height)
+		}
+		wasSplit := m.split()
+		m.termWidth = msg.Width
+		m.termHeight = msg.Height
+		if m.diff != nil {
+			m.diff.view.Width, m.diff.view.Height = m.termWidth, max(1, m.termHeight-2)
+		}
+		if m.split() && !wasSplit {
+			return m, m.detailCmd()
+		}
+
 	case animationTickMsg:
 		m.animations.Update()
 		m.hackerFX.Update(m.termWidth, m.termHeight)
@@ -595,7 +2009,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m model) View() string {
+// spinnerFrame animates off the clock; the tick loop keeps redrawing
+func spinnerFrame() string {
+	spinner := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	return spinner[int(time.Now().UnixNano()/100000000)%len(spinner)]
+}
+
+// viewHeader is the title and search line above the repo list; position
+// says which rows of a scrolled list are visible
+func (m model) viewHeader(position string) string {
 	var s strings.Builder
 
 	titleStyle := lipgloss.NewStyle().
@@ -603,29 +2025,101 @@ func (m model) View() string {
 		Foreground(lipgloss.Color("62")).
 		Padding(1, 2)
 
-	s.WriteString(titleStyle.Render("🚀 Git Status Dashboard"))
-	s.WriteString("\n\n")
+	sortStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Bold(false)
+	s.WriteString(titleStyle.MaxWidth(m.termWidth).Render("🚀 Git Status Dashboard" + sortStyle.Render(m.orderLabel()+position+m.triageSummary()+m.inboxSummary()+offlineLabel())))
+	s.WriteString("\n")
+	if !m.loading {
+		s.WriteString(m.tabBar() + "\n")
+	}
+	s.WriteString("\n")
 
-	if m.loading {
-		spinner := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
-		loadingStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("205")).
-			Bold(true)
-		s.WriteString(loadingStyle.Render(fmt.Sprintf("%s Scanning repositories...", spinner[int(time.Now().UnixNano()/100000000)%len(spinner)])))
-		return s.String()
+	if !m.loading && (m.searching || m.search != "") {
+		cursor := ""
+		if m.searching {
+			cursor = "█"
+		}
+		s.WriteString(fmt.Sprintf("/ %s%s  (%d of %d)\n\n", m.search, cursor, len(m.repos), len(m.scanned)))
 	}
+	return s.String()
+}
 
-	if len(m.repos) == 0 {
-		s.WriteString("No git repositories found.")
+func (m model) View() string {
+	if m.showHelp {
+		return m.helpView()
+	}
+	if m.diff != nil {
+		return m.diffView()
+	}
+	rows := m.listRows()
+	if m.loading || len(rows) == 0 {
+		var s strings.Builder
+		s.WriteString(m.viewHeader(""))
+		if m.loading {
+			loadingStyle := lipgloss.NewStyle().
+				Foreground(lipgloss.Color("205")).
+				Bold(true)
+			s.WriteString(loadingStyle.Render(fmt.Sprintf("%s Scanning repositories...", spinnerFrame())))
+		} else if m.search != "" {
+			s.WriteString("No repositories match.")
+		} else if m.tab != 0 {
+			s.WriteString(fmt.Sprintf("Nothing under %s.", statusTabs[m.tab].Name))
+		} else {
+			s.WriteString("No git repositories found.")
+		}
 		return s.String()
 	}
 
+	list := m.list
+	list.Width = m.listWidth()
+	list.Height = min(m.listHeight(), len(rows))
+	if m.split() {
+		list.Height = m.listHeight() // the details pane takes the whole height
+	}
+	list.SetContent(strings.Join(m.listLines(rows), "\n"))
+	position := ""
+	if len(rows) > list.Height {
+		position = fmt.Sprintf(" · %d-%d of %d", list.YOffset+1, min(len(rows), list.YOffset+list.Height), len(rows))
+	}
+	if m.split() {
+		return m.viewHeader(position) + m.splitPane(list.View(), list.Height) + "\n" + m.viewFooter()
+	}
+	return m.viewHeader(position) + list.View() + "\n" + m.viewFooter()
+}
+
+// listLines renders one line per row: a repo, or a section header when
+// grouping by status
+func (m model) listLines(rows []listRow) []string {
+	var lines []string
+	branchStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+	fitWidth := lipgloss.NewStyle() // long lines are cut rather than wrapped
+	triageStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("213"))
+	pinStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
+	authorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+	treeWidth := treeLabelWidth(rows)
+	width := m.listWidth()
+
 	// Main repo list
-	for i, repo := range m.repos {
+	for _, row := range rows {
+		if row.group != "" {
+			lines = append(lines, fitWidth.MaxWidth(width).Render(m.groupHeader(row.group)))
+			continue
+		}
+		if row.dir != "" {
+			lines = append(lines, fitWidth.MaxWidth(width).Render(m.dirLine(row)))
+			continue
+		}
+		i, repo := row.repo, m.repos[row.repo]
 		cursor := " "
 		if m.cursor == i {
 			cursor = ">"
 		}
+		mark := " "
+		if m.isMarked(i) {
+			mark = "●"
+		} else if m.pinned[configRepoPath(repo.RepoPath)] {
+			mark = pinStyle.Render("★")
+		}
 
 		symbolStyle := lipgloss.NewStyle()
 		repoStyle := lipgloss.NewStyle()
@@ -636,7 +2130,7 @@ func (m model) View() string {
 			symbolStyle = symbolStyle.Foreground(lipgloss.Color("46"))
 			repoStyle = repoStyle.Foreground(lipgloss.Color("46"))
 			messageStyle = messageStyle.Foreground(lipgloss.Color("46"))
-		case "✗", "⚠":
+		case "✗", "⚠", "☠":
 			symbolStyle = symbolStyle.Foreground(lipgloss.Color("196"))
 			repoStyle = repoStyle.Foreground(lipgloss.Color("196"))
 			messageStyle = messageStyle.Foreground(lipgloss.Color("196"))
@@ -657,19 +2151,80 @@ func (m model) View() string {
 			repoName = "."
 		}
 
-		line := fmt.Sprintf("%s %s %-30s %s",
+		line := fmt.Sprintf("%s%s %s %-30s %s",
 			cursor,
+			mark,
 			symbolStyle.Render(repo.Symbol),
 			repoStyle.Render(repoName),
-			messageStyle.Render(repo.Message),
+			messageStyle.Render(statusLabel(repo)),
 		)
+		if row.label != "" {
+			// tree view: the drawn name first, statuses lined up after it
+			label := row.label + strings.Repeat(" ", treeWidth-utf8.RuneCountInString(row.label))
+			line = fmt.Sprintf("%s%s %s  %s %s", cursor, mark, repoStyle.Render(label), symbolStyle.Render(repo.Symbol), messageStyle.Render(statusLabel(repo)))
+		}
+		if repo.OffDefaultBranch() {
+			line += branchStyle.Render(fmt.Sprintf(" ⎇ %s", repo.Branch))
+		}
+		if m.showAuthor {
+			line += authorStyle.Render(authorLabel(repo))
+		}
+		if badge := triageBadge(m.triage[repo.RepoPath]); badge != "" {
+			line += triageStyle.Render(" ◆ " + badge)
+		}
+		if repo.RepoPath == m.compareWith {
+			line += branchStyle.Render(" ⇄")
+		}
+		if m.busy[repo.RepoPath] {
+			line += " " + spinnerFrame()
+		}
+
+		lines = append(lines, fitWidth.MaxWidth(width).Render(line))
+	}
+	return lines
+}
 
-		s.WriteString(line + "\n")
+// detailText is a repo's detail view: the popup's content, or the split
+// view's right pane. The description wraps at width columns.
+func (m model) detailText(repo GitStatus, width int) string {
+	detailContent := fmt.Sprintf(
+		"Repository Details\n\n"+
+			"Path: %s\n"+
+			"Branch: %s\n"+
+			"Status: %s\n"+
+			"Last Commit: %s",
+		repo.RepoPath,
+		describeBranch(repo),
+		repo.Message,
+		repo.LastCommit,
+	)
+	if d := m.description; d != nil && d.RepoPath == repo.RepoPath && d.Text != "" {
+		about := lipgloss.NewStyle().Width(width).
+			Render(fmt.Sprintf("About: %s (%s)", d.Text, d.Source))
+		detailContent += "\n" + about
+	}
+	if t := m.tracking; t != nil && t.RepoPath == repo.RepoPath {
+		detailContent += "\n\n" + t.preview()
+	}
+	if m.changelog != nil && m.changelog.RepoPath == repo.RepoPath {
+		detailContent += "\n\n" + m.changelog.preview(5)
+	}
+	if s := m.stashes; s != nil && s.RepoPath == repo.RepoPath && len(s.Entries) > 0 {
+		detailContent += "\n\n" + s.preview()
 	}
+	if m.gitLog != nil && m.gitLog.RepoPath == repo.RepoPath {
+		detailContent += "\n\n" + m.gitLog.preview(m.logOffset)
+	}
+	return detailContent
+}
+
+// viewFooter is everything below the repo list: the detail popup or
+// comparison, notices and the help line
+func (m model) viewFooter() string {
+	var s strings.Builder
 
-	// Detail popup
-	if m.showDetail && len(m.repos) > 0 && m.cursor < len(m.repos) {
-		repo := m.repos[m.cursor]
+	// Detail popup; the split view shows the same beside the list instead
+	if m.showDetail && !m.split() && len(m.repos) > 0 && m.cursor < len(m.repos) {
 		detailStyle := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(lipgloss.Color("62")).
@@ -677,20 +2232,23 @@ func (m model) View() string {
 			Margin(1, 0).
 			Background(lipgloss.Color("0"))
 
-		detailContent := fmt.Sprintf(
-			"Repository Details\n\n"+
-				"Path: %s\n"+
-				"Branch: %s\n"+
-				"Status: %s\n"+
-				"Last Commit: %s",
-			repo.RepoPath,
-			repo.Branch,
-			repo.Message,
-			repo.LastCommit,
-		)
+		detailContent := m.detailText(m.repos[m.cursor], max(40, min(80, m.termWidth-10)))
+		s.WriteString("\n")
+		detail := detailStyle.Render(detailContent)
+		if lipgloss.Width(detail) > m.termWidth {
+			// wrap the content inside the border instead of overflowing
+			detail = detailStyle.Width(max(20, m.termWidth-2)).Render(detailContent)
+		}
+		s.WriteString(detail)
+	}
 
+	if m.showCompare {
 		s.WriteString("\n")
-		s.WriteString(detailStyle.Render(detailContent))
+		if m.comparison == nil {
+			s.WriteString("Comparing...\n")
+		} else {
+			s.WriteString(renderComparison(*m.comparison, m.termWidth) + "\n")
+		}
 	}
 
 	s.WriteString("\n")
@@ -698,11 +2256,81 @@ func (m model) View() string {
 		Foreground(lipgloss.Color("241")).
 		Italic(true)
 
-	helpText := "↑/↓: navigate • enter: details • q: quit"
-	if m.showDetail {
-		helpText = "↑/↓: navigate • esc: close details • q: quit"
+	if m.palette != nil {
+		s.WriteString(m.palette.view() + "\n")
+	}
+	if m.showInbox {
+		s.WriteString(m.inboxView() + "\n")
+	}
+	if m.branches != nil {
+		s.WriteString(m.branches.view(m.termWidth) + "\n")
+	}
+	if m.dirPicker != nil {
+		s.WriteString(m.dirPicker.view(m.termWidth) + "\n")
+	}
+	if m.stashPrompt != nil {
+		s.WriteString(m.stashPrompt.question() + "\n")
+	}
+	if m.bulkConfirm != nil {
+		s.WriteString(m.bulkConfirm.modal.View() + "\n")
+	}
+	if m.branchPrompt != nil {
+		s.WriteString(fmt.Sprintf("Only repos on branch (globs, comma-separated): %s█\n", m.branchPrompt.text))
+	}
+	if m.prPrompt != nil {
+		s.WriteString(fmt.Sprintf("Pull request %s → %s, title: %s█\n", m.prPrompt.repo.Branch, m.prPrompt.repo.DefaultBranch, m.prPrompt.title))
+	}
+
+	if m.notice != "" {
+		s.WriteString(lipgloss.NewStyle().Width(m.termWidth).Render(m.notice) + "\n")
+	}
+	if m.visualFrom != "" || len(m.marked) > 0 {
+		s.WriteString(fmt.Sprintf("● %d selected, actions apply to all of them (esc to clear)\n", len(m.targets())))
+	}
+	if m.fetchAll != nil {
+		s.WriteString(fmt.Sprintf("%s Fetching %d/%d repositories...\n", spinnerFrame(), m.fetchAll.done, m.fetchAll.total))
+	}
+	if m.unreadable > 0 {
+		s.WriteString(helpStyle.Render("⚠ "+unreadableSummary(m.unreadable)) + "\n")
+	}
+	if m.foreign > 0 {
+		s.WriteString(helpStyle.Render("- "+foreignSummary(m.foreign)) + "\n")
+	}
+	for _, line := range m.machines.lines {
+		s.WriteString(helpStyle.Render("⇆ "+line) + "\n")
+	}
+	for _, warning := range m.machines.warnings {
+		s.WriteString(helpStyle.Render("⚠ Other machines: "+warning) + "\n")
+	}
+	if m.machines.err != nil && m.machines.err != errOffline {
+		s.WriteString(helpStyle.Render("⚠ Couldn't read your other machines' status: "+m.machines.err.Error()) + "\n")
+	}
+
+	helpText := "↑/↓: move • enter: details • space/v: select • /: search • s: sort • f/F/p/e: fetch/all/pull/edit • :: palette • ?: help"
+	if m.palette != nil {
+		helpText = "type to filter • ↑/↓: select • enter: run • esc: close"
+	} else if m.searching {
+		helpText = "type to filter • ↑/↓: select • enter: keep filter • esc: clear"
+	} else if m.branches != nil {
+		helpText = "↑/↓: select • enter: check out • esc: close"
+	} else if m.dirPicker != nil {
+		helpText = "↑/↓: select • l/h: open/up • enter: scan highlighted • .: scan current • esc: close"
+	} else if m.stashPrompt != nil {
+		helpText = "y: confirm • any other key: cancel"
+	} else if m.bulkConfirm != nil {
+		helpText = "type the count or yes • enter: confirm • ↑/↓: scroll • esc: cancel"
+	} else if m.branchPrompt != nil {
+		helpText = "enter: apply (empty shows every branch) • ctrl+u: clear • esc: cancel"
+	} else if m.prPrompt != nil {
+		helpText = "enter: push and open pull request • ctrl+u: clear title • esc: cancel"
+	} else if m.showDetail && m.split() {
+		helpText = "↑/↓: navigate • j/k: scroll commits • d: diff • w: export changelog • esc: back to the list • q: quit"
+	} else if m.showDetail {
+		helpText = "↑/↓: navigate • j/k: scroll commits • d: diff • w: export changelog • esc: close details • q: quit"
+	} else if m.showCompare {
+		helpText = "↑/↓: navigate • c: compare selected • esc: close comparison • q: quit"
 	}
-	s.WriteString(helpStyle.Render(helpText))
+	s.WriteString(helpStyle.MaxWidth(m.termWidth).Render(helpText))
 
 	return s.String()
 }
\ No newline at end of file
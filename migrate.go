@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// manifestSchema is the manifest's schema_version. Like JSON output, fields
+// are only added within a version; anything else bumps it, and import
+// refuses manifests from a newer schema instead of guessing.
+const manifestSchema = 1
+
+// workspaceManifest is what export-workspace writes and import-workspace
+// reads: where each repo sits under the root, where to clone it from, and
+// the config that goes with them
+type workspaceManifest struct {
+	SchemaVersion int            `json:"schema_version"`
+	Version       string         `json:"version"` // the git-status-dash that wrote it
+	Exported      time.Time      `json:"exported_at"`
+	Root          string         `json:"root"`
+	Repos         []manifestRepo `json:"repos"`
+	Config        *UserConfig    `json:"config,omitempty"`
+}
+
+type manifestRepo struct {
+	Path    string            `json:"path"` // relative to the root with forward slashes, "." for the root itself
+	Branch  string            `json:"branch,omitempty"`
+	Remotes map[string]string `json:"remotes,omitempty"`
+}
+
+// cloneURL picks the remote to clone from: origin, else the first by name
+func (r manifestRepo) cloneURL() (name, url string) {
+	if url, ok := r.Remotes["origin"]; ok {
+		return "origin", url
+	}
+	names := make([]string, 0, len(r.Remotes))
+	for name := range r.Remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return "", ""
+	}
+	return names[0], r.Remotes[names[0]]
+}
+
+// withoutCredentials drops a password or token embedded in a remote URL.
+// http(s) URLs lose the whole user part, since tokens are often put in the
+// user name; ssh keeps the login name, which is needed to connect.
+func withoutCredentials(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.Scheme == "" || u.User == nil {
+		return remote
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		u.User = nil
+	} else {
+		u.User = url.User(u.User.Username())
+	}
+	return u.String()
+}
+
+// runExportWorkspace writes the manifest for every repo under baseDir.
+// Passwords and tokens are left out of the config and the remote URLs.
+func runExportWorkspace(baseDir, output string) error {
+	root, err := filepath.Abs(baseDir)
+	if err != nil {
+		return err
+	}
+	manifest := workspaceManifest{SchemaVersion: manifestSchema, Version: version, Exported: time.Now().UTC(), Root: root}
+	orphans := 0
+	for _, repo := range findGitReposOptimized(root, config.Depth) {
+		entry := manifestRepo{Path: filepath.ToSlash(repo.RelativePath), Remotes: repoRemotes(repo.RepoPath)}
+		for name, remote := range entry.Remotes {
+			entry.Remotes[name] = withoutCredentials(remote)
+		}
+		if repo.Branch != "HEAD" {
+			entry.Branch = repo.Branch
+		}
+		if len(entry.Remotes) == 0 {
+			orphans++
+		}
+		manifest.Repos = append(manifest.Repos, entry)
+	}
+	sort.Slice(manifest.Repos, func(i, j int) bool { return manifest.Repos[i].Path < manifest.Repos[j].Path })
+
+	if userConfig, err := loadConfig(); err == nil {
+		userConfig.Email.Password = ""
+		userConfig.Team.Token = ""
+		for name, forge := range userConfig.Forges {
+			forge.Token = ""
+			userConfig.Forges[name] = forge
+		}
+		manifest.Config = userConfig
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := writeFileAtomic(output, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote %d repositories to %s\n", len(manifest.Repos), output)
+	if orphans > 0 {
+		fmt.Printf("⚠ %d have no remote and can't be cloned again; copy them over yourself\n", orphans)
+	}
+	return nil
+}
+
+// rebasePath moves path from under one root to under another, leaving
+// paths outside the old root alone
+func rebasePath(path, from, to string) string {
+	rel, err := filepath.Rel(from, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.Join(to, rel)
+}
+
+// importedConfig is the exported config with its paths moved to the new
+// root. Secrets that were left out of the export are kept from the local
+// config.
+func importedConfig(exported *UserConfig, from, to string) *UserConfig {
+	imported := *exported
+	rebaseAll := func(paths []string) []string {
+		var out []string
+		for _, path := range paths {
+			out = append(out, rebasePath(path, from, to))
+		}
+		return out
+	}
+	imported.Protected = rebaseAll(exported.Protected)
+	imported.AllowedRoots = rebaseAll(exported.AllowedRoots)
+	imported.IgnoreDuplicates = rebaseAll(exported.IgnoreDuplicates)
+	if exported.Mirrors != nil {
+		imported.Mirrors = make(map[string][]string, len(exported.Mirrors))
+		for path, urls := range exported.Mirrors {
+			imported.Mirrors[rebasePath(path, from, to)] = urls
+		}
+	}
+	if local, err := loadConfig(); err == nil {
+		if imported.Email.Password == "" {
+			imported.Email.Password = local.Email.Password
+		}
+		if imported.Team.Token == "" {
+			imported.Team.Token = local.Team.Token
+		}
+		for name, forge := range imported.Forges {
+			if forge.Token == "" {
+				forge.Token = local.Forges[name].Token
+				imported.Forges[name] = forge
+			}
+		}
+	}
+	return &imported
+}
+
+// runImportWorkspace clones every repo in the manifest into the same layout
+// under dir, which defaults to the exported root. Repos already there are
+// skipped, so an interrupted import can simply be run again.
+func runImportWorkspace(manifestPath, dir string, dryRun, yes, withConfig bool) error {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	var manifest workspaceManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("%s is not a workspace manifest: %v", manifestPath, err)
+	}
+	switch {
+	case manifest.SchemaVersion == 0:
+		return fmt.Errorf("%s has no schema_version, export it again with this build", manifestPath)
+	case manifest.SchemaVersion > manifestSchema:
+		return fmt.Errorf("%s is schema version %d from git-status-dash %s, this build reads up to %d", manifestPath, manifest.SchemaVersion, manifest.Version, manifestSchema)
+	}
+	if dir == "" {
+		dir = manifest.Root
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return err
+	}
+
+	var clones []manifestRepo
+	skipped := 0
+	for _, repo := range manifest.Repos {
+		target := filepath.Join(dir, filepath.FromSlash(repo.Path))
+		_, url := repo.cloneURL()
+		entries, readErr := os.ReadDir(target)
+		_, gitErr := os.Stat(filepath.Join(target, ".git"))
+		badErr := checkManifestRepo(repo)
+		switch {
+		case badErr != nil:
+			fmt.Printf("✗ %-30s %v\n", repo.Path, badErr)
+			skipped++
+		case url == "":
+			fmt.Printf("✗ %-30s no remote to clone from\n", repo.Path)
+			skipped++
+		case gitErr == nil:
+			fmt.Printf("- %-30s already there\n", repo.Path)
+			skipped++
+		case readErr == nil && len(entries) > 0 && repo.Path != ".":
+			fmt.Printf("✗ %-30s %s exists and isn't empty\n", repo.Path, target)
+			skipped++
+		default:
+			if dryRun {
+				fmt.Printf("~ %-30s would clone %s\n", repo.Path, url)
+			}
+			clones = append(clones, repo)
+		}
+	}
+	if len(clones) == 0 {
+		fmt.Println("✓ Nothing to clone")
+	} else if dryRun {
+		fmt.Printf("\nWould clone %d repositories into %s, skipped %d\n", len(clones), dir, skipped)
+	}
+	if dryRun {
+		return nil
+	}
+	if offline && len(clones) > 0 {
+		return fmt.Errorf("cloning needs the network; run without --offline")
+	}
+
+	commands := make([]string, len(clones))
+	for i, repo := range clones {
+		_, url := repo.cloneURL()
+		commands[i] = fmt.Sprintf("git clone %s %s", url, filepath.Join(dir, filepath.FromSlash(repo.Path)))
+	}
+	if len(clones) > 0 && !yes && !confirmBulk(fmt.Sprintf("Clone %d repositories into %s?", len(clones), dir), commands) {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	// parents sort before the repos nested in them, so they are cloned first
+	cloned := 0
+	for _, repo := range clones {
+		if err := cloneManifestRepo(repo, filepath.Join(dir, filepath.FromSlash(repo.Path))); err != nil {
+			fmt.Printf("✗ %-30s %v\n", repo.Path, err)
+			skipped++
+			continue
+		}
+		fmt.Printf("✓ %-30s %s\n", repo.Path, repo.Branch)
+		cloned++
+	}
+	if len(clones) > 0 {
+		fmt.Printf("\nCloned %d repositories, skipped %d\n", cloned, skipped)
+	}
+
+	if withConfig && manifest.Config != nil {
+		if err := saveConfig(importedConfig(manifest.Config, manifest.Root, dir)); err != nil {
+			return err
+		}
+		fmt.Println("✓ Replaced the config with the exported one")
+	}
+	return nil
+}
+
+// checkManifestRepo rejects entries that would clone outside the import
+// root or hand git an option where it expects a name
+func checkManifestRepo(repo manifestRepo) error {
+	if !filepath.IsLocal(filepath.FromSlash(repo.Path)) {
+		return fmt.Errorf("path %q leaves the import directory", repo.Path)
+	}
+	if strings.HasPrefix(repo.Branch, "-") {
+		return fmt.Errorf("branch %q isn't a valid branch name", repo.Branch)
+	}
+	for name, remote := range repo.Remotes {
+		if strings.HasPrefix(name, "-") || strings.HasPrefix(remote, "-") {
+			return fmt.Errorf("remote %q isn't a valid remote", name)
+		}
+	}
+	return nil
+}
+
+// cloneManifestRepo clones one repo, adds its other remotes and checks out
+// the branch it was on
+func cloneManifestRepo(repo manifestRepo, target string) error {
+	name, url := repo.cloneURL()
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := checkManifestRepo(repo); err != nil {
+		return err
+	}
+	if _, err := runGitLong(filepath.Dir(target), "clone", "--origin", name, "--", url, target); err != nil {
+		return err
+	}
+	for remote, remoteURL := range repo.Remotes {
+		if remote == name {
+			continue
+		}
+		if _, err := runGit(target, "remote", "add", "--", remote, remoteURL); err != nil {
+			return err
+		}
+	}
+	if repo.Branch == "" {
+		return nil
+	}
+	if current, err := runGit(target, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && current != repo.Branch {
+		if _, err := runGit(target, "checkout", repo.Branch, "--"); err != nil {
+			return fmt.Errorf("cloned, but %s isn't on the remote to check out", repo.Branch)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mirrorPair is a source remote URL and a URL that should mirror it
+type mirrorPair struct {
+	Remote string // remote name the pair came from, empty for configured mirrors
+	Source string
+	Mirror string
+}
+
+// repoMirrors finds push URLs that differ from their remote's fetch URL,
+// plus mirrors configured for the repo under "mirrors" (keyed by its path
+// relative to the scanned directory, or its absolute path)
+func repoMirrors(repo GitStatus, configured map[string][]string) []mirrorPair {
+	var pairs []mirrorPair
+
+	remotes, _ := runGit(repo.RepoPath, "remote")
+	for _, remote := range strings.Fields(remotes) {
+		fetchURL, err := runGit(repo.RepoPath, "remote", "get-url", remote)
+		if err != nil {
+			continue
+		}
+		pushURLs, err := runGit(repo.RepoPath, "remote", "get-url", "--push", "--all", remote)
+		if err != nil {
+			continue
+		}
+		for _, pushURL := range strings.Split(pushURLs, "\n") {
+			if pushURL != "" && pushURL != fetchURL {
+				pairs = append(pairs, mirrorPair{Remote: remote, Source: fetchURL, Mirror: pushURL})
+			}
+		}
+	}
+
+	mirrors := configured[repo.RelativePath]
+	if len(mirrors) == 0 {
+		mirrors = configured[repo.RepoPath]
+	}
+	if len(mirrors) > 0 {
+		if source, err := runGit(repo.RepoPath, "remote", "get-url", "origin"); err == nil {
+			for _, mirror := range mirrors {
+				pairs = append(pairs, mirrorPair{Remote: "origin", Source: source, Mirror: mirror})
+			}
+		}
+	}
+	return pairs
+}
+
+// listRefs returns branch and tag refs of a remote URL mapped to their hashes
+func listRefs(repoPath, url string) (map[string]string, error) {
+	out, err := runGit(repoPath, "ls-remote", "--heads", "--tags", url)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		hash, ref, ok := strings.Cut(line, "\t")
+		if ok {
+			refs[ref] = hash
+		}
+	}
+	return refs, nil
+}
+
+// mirrorDrift lists refs that differ between source and mirror
+func mirrorDrift(source, mirror map[string]string) []string {
+	var drift []string
+	for ref, hash := range source {
+		mirrored, ok := mirror[ref]
+		switch {
+		case !ok:
+			drift = append(drift, ref+" missing")
+		case mirrored != hash:
+			drift = append(drift, ref+" differs")
+		}
+	}
+	for ref := range mirror {
+		if _, ok := source[ref]; !ok {
+			drift = append(drift, ref+" only on mirror")
+		}
+	}
+	sort.Strings(drift)
+	return drift
+}
+
+// syncMirror makes the mirror's branches and tags match the source exactly,
+// staging the source refs under a private namespace of the local repo
+func syncMirror(repoPath string, pair mirrorPair) error {
+	const staging = "refs/git-status-dash/mirror/"
+	defer func() {
+		if out, err := runGit(repoPath, "for-each-ref", "--format=%(refname)", staging); err == nil {
+			for _, ref := range strings.Fields(out) {
+				runGit(repoPath, "update-ref", "-d", ref)
+			}
+		}
+	}()
+
+	if _, err := runGitLong(repoPath, "fetch", "--no-tags", pair.Source,
+		"+refs/heads/*:"+staging+"heads/*", "+refs/tags/*:"+staging+"tags/*"); err != nil {
+		return err
+	}
+	_, err := runGitLong(repoPath, "push", "--prune", pair.Mirror,
+		"+"+staging+"heads/*:refs/heads/*", "+"+staging+"tags/*:refs/tags/*")
+	return err
+}
+
+// runMirrors checks every mirrored repo for drift and optionally re-syncs it
+func runMirrors(baseDir string, sync, yes bool) error {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	checked, drifted := 0, 0
+	for _, repo := range findGitReposOptimized(baseDir, config.Depth) {
+		for _, pair := range repoMirrors(repo, userConfig.Mirrors) {
+			checked++
+			name := repo.RelativePath
+			if name == "" {
+				name = filepath.Base(repo.RepoPath)
+			}
+
+			sourceRefs, err := listRefs(repo.RepoPath, pair.Source)
+			if err != nil {
+				fmt.Printf("⚠ %-30s cannot read %s: %v\n", name, pair.Source, err)
+				continue
+			}
+			mirrorRefs, err := listRefs(repo.RepoPath, pair.Mirror)
+			if err != nil {
+				fmt.Printf("⚠ %-30s cannot read %s: %v\n", name, pair.Mirror, err)
+				continue
+			}
+
+			drift := mirrorDrift(sourceRefs, mirrorRefs)
+			if len(drift) == 0 {
+				fmt.Printf("✓ %-30s %s in sync (%d refs)\n", name, pair.Mirror, len(sourceRefs))
+				continue
+			}
+
+			drifted++
+			fmt.Printf("✗ %-30s %s drifted: %d ref(s)\n", name, pair.Mirror, len(drift))
+			for _, line := range drift {
+				fmt.Printf("    %s\n", line)
+			}
+
+			if sync && isProtected(repo.RepoPath) {
+				fmt.Printf("  - Not syncing, %s\n", protectedMessage)
+				continue
+			}
+			if sync && (yes || confirm(fmt.Sprintf("  Sync %s from %s?", pair.Mirror, pair.Source))) {
+				if err := syncMirror(repo.RepoPath, pair); err != nil {
+					fmt.Printf("  ✗ Sync failed: %v\n", err)
+				} else {
+					fmt.Printf("  ✓ Synced %s\n", pair.Mirror)
+				}
+			}
+		}
+	}
+
+	if checked == 0 {
+		fmt.Println("No mirrors found (no push URLs differing from fetch URLs and nothing under \"mirrors\" in config).")
+		return nil
+	}
+	fmt.Printf("\n%d of %d mirrors drifted\n", drifted, checked)
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+)
+
+// bulk operations touching more repos than this need a typed confirmation
+const defaultBulkConfirmThreshold = 5
+
+func bulkConfirmThreshold() int {
+	if userConfig, err := loadConfig(); err == nil && userConfig.Behavior.BulkConfirmThreshold > 0 {
+		return userConfig.Behavior.BulkConfirmThreshold
+	}
+	return defaultBulkConfirmThreshold
+}
+
+// confirmModal lists the commands a bulk operation is about to run and only
+// confirms once the repo count or "yes" is typed. It works on its own as a
+// program and embedded in the TUI.
+type confirmModal struct {
+	title     string
+	commands  []string
+	input     string
+	offset    int
+	height    int // command lines visible at once
+	confirmed bool
+	done      bool
+}
+
+func newConfirmModal(title string, commands []string) confirmModal {
+	return confirmModal{title: title, commands: commands, height: 10}
+}
+
+// accepts reports whether answer confirms an operation on count repos
+func accepts(answer string, count int) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "yes" || answer == strconv.Itoa(count)
+}
+
+func (c confirmModal) Init() tea.Cmd {
+	return nil
+}
+
+func (c confirmModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// leave room for the title, prompt and border
+		c.height = max(3, msg.Height-8)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			c.done = true
+			return c, tea.Quit
+		case "enter":
+			c.done = true
+			c.confirmed = accepts(c.input, len(c.commands))
+			return c, tea.Quit
+		case "up", "ctrl+p":
+			if c.offset > 0 {
+				c.offset--
+			}
+		case "down", "ctrl+n":
+			if c.offset < len(c.commands)-c.height {
+				c.offset++
+			}
+		case "pgup":
+			c.offset = max(0, c.offset-c.height)
+		case "pgdown":
+			c.offset = max(0, min(len(c.commands)-c.height, c.offset+c.height))
+		case "backspace":
+			if input := []rune(c.input); len(input) > 0 {
+				c.input = string(input[:len(input)-1])
+			}
+		default:
+			if msg.Type == tea.KeyRunes {
+				c.input += string(msg.Runes)
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c confirmModal) View() string {
+	if c.done {
+		return ""
+	}
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("196")).
+		Padding(0, 1)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(c.title) + "\n\n")
+	end := min(len(c.commands), c.offset+c.height)
+	for _, command := range c.commands[c.offset:end] {
+		b.WriteString("  " + command + "\n")
+	}
+	if len(c.commands) > c.height {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  %d-%d of %d, ↑/↓ to scroll", c.offset+1, end, len(c.commands))) + "\n")
+	}
+	b.WriteString(fmt.Sprintf("\nType %d or yes to continue, esc to cancel: %s█", len(c.commands), c.input))
+	return boxStyle.Render(b.String())
+}
+
+// bulkConfirm is the confirmation modal the TUI shows before a batch
+// action on more marked repos than the threshold
+type bulkConfirm struct {
+	modal confirmModal
+	run   func(model) (model, tea.Cmd)
+}
+
+// confirmTargets runs action straight away for a few commands, and asks
+// first with the modal for more than the threshold
+func (m model) confirmTargets(title string, commands []string, action func(model) (model, tea.Cmd)) (model, tea.Cmd) {
+	if len(commands) <= bulkConfirmThreshold() {
+		return action(m)
+	}
+	modal := newConfirmModal(title, commands)
+	modal.height = max(3, m.termHeight-8)
+	m.bulkConfirm = &bulkConfirm{modal: modal, run: action}
+	return m, nil
+}
+
+func (m model) updateBulkConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	updated, _ := m.bulkConfirm.modal.Update(msg)
+	modal := updated.(confirmModal)
+	if !modal.done {
+		m.bulkConfirm = &bulkConfirm{modal: modal, run: m.bulkConfirm.run}
+		return m, nil
+	}
+	run := m.bulkConfirm.run
+	m.bulkConfirm = nil
+	if !modal.confirmed {
+		m.notice = "Cancelled"
+		return m, nil
+	}
+	return run(m)
+}
+
+// confirmBulk asks before running commands against many repos. Up to the
+// configured threshold it confirms right away; above it the count or "yes"
+// has to be typed, in a modal on a terminal or as a plain prompt otherwise.
+// The TUI shows the same modal through confirmTargets.
+func confirmBulk(title string, commands []string) bool {
+	if len(commands) <= bulkConfirmThreshold() {
+		return true
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Println(title)
+		for _, command := range commands {
+			fmt.Printf("  %s\n", command)
+		}
+		return accepts(prompt(fmt.Sprintf("Type %d or yes to continue", len(commands)), ""), len(commands))
+	}
+
+	final, err := tea.NewProgram(newConfirmModal(title, commands)).Run()
+	if err != nil {
+		return false
+	}
+	return final.(confirmModal).confirmed
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// motdBudget is how long motd may take: it runs on every login, so it
+// prints nothing rather than hold one up
+const motdBudget = 100 * time.Millisecond
+
+// statusSnapshot is the last scan of a directory, saved by reports and the
+// TUI so motd never has to scan
+type statusSnapshot struct {
+	Root      string      `json:"root"`
+	ScannedAt time.Time   `json:"scanned_at"`
+	Repos     []GitStatus `json:"repos"`
+}
+
+func snapshotPath(root string) (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(root))
+	return filepath.Join(configDir, "snapshots", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// saveSnapshot records a scan of root. Failing to is never worth
+// interrupting anything for, so errors are dropped.
+func saveSnapshot(root string, repos []GitStatus) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return
+	}
+	path, err := snapshotPath(root)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(statusSnapshot{Root: root, ScannedAt: time.Now().UTC(), Repos: repos})
+	if err != nil || os.MkdirAll(filepath.Dir(path), 0755) != nil {
+		return
+	}
+	writeFileAtomic(path, data, 0644)
+}
+
+func loadSnapshot(root string) (*statusSnapshot, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	path, err := snapshotPath(root)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot statusSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// cutToWidth cuts s to width runes, marking the cut with …
+func cutToWidth(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// writeMotd renders the banner: a summary line, counts per state and the
+// count repos most in need of attention. summary prints only the counts,
+// on one line, for status bars.
+func writeMotd(w io.Writer, snapshot *statusSnapshot, count, width int, color, summary bool) {
+	repos := append([]GitStatus(nil), snapshot.Repos...)
+	sortRepos(repos, "status")
+
+	counts := map[string]int{}
+	var attention []GitStatus
+	for _, repo := range repos {
+		counts[repo.Symbol]++
+		if repo.Symbol != "✓" {
+			attention = append(attention, repo)
+		}
+	}
+
+	age := relativeTime(snapshot.ScannedAt)
+	if time.Since(snapshot.ScannedAt) > 24*time.Hour {
+		age += ", stale"
+	}
+	root := snapshot.Root
+	if home, err := os.UserHomeDir(); err == nil && (root == home || strings.HasPrefix(root, home+string(filepath.Separator))) {
+		root = "~" + strings.TrimPrefix(root, home)
+	}
+	lines := []string{cutToWidth(fmt.Sprintf("git-status-dash · %s · %d repos · %s", root, len(repos), age), width)}
+
+	var counted []string
+	for _, state := range []struct{ symbol, label string }{
+		{"☠", "damaged"}, {"⚠", "failing"}, {"✗", "dirty"}, {"↕", "diverged"}, {"↓", "to pull"}, {"↑", "to push"},
+	} {
+		if counts[state.symbol] > 0 {
+			counted = append(counted, fmt.Sprintf("%s %d %s", state.symbol, counts[state.symbol], state.label))
+		}
+	}
+	if len(counted) == 0 {
+		counted = []string{"✓ all clean"}
+	}
+	if summary {
+		fmt.Fprintln(w, cutToWidth(strings.Join(counted, " "), width))
+		return
+	}
+	lines = append(lines, cutToWidth(strings.Join(counted, "  "), width))
+
+	limited, hidden := limitRepos(attention, count)
+	nameWidth := 0
+	for _, repo := range limited {
+		nameWidth = max(nameWidth, len([]rune(displayName(repo))))
+	}
+	nameWidth = min(nameWidth, width/2)
+	for _, repo := range limited {
+		name := cutToWidth(displayName(repo), nameWidth)
+		lines = append(lines, cutToWidth(fmt.Sprintf("%s %-*s %s", repo.Symbol, nameWidth, name, repo.Message), width))
+	}
+	if hidden > 0 {
+		lines = append(lines, fmt.Sprintf("… and %d more", hidden))
+	}
+
+	if !color {
+		fmt.Fprintln(w, strings.Join(lines, "\n"))
+		return
+	}
+	renderer := colorRenderer(w)
+	theme := activeTheme()
+	dimStyle := renderer.NewStyle().Foreground(themeColor(theme.Colors["dim"]))
+	fmt.Fprintln(w, dimStyle.Render(lines[0]))
+	fmt.Fprintln(w, lines[1])
+	for i, line := range lines[2:] {
+		if i < len(limited) {
+			line = renderer.NewStyle().Foreground(themeColor(theme.Colors[symbolColorKey(limited[i].Symbol)])).Render(line)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// runMotd prints the banner for dir from its last saved scan, within
+// motdBudget. Without a saved scan it says how to get one. refresh scans
+// first and saves the result, which takes as long as it takes; that is
+// for cron, not the login itself.
+func runMotd(dir string, count, width int, refresh, summary bool) error {
+	if refresh {
+		repos := findGitReposOptimized(dir, config.Depth)
+		// the daemon's refresh is also where the integrity checks run
+		scheduledFsck(repos)
+		for i := range repos {
+			markCorrupt(&repos[i])
+		}
+		saveSnapshot(dir, repos)
+		// the daemon's refresh also keeps the team dashboard and your other
+		// machines current
+		if userConfig, err := loadConfig(); err == nil {
+			if userConfig.Team.URL != "" {
+				if err := pushTeamReport(userConfig.Team, dir, repos); err != nil {
+					fmt.Fprintf(os.Stderr, "⚠ Couldn't push to the team dashboard: %v\n", err)
+				}
+			}
+			if userConfig.Sync.Backend != "" {
+				if err := publishStatus(userConfig.Sync, dir, repos); err != nil {
+					fmt.Fprintf(os.Stderr, "⚠ Couldn't publish to sync: %v\n", err)
+				}
+			}
+		}
+	}
+
+	type loaded struct {
+		snapshot *statusSnapshot
+		err      error
+	}
+	done := make(chan loaded, 1)
+	go func() {
+		snapshot, err := loadSnapshot(dir)
+		done <- loaded{snapshot, err}
+	}()
+
+	select {
+	case result := <-done:
+		if os.IsNotExist(result.err) && summary {
+			return nil
+		}
+		if os.IsNotExist(result.err) {
+			fmt.Printf("git-status-dash: no saved status for %s yet; run `git-status-dash motd --refresh %s` from cron\n", dir, dir)
+			return nil
+		}
+		if result.err != nil {
+			return result.err
+		}
+		var out strings.Builder
+		writeMotd(&out, result.snapshot, count, width, colorEnabled(os.Stdout), summary)
+		_, err := os.Stdout.WriteString(out.String())
+		return err
+	case <-time.After(motdBudget):
+		return nil // a slow disk shouldn't slow down the login
+	}
+}
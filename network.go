@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// NetworkConfig holds the proxy and certificate settings for networks that
+// intercept HTTPS. The environment wins over the proxy settings here.
+type NetworkConfig struct {
+	Proxy    string `json:"proxy,omitempty"`     // used when HTTPS_PROXY and HTTP_PROXY are unset
+	NoProxy  string `json:"no_proxy,omitempty"`  // used when NO_PROXY is unset
+	CABundle string `json:"ca_bundle,omitempty"` // PEM file trusted on top of the system roots
+}
+
+// applyNetworkConfig exports network.proxy and network.no_proxy as the
+// standard variables before anything connects, so the HTTP client and the
+// git subprocesses both pick them up. network.ca_bundle reaches git through
+// GIT_SSL_CAINFO.
+func applyNetworkConfig() {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return
+	}
+	settings := userConfig.Network
+	if settings.Proxy != "" && os.Getenv("HTTPS_PROXY") == "" && os.Getenv("https_proxy") == "" &&
+		os.Getenv("HTTP_PROXY") == "" && os.Getenv("http_proxy") == "" {
+		os.Setenv("HTTPS_PROXY", settings.Proxy)
+		os.Setenv("HTTP_PROXY", settings.Proxy)
+	}
+	if settings.NoProxy != "" && os.Getenv("NO_PROXY") == "" && os.Getenv("no_proxy") == "" {
+		os.Setenv("NO_PROXY", settings.NoProxy)
+	}
+	if settings.CABundle != "" && os.Getenv("GIT_SSL_CAINFO") == "" {
+		if bundle, err := gitCABundle(settings.CABundle); err == nil {
+			os.Setenv("GIT_SSL_CAINFO", bundle)
+		}
+	}
+}
+
+// systemCAFiles are where the usual distributions keep their PEM bundle
+var systemCAFiles = []string{
+	"/etc/ssl/certs/ca-certificates.crt", // Debian, Ubuntu, Arch
+	"/etc/pki/tls/certs/ca-bundle.crt",   // Fedora, RHEL
+	"/etc/ssl/ca-bundle.pem",             // openSUSE
+	"/etc/ssl/cert.pem",                  // macOS, Alpine, BSDs
+}
+
+// gitCABundle writes the system bundle and the extra one into a single file
+// for git, whose sslCAInfo replaces the system roots rather than adding to
+// them
+func gitCABundle(extra string) (string, error) {
+	pem, err := os.ReadFile(extra)
+	if err != nil {
+		return "", err
+	}
+	var combined []byte
+	for _, path := range systemCAFiles {
+		if system, err := os.ReadFile(path); err == nil {
+			combined = append(append(system, '\n'), pem...)
+			break
+		}
+	}
+	if combined == nil {
+		combined = pem
+	}
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(configDir, "ca-bundle.pem")
+	if err := writeFileAtomic(path, combined, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// rootCAs is the system pool plus network.ca_bundle, or nil to use the
+// system pool as is
+var rootCAs = sync.OnceValues(func() (*x509.CertPool, error) {
+	userConfig, err := loadConfig()
+	if err != nil || userConfig.Network.CABundle == "" {
+		return nil, nil
+	}
+	bundle := userConfig.Network.CABundle
+	pem, err := os.ReadFile(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("reading network.ca_bundle: %v", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("network.ca_bundle %s holds no PEM certificates", bundle)
+	}
+	return pool, nil
+})
+
+// httpClient is the client for every HTTP request: forge APIs, forge logins
+// and theme downloads. It goes through HTTPS_PROXY unless NO_PROXY matches.
+var httpClient = sync.OnceValues(func() (*http.Client, error) {
+	pool, err := rootCAs()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if pool != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: transport, Timeout: 30 * time.Second}, nil
+})
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// gitEnv is the environment every git subprocess runs with. Prompts are
+// turned off so a remote that wants a password fails instead of hanging a
+// scan or writing over the TUI: no terminal prompt, Git Credential Manager
+// in batch mode, and ssh asking a program that always declines rather than
+// the terminal. Credential helpers and ssh-agent keys still work. The ssh
+// part needs OpenSSH 8.4 and leaves GIT_SSH and GIT_SSH_COMMAND alone.
+var gitEnv = sync.OnceValue(func() []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	if os.Getenv("GIT_SSH") == "" && os.Getenv("GIT_SSH_COMMAND") == "" {
+		env = append(env, "SSH_ASKPASS_REQUIRE=force", "SSH_ASKPASS=false")
+	}
+	return env
+})
+
+// gitCommand builds a git subprocess that never prompts
+func gitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = gitEnv()
+	return cmd
+}
+
+// authFailures remembers, by repo path, why the last fetch, pull, push or
+// ls-remote couldn't log in. The scan reports those repos as
+// auth-required until one succeeds.
+var authFailures sync.Map
+
+// promptMarkers are how git fails when it would have asked for a username
+// or password, which gitEnv doesn't let it do
+var promptMarkers = []string{
+	"terminal prompts disabled",
+	"could not read username",
+	"could not read password",
+	"unable to read askpass response",
+}
+
+// authFailureMarkers are what git, ssh and the forges print when
+// credentials are refused
+var authFailureMarkers = []string{
+	"authentication failed",
+	"permission denied (publickey",
+	"permission denied, please try again",
+	"host key verification failed",
+	"http basic: access denied",
+	"the requested url returned error: 401",
+	"the requested url returned error: 403",
+}
+
+// authFailure says why git's output shows credentials were needed, or ""
+// when it failed for another reason
+func authFailure(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		lower := strings.ToLower(line)
+		for _, marker := range promptMarkers {
+			if strings.Contains(lower, marker) {
+				return "the remote asked for a username or password (set up a credential helper)"
+			}
+		}
+		for _, marker := range authFailureMarkers {
+			if strings.Contains(lower, marker) {
+				line = strings.TrimPrefix(strings.TrimSpace(line), "fatal: ")
+				return strings.TrimPrefix(line, "remote: ")
+			}
+		}
+	}
+	return ""
+}
+
+// touchesRemote reports whether a git command talks to a remote
+func touchesRemote(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "fetch", "pull", "push", "ls-remote":
+		return true
+	}
+	return false
+}
+
+// noteRemoteResult records or clears a repo's auth failure after a git
+// command that talked to a remote
+func noteRemoteResult(repoPath, output string, err error) {
+	if err == nil {
+		authFailures.Delete(repoPath)
+	} else if reason := authFailure(output); reason != "" {
+		authFailures.Store(repoPath, reason)
+	}
+}
+
+// markAuthRequired turns a repo whose remote refused the last login into
+// the auth-required state. Dirty, Ahead and Behind are kept.
+func markAuthRequired(status *GitStatus) {
+	reason, ok := authFailures.Load(status.RepoPath)
+	if !ok || !status.HasRemote {
+		return
+	}
+	status.AuthRequired = true
+	status.Symbol = "⚠"
+	status.Message = "Authentication required: " + reason.(string)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"net"
+)
+
+// offline is set by --offline, or at startup when no network interface is
+// up. Fetches, pulls, pushes, forge API calls and theme downloads are then
+// skipped instead of failing one by one, and ahead/behind counts are
+// flagged as possibly stale.
+var offline bool
+
+var errOffline = errors.New("offline, skipped")
+
+// offlineNote annotates the TUI header and reports while offline
+const offlineNote = "offline: ahead/behind as of the last fetch"
+
+// detectOffline reports whether no interface other than loopback is up
+// with a routable address. It doesn't touch the network, so it can't hang.
+func detectOffline() bool {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return false
+	}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.IsGlobalUnicast() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// offlineNotice answers a key that would contact a remote
+func (m model) offlineNotice() model {
+	m.notice = "Offline, not contacting remotes"
+	return m
+}
+
+// offlineLabel is the header annotation while offline
+func offlineLabel() string {
+	if !offline {
+		return ""
+	}
+	return "  ⚠ " + offlineNote
+}
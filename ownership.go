@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// onlyMine is --mine: scans leave out repos owned by other users or whose
+// .git this user can't write to, like system-level clones on shared machines
+var onlyMine bool
+
+// scanForeign counts the repos the last --mine scan left out
+var scanForeign atomic.Int64
+
+// skipForeign reports whether a scan should leave out the repo at repoPath
+func skipForeign(repoPath string) bool {
+	if !onlyMine || ownedAndWritable(filepath.Join(repoPath, ".git")) {
+		return false
+	}
+	scanForeign.Add(1)
+	return true
+}
+
+func foreignSummary(count int64) string {
+	if count == 1 {
+		return "1 repository skipped, owned by another user or read-only (--mine)"
+	}
+	return fmt.Sprintf("%d repositories skipped, owned by other users or read-only (--mine)", count)
+}
+
+// applyScanConfig turns on --mine when the config asks for it on every scan
+func applyScanConfig() {
+	if userConfig, err := loadConfig(); err == nil && userConfig.Filter.OnlyMine {
+		onlyMine = true
+	}
+}
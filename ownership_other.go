@@ -0,0 +1,12 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// ownedAndWritable reports whether path can be written to. There are no
+// uids to compare here, so ownership isn't checked.
+func ownedAndWritable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().Perm()&0200 != 0
+}
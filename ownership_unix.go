@@ -0,0 +1,21 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// ownedAndWritable reports whether path belongs to this user and they can
+// write to it
+func ownedAndWritable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return false
+	}
+	return info.Mode().Perm()&0200 != 0
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// pagerCommand picks the pager the way git does: $GIT_PAGER, then $PAGER,
+// then less -R so report colors survive. A pager set to "" or cat means no
+// paging and returns nil.
+func pagerCommand() []string {
+	for _, name := range []string{"GIT_PAGER", "PAGER"} {
+		if value, ok := os.LookupEnv(name); ok {
+			if pager := strings.Fields(value); len(pager) > 0 && pager[0] != "cat" {
+				return pager
+			}
+			return nil
+		}
+	}
+	return []string{"less", "-R"}
+}
+
+// terminalWidth is stdout's width in columns, or 0 when it isn't a terminal
+func terminalWidth() int {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// writePaged prints output, through the pager when stdout is a terminal and
+// the output would not fit on one screen
+func writePaged(output []byte) error {
+	if config.NoPager || !term.IsTerminal(int(os.Stdout.Fd())) {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+	_, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || bytes.Count(output, []byte("\n")) < height {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+
+	args := pagerCommand()
+	if args == nil {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(output)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Same defaults git uses: keep colors, don't clear the screen on exit
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Run(); err != nil {
+		// No usable pager: fall back to plain output
+		if _, ok := err.(*exec.ExitError); !ok {
+			_, err := os.Stdout.Write(output)
+			return err
+		}
+	}
+	return nil
+}
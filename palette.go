@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// paletteAction is one entry of the ":" command palette. Key is the binding
+// the action runs; actions without a binding of their own use a name like
+// "toggle:tree" instead.
+type paletteAction struct {
+	Title string
+	Key   string
+}
+
+var paletteActions = []paletteAction{
+	{"Move down", "j"},
+	{"Move up", "k"},
+	{"Show details", "enter"},
+	{"Close details / comparison", "esc"},
+	{"Search repos", "/"},
+	{"Cycle sort order", "s"},
+	{"Refresh", "r"},
+	{"Fetch selected repo", "f"},
+	{"Fetch all repos", "F"},
+	{"Pull selected repo (fast-forward only)", "p"},
+	{"Pin / unpin repo to the top of the list", "*"},
+	{"Pin for comparison", "="},
+	{"Compare with pinned", "c"},
+	{"Export changelog", "w"},
+	{"Show uncommitted changes", "d"},
+	{"Branches / check out a branch", "b"},
+	{"Stash uncommitted changes", "S"},
+	{"Pop the latest stash", "U"},
+	{"Reveal in file manager", "R"},
+	{"Open terminal", "T"},
+	{"Open in editor", "e"},
+	{"Open shell in repo", "!"},
+	{"Open origin in browser", "o"},
+	{"Create pull request for branch", "P"},
+	{"Open new pull request for branch", "open:pr"},
+	{"Open issues", "open:issues"},
+	{"Open latest pipeline for branch", "open:pipeline"},
+	{"Mark / unmark repo", " "},
+	{"Visual selection", "v"},
+	{"Hide selected repos", "H"},
+	{"Hide selected repos for good (config unhide undoes it)", "x"},
+	{"Show hidden repos", "show:hidden"},
+	{"Toggle matrix mode", "m"},
+	{"Toggle showing all repos, synced ones included", "a"},
+	{"Toggle off-default-branch filter", "toggle:off-default"},
+	{"Filter by branch name", "B"},
+	{"Toggle only repos with reviews or assigned issues", "toggle:triage"},
+	{"Toggle grouping by status", "toggle:groups"},
+	{"Toggle tree view", "toggle:tree"},
+	{"Toggle last commit author", "toggle:author"},
+	{"Toggle split view (details beside the list)", "|"},
+	{"Change scan root directory", "D"},
+	{"Collapse status section / directory", "z"},
+	{"Expand status section / directory", "l"},
+	{"Expand / collapse all sections or directories", "Z"},
+	{"Start / stop recording macro", "Q"},
+	{"Replay macro", "@"},
+	{"Page down", "pgdown"},
+	{"Page up", "pgup"},
+	{"Show all keys and symbols", "?"},
+	{"Open inbox", "i"},
+	{"Next status tab (1-5 jump to All/Dirty/Ahead/Behind/Errors)", "tab"},
+	{"Quit", "q"},
+}
+
+// keyAliases maps alternate bindings onto the key a macro records
+var keyAliases = map[string]string{
+	"down": "j",
+	"up":   "k",
+}
+
+// recordable reports whether key runs an action a macro can replay
+func recordable(key string) bool {
+	switch key {
+	case "q", "Q", "@", "/":
+		return false
+	}
+	for _, action := range paletteActions {
+		if action.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+type commandPalette struct {
+	query  string
+	cursor int
+}
+
+// fuzzyScore matches query as a case-insensitive subsequence of title.
+// Runs of consecutive letters and matches at word starts score higher.
+func fuzzyScore(query, title string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(title))
+	score, qi, prev := 0, 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == prev+1 {
+			score += 2
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) {
+			score += 3
+		}
+		prev = ti
+		qi++
+	}
+	return score, qi == len(q)
+}
+
+func (p commandPalette) matches() []paletteAction {
+	type scored struct {
+		action paletteAction
+		score  int
+	}
+	var found []scored
+	for _, action := range paletteActions {
+		if score, ok := fuzzyScore(p.query, action.Title); ok {
+			found = append(found, scored{action, score})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].score > found[j].score })
+	actions := make([]paletteAction, len(found))
+	for i, f := range found {
+		actions[i] = f.action
+	}
+	return actions
+}
+
+func (p commandPalette) view() string {
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1)
+	selectedStyle := lipgloss.NewStyle().Background(lipgloss.Color("238"))
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	var b strings.Builder
+	b.WriteString(": " + p.query + "█")
+	matches := p.matches()
+	if len(matches) == 0 {
+		b.WriteString("\n" + keyStyle.Render("no matching actions"))
+	}
+	for i, action := range matches {
+		key := action.Key
+		if len(key) > 1 && strings.Contains(key, ":") {
+			key = ""
+		}
+		line := fmt.Sprintf("%-36s %s", action.Title, keyStyle.Render(key))
+		if i == p.cursor {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString("\n" + line)
+	}
+	return boxStyle.Render(b.String())
+}
+
+// keyMsg builds the key press that triggers a bound action
+func keyMsg(key string) tea.KeyMsg {
+	switch key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "pgup":
+		return tea.KeyMsg{Type: tea.KeyPgUp}
+	case "pgdown":
+		return tea.KeyMsg{Type: tea.KeyPgDown}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(key)}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func (m model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	matches := m.palette.matches()
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.palette = nil
+	case "enter":
+		if cursor := m.palette.cursor; cursor < len(matches) {
+			m.palette = nil
+			return m.runAction(matches[cursor].Key)
+		}
+	case "up", "ctrl+p":
+		if m.palette.cursor > 0 {
+			m.palette.cursor--
+		}
+	case "down", "ctrl+n":
+		if m.palette.cursor < len(matches)-1 {
+			m.palette.cursor++
+		}
+	case "backspace":
+		if q := []rune(m.palette.query); len(q) > 0 {
+			m.palette.query = string(q[:len(q)-1])
+			m.palette.cursor = 0
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			m.palette.query += string(msg.Runes)
+			m.palette.cursor = 0
+		}
+	}
+	return m, nil
+}
+
+// runAction runs a palette action, recording it if a macro is being recorded
+func (m model) runAction(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "toggle:off-default":
+		if m.recording {
+			m.macro = append(m.macro, key)
+		}
+		m.config.OffDefault = !m.config.OffDefault
+		m.notice = fmt.Sprintf("Only repos off their default branch: %t", m.config.OffDefault)
+		return m.refilter(), nil
+	case "open:pr", "open:issues", "open:pipeline":
+		if m.recording {
+			m.macro = append(m.macro, key)
+		}
+		if m.cursor >= len(m.repos) {
+			return m, nil
+		}
+		return m, browseRepoCmd(m.repos[m.cursor], strings.TrimPrefix(key, "open:"))
+	case "toggle:triage":
+		if m.recording {
+			m.macro = append(m.macro, key)
+		}
+		m.triageOnly = !m.triageOnly
+		m.notice = fmt.Sprintf("Only repos with reviews or assigned issues: %t", m.triageOnly)
+		return m.applySearch(), nil
+	case "toggle:tree":
+		if m.recording {
+			m.macro = append(m.macro, key)
+		}
+		m.treeView = !m.treeView
+		m.notice = fmt.Sprintf("Tree view: %t", m.treeView)
+		return m.applySearch(), nil
+	case "toggle:author":
+		if m.recording {
+			m.macro = append(m.macro, key)
+		}
+		m.showAuthor = !m.showAuthor
+		m.notice = fmt.Sprintf("Last commit author: %t", m.showAuthor)
+		return m, nil
+	case "toggle:groups":
+		if m.recording {
+			m.macro = append(m.macro, key)
+		}
+		m.grouping = !m.grouping
+		m.notice = fmt.Sprintf("Grouping by status: %t", m.grouping)
+		return m.applySearch(), nil
+	case "show:hidden":
+		if m.recording {
+			m.macro = append(m.macro, key)
+		}
+		m.notice = fmt.Sprintf("Showing %d hidden repositories again", len(m.hidden))
+		m.hidden = make(map[string]bool)
+		return m.applySearch(), nil
+	}
+	return m.Update(keyMsg(key))
+}
+
+func (m model) toggleRecording() model {
+	if m.recording {
+		m.recording = false
+		m.notice = fmt.Sprintf("Recorded macro of %d action(s), press @ to replay it", len(m.macro))
+	} else {
+		m.recording = true
+		m.macro = nil
+		m.notice = "● Recording macro, press Q to stop"
+	}
+	return m
+}
+
+// playMacro replays the recorded actions against the current selection
+func (m model) playMacro() (tea.Model, tea.Cmd) {
+	if m.recording {
+		m.notice = "Stop recording with Q before replaying"
+		return m, nil
+	}
+	if len(m.macro) == 0 {
+		m.notice = "No macro recorded, start one with Q"
+		return m, nil
+	}
+	var cmds []tea.Cmd
+	var next tea.Model = m
+	for _, key := range m.macro {
+		var cmd tea.Cmd
+		next, cmd = next.(model).runAction(key)
+		cmds = append(cmds, cmd)
+	}
+	return next, tea.Batch(cmds...)
+}
@@ -2,9 +2,7 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -65,7 +63,9 @@ func (wp *WorkerPool) worker(id int) {
 			}
 			
 			// Process the git status
+			scanProgress.emit("scanning", job.RepoPath)
 			status := getGitStatusOptimized(job.RepoPath, job.BaseDir)
+			scanProgress.emit("scanned", job.RepoPath)
 			
 			select {
 			case wp.results <- status:
@@ -117,7 +117,7 @@ func getGitStatusOptimized(repoPath, baseDir string) GitStatus {
 	defer cancel()
 
 	// Use faster git commands where possible
-	statusCmd := exec.CommandContext(ctx, "git", "-C", repoPath, "status", "--porcelain", "--untracked-files=no")
+	statusCmd := gitCommand(ctx, "-C", repoPath, "status", "--porcelain", "--untracked-files=no")
 	statusOut, err := statusCmd.Output()
 	if err != nil {
 		return status
@@ -129,6 +129,10 @@ func getGitStatusOptimized(repoPath, baseDir string) GitStatus {
 		behind  string
 		branch  string
 		commit  string
+		author  string
+		commitAt time.Time
+		defaultBranch string
+		hasRemote bool
 	}
 
 	resultChan := make(chan gitResult, 1)
@@ -138,33 +142,45 @@ func getGitStatusOptimized(repoPath, baseDir string) GitStatus {
 		var wg sync.WaitGroup
 		
 		// Execute git commands in parallel
-		wg.Add(4)
+		wg.Add(6)
 		
 		go func() {
 			defer wg.Done()
-			if out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-list", "--count", "@{u}..HEAD").Output(); err == nil {
+			if out, err := gitCommand(ctx, "-C", repoPath, "rev-list", "--count", "@{u}..HEAD").Output(); err == nil {
 				result.ahead = strings.TrimSpace(string(out))
 			}
 		}()
 		
 		go func() {
 			defer wg.Done()
-			if out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-list", "--count", "HEAD..@{u}").Output(); err == nil {
+			if out, err := gitCommand(ctx, "-C", repoPath, "rev-list", "--count", "HEAD..@{u}").Output(); err == nil {
 				result.behind = strings.TrimSpace(string(out))
 			}
 		}()
 		
 		go func() {
 			defer wg.Done()
-			if out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-parse", "--abbrev-ref", "HEAD").Output(); err == nil {
+			if out, err := gitCommand(ctx, "-C", repoPath, "rev-parse", "--abbrev-ref", "HEAD").Output(); err == nil {
 				result.branch = strings.TrimSpace(string(out))
 			}
 		}()
 		
 		go func() {
 			defer wg.Done()
-			if out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "log", "-1", "--pretty=%h %cr %an").Output(); err == nil {
-				result.commit = strings.TrimSpace(string(out))
+			if out, err := gitCommand(ctx, "-C", repoPath, "log", "-1", "--pretty="+lastCommitFormat).Output(); err == nil {
+				result.commit, result.author, result.commitAt = parseLastCommit(string(out))
+			}
+		}()
+		
+		go func() {
+			defer wg.Done()
+			result.defaultBranch = detectDefaultBranch(ctx, repoPath)
+		}()
+		
+		go func() {
+			defer wg.Done()
+			if out, err := gitCommand(ctx, "-C", repoPath, "remote").Output(); err == nil {
+				result.hasRemote = strings.TrimSpace(string(out)) != ""
 			}
 		}()
 		
@@ -177,25 +193,13 @@ func getGitStatusOptimized(repoPath, baseDir string) GitStatus {
 	case result := <-resultChan:
 		status.Branch = result.branch
 		status.LastCommit = result.commit
+		status.LastCommitAt = result.commitAt
+		status.LastAuthor = result.author
 		
-		statusStr := strings.TrimSpace(string(statusOut))
-		
-		if statusStr == "" && result.ahead == "0" && result.behind == "0" {
-			status.Symbol = "✓"
-			status.Message = "Up to date"
-		} else if result.ahead != "0" && result.behind != "0" {
-			status.Symbol = "↕"
-			status.Message = fmt.Sprintf("Diverged (%s ahead, %s behind)", result.ahead, result.behind)
-		} else if result.ahead != "0" {
-			status.Symbol = "↑"
-			status.Message = fmt.Sprintf("%s commit(s) to push", result.ahead)
-		} else if result.behind != "0" {
-			status.Symbol = "↓"
-			status.Message = fmt.Sprintf("%s commit(s) to pull", result.behind)
-		} else {
-			status.Symbol = "✗"
-			status.Message = "Uncommitted changes"
-		}
+		status.DefaultBranch = result.defaultBranch
+		status.HasRemote = result.hasRemote
+		classifyStatus(&status, string(statusOut), result.ahead, result.behind)
+		addDiffstat(ctx, &status)
 		
 	case <-ctx.Done():
 		status.Symbol = "⚠"
@@ -207,9 +211,14 @@ func getGitStatusOptimized(repoPath, baseDir string) GitStatus {
 
 // Enhanced repo discovery with smarter filtering
 func findGitReposOptimized(baseDir string, maxDepth int) []GitStatus {
+	if config.Subdirs {
+		return scanSubdirs(baseDir)
+	}
 	// First pass: collect all repo paths
 	var repoPaths []string
 	repoPathsChan := make(chan string, 100)
+	scanUnreadable.reset()
+	scanForeign.Store(0)
 	
 	go func() {
 		defer close(repoPathsChan)
@@ -218,9 +227,11 @@ func findGitReposOptimized(baseDir string, maxDepth int) []GitStatus {
 	
 	for repoPath := range repoPathsChan {
 		repoPaths = append(repoPaths, repoPath)
+		scanProgress.emit("discovered", repoPath)
 	}
 
 	if len(repoPaths) == 0 {
+		scanProgress.emit("done", "")
 		return []GitStatus{}
 	}
 
@@ -253,6 +264,8 @@ func findGitReposOptimized(baseDir string, maxDepth int) []GitStatus {
 		}
 	}
 
+	scanProgress.emit("done", "")
+
 	// Sort by modification time (newest first)
 	sort.Slice(repos, func(i, j int) bool {
 		return repos[i].ModTime.After(repos[j].ModTime)
@@ -268,13 +281,16 @@ func walkReposOptimized(currentPath, baseDir string, currentDepth, maxDepth int,
 
 	entries, err := os.ReadDir(currentPath)
 	if err != nil {
+		scanUnreadable.add(currentPath, err)
 		return
 	}
 
 	// Check if current directory is a git repo
 	for _, entry := range entries {
 		if entry.IsDir() && entry.Name() == ".git" {
-			repoPaths <- currentPath
+			if !skipForeign(currentPath) {
+				repoPaths <- currentPath
+			}
 			return // Don't recurse into .git directory
 		}
 	}
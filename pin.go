@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// configRepoPath is how a repo is listed in the config's "pinned" and
+// "hidden" lists: its absolute path
+func configRepoPath(repoPath string) string {
+	if abs, err := filepath.Abs(repoPath); err == nil {
+		return abs
+	}
+	return filepath.Clean(repoPath)
+}
+
+// loadPinned reads the pinned repos from the config
+func loadPinned() map[string]bool {
+	pinned := map[string]bool{}
+	if userConfig, err := loadConfig(); err == nil {
+		for _, path := range userConfig.Pinned {
+			pinned[filepath.Clean(path)] = true
+		}
+	}
+	return pinned
+}
+
+// pinnedFirst moves the pinned repos to the top of an already sorted list,
+// keeping the sort order within both parts
+func pinnedFirst(repos []GitStatus, pinned map[string]bool) {
+	if len(pinned) == 0 {
+		return
+	}
+	isPinned := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		isPinned[repo.RepoPath] = pinned[configRepoPath(repo.RepoPath)]
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		return isPinned[repos[i].RepoPath] && !isPinned[repos[j].RepoPath]
+	})
+}
+
+// togglePin pins the highlighted repo to the top of the list, or unpins it,
+// and saves the pins to the config
+func (m model) togglePin() model {
+	if m.cursor >= len(m.repos) {
+		return m
+	}
+	repo := m.repos[m.cursor]
+	userConfig, err := loadConfig()
+	if err != nil {
+		m.notice = fmt.Sprintf("✗ Couldn't load the config: %v", err)
+		return m
+	}
+	path := configRepoPath(repo.RepoPath)
+	if m.pinned[path] {
+		userConfig.Pinned = slices.DeleteFunc(userConfig.Pinned, func(p string) bool { return filepath.Clean(p) == path })
+	} else {
+		userConfig.Pinned = append(userConfig.Pinned, path)
+	}
+	if err := saveConfig(userConfig); err != nil {
+		m.notice = fmt.Sprintf("✗ Couldn't save the pin: %v", err)
+		return m
+	}
+
+	if m.pinned[path] {
+		delete(m.pinned, path)
+		m.notice = fmt.Sprintf("Unpinned %s", displayName(repo))
+	} else {
+		m.pinned[path] = true
+		m.notice = fmt.Sprintf("Pinned %s to the top", displayName(repo))
+	}
+	return m.refilter()
+}
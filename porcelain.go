@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Porcelain v1 is a stable, machine-readable report format. It will not
+// change between releases; a new layout would get a new version instead.
+//
+// One line per repository, fields separated by a single tab:
+//
+//	<status> <path> <branch> <default-branch> <ahead> <behind> <dirty>
+//
+//	status          clean, dirty, ahead, behind, diverged, no-remote, no-upstream, auth-required, remote-gone, integrity or error
+//	path            repository path relative to the scanned directory ("." for the root)
+//	branch          checked-out branch ("HEAD" when detached), "-" if unknown
+//	default-branch  remote default branch, "-" if unknown
+//	ahead, behind   commit counts against the upstream, "-" without an upstream
+//	dirty           1 if tracked files have uncommitted changes, else 0
+//
+// Lines are sorted by path. There is no header line and no color. Filters (--all, --off-default) apply
+// as they do for the regular report.
+const porcelainV1 = "v1"
+
+// statusKey is the stable name for a repo's state, independent of symbols
+// and themes
+func (s GitStatus) statusKey() string {
+	switch {
+	case s.Corrupt:
+		return "integrity"
+	case s.RemoteGone:
+		return "remote-gone"
+	case s.AuthRequired:
+		return "auth-required"
+	case s.Symbol == "⚠":
+		return "error"
+	case !s.HasRemote:
+		return "no-remote"
+	case !s.HasUpstream:
+		return "no-upstream"
+	case s.Ahead > 0 && s.Behind > 0:
+		return "diverged"
+	case s.Ahead > 0:
+		return "ahead"
+	case s.Behind > 0:
+		return "behind"
+	case s.Dirty:
+		return "dirty"
+	default:
+		return "clean"
+	}
+}
+
+// statusKeys lists every statusKey value in display order
+var statusKeys = []string{"clean", "dirty", "ahead", "behind", "diverged", "no-remote", "no-upstream", "auth-required", "remote-gone", "integrity", "error"}
+
+// filterStates are the names --only and --exclude accept. They follow the
+// filter.show_* config settings; "synced" is the same as "clean".
+var filterStates = []string{"clean", "synced", "dirty", "ahead", "behind", "diverged", "no-remote", "no-upstream", "auth-required", "remote-gone", "integrity", "error"}
+
+func validateStates(states []string) error {
+	for _, state := range states {
+		found := false
+		for _, known := range filterStates {
+			found = found || state == known
+		}
+		if !found {
+			return fmt.Errorf("unknown state %q (available: %s)", state, strings.Join(filterStates, ", "))
+		}
+	}
+	return nil
+}
+
+// repoInStates reports whether the repo is in any of the states. Unlike
+// statusKey, a repo can be in several: dirty and ahead, say.
+func repoInStates(repo GitStatus, states []string) bool {
+	for _, state := range states {
+		switch state {
+		case "clean", "synced":
+			if repo.statusKey() == "clean" {
+				return true
+			}
+		case "dirty":
+			if repo.Dirty {
+				return true
+			}
+		case "ahead":
+			if repo.Ahead > 0 {
+				return true
+			}
+		case "behind":
+			if repo.Behind > 0 {
+				return true
+			}
+		default:
+			if repo.statusKey() == state {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quietSummary counts repos per status on one line, for shell prompts
+func quietSummary(repos []GitStatus) string {
+	counts := make(map[string]int)
+	for _, repo := range repos {
+		counts[repo.statusKey()]++
+	}
+	fields := make([]string, len(statusKeys))
+	for i, key := range statusKeys {
+		fields[i] = fmt.Sprintf("%s:%d", key, counts[key])
+	}
+	return strings.Join(fields, " ")
+}
+
+func porcelainField(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func writePorcelain(w io.Writer, version string, repos []GitStatus) error {
+	if version != porcelainV1 {
+		return fmt.Errorf("unsupported porcelain version %q (supported: %s)", version, porcelainV1)
+	}
+
+	sorted := append([]GitStatus(nil), repos...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].RelativePath < sorted[j].RelativePath
+	})
+
+	for _, repo := range sorted {
+		path := repo.RelativePath
+		if path == "" {
+			path = "."
+		}
+		ahead, behind := "-", "-"
+		if repo.HasUpstream {
+			ahead, behind = strconv.Itoa(repo.Ahead), strconv.Itoa(repo.Behind)
+		}
+		dirty := "0"
+		if repo.Dirty {
+			dirty = "1"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			repo.statusKey(), path, porcelainField(repo.Branch),
+			porcelainField(repo.DefaultBranch), ahead, behind, dirty)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prPrompt asks for the title of a pull request about to be opened
+type prPrompt struct {
+	repo  GitStatus
+	title string
+}
+
+// createPullRequest opens a pull request (GitHub) or merge request (GitLab)
+// from head into base and returns its web URL. When one is already open for
+// head, that one is returned instead.
+func createPullRequest(f ForgeConfig, project, head, base, title string) (string, error) {
+	if f.Type == "gitlab" {
+		var mr struct {
+			WebURL string `json:"web_url"`
+		}
+		body := map[string]string{"source_branch": head, "target_branch": base, "title": title}
+		err := forgeRequest(f, "POST", "/projects/"+url.PathEscape(project)+"/merge_requests", body, &mr)
+		if err != nil && strings.Contains(err.Error(), "already exists") {
+			var open []struct {
+				WebURL string `json:"web_url"`
+			}
+			query := url.Values{"source_branch": {head}, "state": {"opened"}}.Encode()
+			if forgeRequest(f, "GET", "/projects/"+url.PathEscape(project)+"/merge_requests?"+query, nil, &open) == nil && len(open) > 0 {
+				return open[0].WebURL, nil
+			}
+		}
+		return mr.WebURL, err
+	}
+
+	var pr struct {
+		HTMLURL string `json:"html_url"`
+	}
+	body := map[string]string{"head": head, "base": base, "title": title}
+	err := forgeRequest(f, "POST", "/repos/"+project+"/pulls", body, &pr)
+	if err != nil && strings.Contains(err.Error(), "already exists") {
+		var open []struct {
+			HTMLURL string `json:"html_url"`
+		}
+		owner, _, _ := strings.Cut(project, "/")
+		query := url.Values{"head": {owner + ":" + head}, "state": {"open"}}.Encode()
+		if forgeRequest(f, "GET", "/repos/"+project+"/pulls?"+query, nil, &open) == nil && len(open) > 0 {
+			return open[0].HTMLURL, nil
+		}
+	}
+	return pr.HTMLURL, err
+}
+
+// createPullRequestCmd pushes the repo's branch to origin and opens a pull
+// request for it against the default branch
+func createPullRequestCmd(repo GitStatus, title, baseDir string) tea.Cmd {
+	return func() tea.Msg {
+		notice := func() string {
+			origin, err := runGit(repo.RepoPath, "remote", "get-url", "origin")
+			if err != nil {
+				return fmt.Sprintf("✗ %s has no origin remote", displayName(repo))
+			}
+			forge, project, err := forgeForRemote(origin)
+			if err != nil {
+				return fmt.Sprintf("✗ Can't open a pull request: %v", err)
+			}
+			if _, err := runGit(repo.RepoPath, "push", "-u", "origin", repo.Branch); err != nil {
+				return fmt.Sprintf("✗ Push failed for %s: %v", displayName(repo), err)
+			}
+			link, err := createPullRequest(forge, project, repo.Branch, repo.DefaultBranch, title)
+			if err != nil {
+				return fmt.Sprintf("✗ Pushed %s, but the pull request failed: %v", repo.Branch, err)
+			}
+			return fmt.Sprintf("✓ Pull request for %s: %s", repo.Branch, link)
+		}()
+		return repoStatusMsg{status: getGitStatus(repo.RepoPath, baseDir, nil), notice: notice}
+	}
+}
+
+// startPullRequest opens the title prompt for the selected repo, which has
+// to be on a feature branch
+func (m model) startPullRequest() model {
+	if m.cursor >= len(m.repos) {
+		return m
+	}
+	repo := m.repos[m.cursor]
+	switch {
+	case m.busy[repo.RepoPath]:
+	case !repo.HasRemote:
+		m.notice = fmt.Sprintf("✗ %s has no remote to open a pull request on", displayName(repo))
+	case !repo.OffDefaultBranch():
+		m.notice = fmt.Sprintf("✗ %s is on its default branch, pull requests are opened from feature branches", displayName(repo))
+	case isProtected(repo.RepoPath):
+		m.notice = fmt.Sprintf("✗ %s is %s", displayName(repo), protectedMessage)
+	default:
+		subject, _ := runGit(repo.RepoPath, "log", "-1", "--format=%s")
+		m.prPrompt = &prPrompt{repo: repo, title: subject}
+	}
+	return m
+}
+
+// updatePullRequest handles keys while the title prompt is open
+func (m model) updatePullRequest(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.prPrompt = nil
+	case "enter":
+		prompt := m.prPrompt
+		m.prPrompt = nil
+		if strings.TrimSpace(prompt.title) == "" {
+			m.notice = "✗ A pull request needs a title"
+			return m, nil
+		}
+		m.busy[prompt.repo.RepoPath] = true
+		m.notice = fmt.Sprintf("Pushing %s and opening a pull request...", prompt.repo.Branch)
+		return m, createPullRequestCmd(prompt.repo, strings.TrimSpace(prompt.title), m.baseDir)
+	case "ctrl+u":
+		m.prPrompt.title = ""
+	case "backspace":
+		if title := []rune(m.prPrompt.title); len(title) > 0 {
+			m.prPrompt.title = string(title[:len(title)-1])
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			m.prPrompt.title += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostCheck is one host a workspace's origins point at, checked once
+// before a bulk fetch or pull
+type hostCheck struct {
+	Name   string           // "ssh github.com" or "https github.com"
+	Repos  []string         // repo paths whose origin is on it
+	Err    error            // why the host is down, nil when any repo on it works
+	Failed map[string]error // repos whose own probe failed on a working host
+}
+
+// hostFailureLimit is how many repos on a host have to fail their probe
+// before the whole host counts as down. One repo with a broken remote or
+// no access shouldn't take the others with it.
+const hostFailureLimit = 3
+
+// probeHost probes the host's repos in turn until one gets through, or
+// hostFailureLimit of them (or all, if fewer) have failed
+func probeHost(host *hostCheck) {
+	failed := map[string]error{}
+	for _, repoPath := range host.Repos {
+		err := probeRemote(repoPath)
+		if err == nil {
+			if len(failed) > 0 {
+				host.Failed = failed
+			}
+			return
+		}
+		failed[repoPath] = err
+		if len(failed) >= hostFailureLimit {
+			host.Err = err
+			return
+		}
+	}
+	host.Err = failed[host.Repos[len(host.Repos)-1]]
+}
+
+// remoteHostName keys a remote URL by transport and host. Local paths
+// and file:// remotes have no host and can't prompt, so they give "".
+func remoteHostName(remote string) string {
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" {
+		switch u.Scheme {
+		case "http", "https":
+			return "https " + strings.ToLower(u.Hostname())
+		case "ssh", "git+ssh", "ssh+git":
+			return "ssh " + strings.ToLower(u.Hostname())
+		}
+		return ""
+	}
+	if h, _, ok := strings.Cut(remote, ":"); ok && !strings.Contains(h, "/") {
+		if _, host, ok := strings.Cut(h, "@"); ok {
+			h = host
+		}
+		return "ssh " + strings.ToLower(h)
+	}
+	return ""
+}
+
+// probeRemote asks origin for its HEAD the way fetch would. Like every git
+// command here it can't prompt, so a host that wants a password or an
+// unloaded key fails fast.
+func probeRemote(repoPath string) error {
+	if offline {
+		return errOffline
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	out, err := gitCommand(ctx, "-C", repoPath, "ls-remote", "origin", "HEAD").CombinedOutput()
+	noteRemoteResult(repoPath, string(out), err)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("timed out")
+	}
+	// the first line says why: "Permission denied (publickey)", "could not
+	// read Username", ...
+	if reason := authFailure(string(out)); reason != "" {
+		return fmt.Errorf("%s", reason)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "warning:") {
+			return fmt.Errorf("%s", strings.TrimPrefix(line, "fatal: "))
+		}
+	}
+	return err
+}
+
+// checkRemoteHosts groups repos by origin host and probes the hosts in
+// parallel
+func checkRemoteHosts(repoPaths []string) []hostCheck {
+	byName := map[string]*hostCheck{}
+	var hosts []*hostCheck
+	for _, repoPath := range repoPaths {
+		origin, err := runGit(repoPath, "remote", "get-url", "origin")
+		if err != nil {
+			continue
+		}
+		name := remoteHostName(origin)
+		if name == "" {
+			continue
+		}
+		if byName[name] == nil {
+			byName[name] = &hostCheck{Name: name}
+			hosts = append(hosts, byName[name])
+		}
+		byName[name].Repos = append(byName[name].Repos, repoPath)
+	}
+
+	semaphore := make(chan struct{}, min(runtime.NumCPU()*2, 16))
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host *hostCheck) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			probeHost(host)
+		}(host)
+	}
+	wg.Wait()
+
+	result := make([]hostCheck, len(hosts))
+	for i, host := range hosts {
+		result[i] = *host
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// unreachableRepos maps every repo on a failing host to that host's error,
+// and every repo that failed its own probe to its error
+func unreachableRepos(hosts []hostCheck) map[string]error {
+	blocked := map[string]error{}
+	for _, host := range hosts {
+		for repoPath, err := range host.Failed {
+			blocked[repoPath] = fmt.Errorf("can't reach or log in to origin: %v", err)
+		}
+		if host.Err == nil {
+			continue
+		}
+		for _, repoPath := range host.Repos {
+			blocked[repoPath] = fmt.Errorf("can't reach or log in to %s: %v", host.Name, host.Err)
+		}
+	}
+	return blocked
+}
+
+// failingHostNames lists the hosts that failed, for notices
+func failingHostNames(hosts []hostCheck) []string {
+	var names []string
+	for _, host := range hosts {
+		if host.Err != nil {
+			names = append(names, host.Name)
+		}
+	}
+	return names
+}
+
+// runAuthCheck reports, per remote host in the workspace, whether fetching
+// from it would work without a prompt
+func runAuthCheck(baseDir string) bool {
+	repos := findGitReposOptimized(baseDir, config.Depth)
+	repoPaths := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		if repo.HasRemote {
+			repoPaths = append(repoPaths, repo.RepoPath)
+		}
+	}
+
+	hosts := checkRemoteHosts(repoPaths)
+	if len(hosts) == 0 {
+		fmt.Println("No remote hosts to check")
+		return true
+	}
+	ok := true
+	for _, host := range hosts {
+		if host.Err != nil {
+			fmt.Printf("✗ %-30s %d repositories: %v\n", host.Name, len(host.Repos), host.Err)
+			ok = false
+			continue
+		}
+		fmt.Printf("✓ %-30s %d repositories\n", host.Name, len(host.Repos))
+		failed := make([]string, 0, len(host.Failed))
+		for repoPath := range host.Failed {
+			failed = append(failed, repoPath)
+		}
+		sort.Strings(failed)
+		for _, repoPath := range failed {
+			fmt.Printf("  ✗ %s: %v\n", repoPath, host.Failed[repoPath])
+			ok = false
+		}
+	}
+	return ok
+}
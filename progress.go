@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// progressEvent is one line of --progress json output. Events are
+// "discovered" (a repo was found), "scanning" (a repo's status is being
+// read), "scanned" (it is done) and a final "done".
+type progressEvent struct {
+	SchemaVersion int    `json:"schema_version"`
+	Version       string `json:"version"`
+	Event         string `json:"event"`
+	Repo          string `json:"repo,omitempty"`
+	Discovered    int    `json:"discovered"`
+	Scanned       int    `json:"scanned"`
+	ElapsedMS     int64  `json:"elapsed_ms"`
+}
+
+// progressReporter writes scan progress as JSON lines. A nil reporter
+// does nothing, so scanning code can call it unconditionally.
+type progressReporter struct {
+	mu         sync.Mutex
+	enc        *json.Encoder
+	baseDir    string
+	start      time.Time
+	discovered int
+	scanned    int
+}
+
+// scanProgress is set when --progress is given
+var scanProgress *progressReporter
+
+func newProgressReporter(w io.Writer, format, baseDir string) (*progressReporter, error) {
+	if format != "json" {
+		return nil, fmt.Errorf("unsupported progress format %q (supported: json)", format)
+	}
+	return &progressReporter{enc: json.NewEncoder(w), baseDir: baseDir, start: time.Now()}, nil
+}
+
+func (p *progressReporter) emit(event, repoPath string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch event {
+	case "discovered":
+		p.discovered++
+	case "scanned":
+		p.scanned++
+	}
+
+	rel := ""
+	if repoPath != "" {
+		rel, _ = filepath.Rel(p.baseDir, repoPath)
+	}
+	p.enc.Encode(progressEvent{
+		SchemaVersion: schemaVersion(),
+		Version:       version,
+		Event:         event,
+		Repo:          rel,
+		Discovered:    p.discovered,
+		Scanned:       p.scanned,
+		ElapsedMS:     time.Since(p.start).Milliseconds(),
+	})
+}
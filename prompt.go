@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// prompt asks a question on the terminal and returns the answer, or def when
+// the user just presses enter
+func prompt(question, def string) string {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", question, def)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+
+	answer, _ := stdinReader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return def
+	}
+	return answer
+}
+
+// confirm asks a yes/no question, defaulting to no
+func confirm(question string) bool {
+	answer := strings.ToLower(prompt(question+" (y/N)", ""))
+	return answer == "y" || answer == "yes"
+}
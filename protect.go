@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// protectedMessage is what mutating commands report for a protected repo
+const protectedMessage = "protected (remove it from the protected list to change it)"
+
+var loadedProtected map[string]bool
+
+// isProtected reports whether repoPath is listed under "protected" in the
+// config. Nothing in this tool may change a protected repo.
+func isProtected(repoPath string) bool {
+	if loadedProtected == nil {
+		loadedProtected = map[string]bool{}
+		if userConfig, err := loadConfig(); err == nil {
+			for _, path := range userConfig.Protected {
+				loadedProtected[filepath.Clean(path)] = true
+			}
+		}
+	}
+	if abs, err := filepath.Abs(repoPath); err == nil {
+		repoPath = abs
+	}
+	return loadedProtected[filepath.Clean(repoPath)]
+}
+
+// configPaths turns a comma-separated list of paths for "config set" into
+// absolute ones, so the list doesn't depend on where it was set from
+func configPaths(value string) ([]string, error) {
+	var paths []string
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		if path == "~" || strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, abs)
+	}
+	return paths, nil
+}
+
+func protectedError(repoPath string) error {
+	return fmt.Errorf("%s is %s", repoPath, protectedMessage)
+}
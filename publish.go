@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type publishOptions struct {
+	Forge  string
+	Yes    bool
+	Public bool
+}
+
+// runPublish walks the repos that have no remote at all, creates a matching
+// repository on the forge, wires it up as origin and pushes everything.
+func runPublish(baseDir string, opts publishOptions) error {
+	forge, err := selectForge(opts.Forge)
+	if err != nil {
+		return err
+	}
+
+	var localOnly []GitStatus
+	for _, repo := range findGitReposOptimized(baseDir, config.Depth) {
+		if repo.Symbol != "⚠" && !repo.Corrupt && !repo.HasRemote {
+			localOnly = append(localOnly, repo)
+		}
+	}
+
+	if len(localOnly) == 0 {
+		fmt.Println("Every repository already has a remote.")
+		return nil
+	}
+	fmt.Printf("Found %d repositories without a remote\n\n", len(localOnly))
+
+	published := 0
+	for _, repo := range localOnly {
+		if isProtected(repo.RepoPath) {
+			fmt.Printf("- %s skipped: %s\n", repo.RepoPath, protectedMessage)
+			continue
+		}
+		name := filepath.Base(repo.RepoPath)
+		visibility := "private"
+		if opts.Public {
+			visibility = "public"
+		}
+
+		if !opts.Yes {
+			if !confirm(fmt.Sprintf("Publish %s to %s?", repo.RepoPath, forge.host())) {
+				continue
+			}
+			name = prompt("  Repository name", name)
+			visibility = prompt("  Visibility (private/public)", visibility)
+		}
+
+		if err := publishRepo(forge, repo, name, strings.ToLower(visibility) != "public"); err != nil {
+			fmt.Printf("✗ %s: %v\n", repo.RepoPath, err)
+			continue
+		}
+		published++
+	}
+
+	fmt.Printf("\nPublished %d of %d repositories\n", published, len(localOnly))
+	return nil
+}
+
+func publishRepo(forge ForgeConfig, repo GitStatus, name string, private bool) error {
+	remote, err := createForgeRepo(forge, name, private, "")
+	if err != nil {
+		return err
+	}
+	if _, err := runGit(repo.RepoPath, "remote", "add", "origin", forge.remoteURL(remote)); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Created %s\n", remote.WebURL)
+
+	if _, err := runGit(repo.RepoPath, "rev-parse", "--verify", "HEAD"); err != nil {
+		fmt.Println("  Nothing committed yet, skipping push")
+		return nil
+	}
+	if _, err := runGitLong(repo.RepoPath, "push", "-u", "origin", "--all"); err != nil {
+		return fmt.Errorf("pushing branches: %v", err)
+	}
+	if _, err := runGitLong(repo.RepoPath, "push", "origin", "--tags"); err != nil {
+		return fmt.Errorf("pushing tags: %v", err)
+	}
+	fmt.Println("  Pushed all branches and tags")
+	return nil
+}
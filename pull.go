@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pullRepoCmd fast-forwards a repo that is behind its upstream, then
+// rescans it. Anything that can't fast-forward is left alone.
+func pullRepoCmd(repo GitStatus, baseDir string) tea.Cmd {
+	return func() tea.Msg {
+		notice := fmt.Sprintf("✓ Pulled %s", displayName(repo))
+		if _, err := runGit(repo.RepoPath, "pull", "--ff-only"); err != nil {
+			reason := err.Error()
+			if strings.Contains(reason, "Not possible to fast-forward") || strings.Contains(reason, "diverg") {
+				reason = "can't fast-forward, the branches have diverged"
+			} else if lines := strings.Split(reason, "\n"); len(lines) > 1 {
+				reason = lines[len(lines)-1]
+			}
+			notice = fmt.Sprintf("✗ Pull failed for %s: %s", displayName(repo), reason)
+		}
+		return repoStatusMsg{status: getGitStatus(repo.RepoPath, baseDir, nil), notice: notice}
+	}
+}
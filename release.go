@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type releaseOptions struct {
+	Version      string
+	Message      string
+	Sign         bool
+	ForgeRelease bool
+	Forge        string
+	Yes          bool
+}
+
+// nextPatchVersion suggests the version after tag: v1.2.3 becomes v1.2.4.
+// Tags that aren't semver get no suggestion.
+func nextPatchVersion(tag string) string {
+	if tag == "" {
+		return "v0.1.0"
+	}
+	prefix := ""
+	version := tag
+	if strings.HasPrefix(version, "v") {
+		prefix, version = "v", version[1:]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	patch, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return ""
+	}
+	parts[2] = strconv.Itoa(patch + 1)
+	return prefix + strings.Join(parts, ".")
+}
+
+// releaseBlocker explains why a repo shouldn't be tagged right now: the tag
+// must point at a commit that is already on the remote
+func releaseBlocker(repo GitStatus) string {
+	switch {
+	case isProtected(repo.RepoPath):
+		return protectedMessage
+	case repo.Symbol == "⚠" || repo.Corrupt:
+		return repo.Message
+	case repo.Dirty:
+		return "uncommitted changes"
+	case repo.Branch == "HEAD":
+		return "detached HEAD"
+	case !repo.HasRemote:
+		return "no remote configured"
+	case !repo.HasUpstream:
+		return "no upstream branch"
+	case repo.Ahead > 0:
+		return fmt.Sprintf("%d unpushed commit(s), push first", repo.Ahead)
+	case repo.Behind > 0:
+		return fmt.Sprintf("%d commit(s) behind upstream, pull first", repo.Behind)
+	}
+	return ""
+}
+
+// runRelease tags each repo with a prompted version, pushes the tag and
+// optionally creates a forge release with the changelog as notes
+func runRelease(paths []string, opts releaseOptions) error {
+	var forge ForgeConfig
+	if opts.ForgeRelease {
+		var err error
+		if forge, err = selectForge(opts.Forge); err != nil {
+			return err
+		}
+	}
+
+	released := 0
+	for _, path := range paths {
+		repoPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if err := releaseRepo(repoPath, forge, opts); err != nil {
+			fmt.Printf("✗ %s: %v\n", repoPath, err)
+			continue
+		}
+		released++
+	}
+
+	if len(paths) > 1 {
+		fmt.Printf("\nReleased %d of %d repositories\n", released, len(paths))
+	}
+	return nil
+}
+
+func releaseRepo(repoPath string, forge ForgeConfig, opts releaseOptions) error {
+	repo := getGitStatusOptimized(repoPath, filepath.Dir(repoPath))
+	if reason := releaseBlocker(repo); reason != "" {
+		return fmt.Errorf("not releasing: %s", reason)
+	}
+
+	notes, err := loadChangelog(repoPath)
+	if err != nil {
+		return err
+	}
+
+	version := opts.Version
+	if version == "" {
+		fmt.Printf("\n%s (%s)\n", repo.RelativePath, notes.heading())
+		version = prompt("  Version", nextPatchVersion(notes.Tag))
+	}
+	if version == "" {
+		return fmt.Errorf("no version given")
+	}
+	if _, err := runGit(repoPath, "rev-parse", "--quiet", "--verify", "refs/tags/"+version); err == nil {
+		return fmt.Errorf("tag %s already exists", version)
+	}
+
+	head, _ := runGit(repoPath, "rev-parse", "--short", "HEAD")
+	if !opts.Yes && !confirm(fmt.Sprintf("  Tag %s at %s and push it to origin?", version, head)) {
+		return fmt.Errorf("skipped")
+	}
+
+	message := opts.Message
+	if message == "" {
+		message = "Release " + version
+	}
+	tagFlag := "-a"
+	if opts.Sign {
+		tagFlag = "-s"
+	}
+	if _, err := runGit(repoPath, "tag", tagFlag, version, "-m", message); err != nil {
+		return err
+	}
+	if _, err := runGit(repoPath, "push", "origin", "refs/tags/"+version); err != nil {
+		return fmt.Errorf("tag %s created locally but push failed: %v", version, err)
+	}
+	fmt.Printf("✓ %s: tagged and pushed %s\n", repo.RelativePath, version)
+
+	if opts.ForgeRelease {
+		remote, err := runGit(repoPath, "remote", "get-url", "origin")
+		if err != nil {
+			return err
+		}
+		link, err := createForgeRelease(forge, forgeProjectPath(remote), version, notes.markdown())
+		if err != nil {
+			return fmt.Errorf("tag pushed but creating the release failed: %v", err)
+		}
+		fmt.Printf("✓ %s: created release %s\n", repo.RelativePath, link)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// movedRemote is an origin that now redirects to another URL because the
+// repo or its org was renamed or transferred
+type movedRemote struct {
+	repo   GitStatus
+	oldURL string
+	newURL string
+	err    error
+}
+
+// forgeProjectMoved asks the forge for the project behind a remote. Both
+// GitHub and GitLab answer for the old path with the project's current
+// one; "" means it hasn't moved.
+func forgeProjectMoved(f ForgeConfig, project string) (string, error) {
+	var current string
+	if f.Type == "gitlab" {
+		var p struct {
+			Path string `json:"path_with_namespace"`
+		}
+		if err := forgeRequest(f, "GET", "/projects/"+url.PathEscape(project), nil, &p); err != nil {
+			return "", err
+		}
+		current = p.Path
+	} else {
+		var r struct {
+			FullName string `json:"full_name"`
+		}
+		if err := forgeRequest(f, "GET", "/repos/"+project, nil, &r); err != nil {
+			return "", err
+		}
+		current = r.FullName
+	}
+	if current == "" || strings.EqualFold(current, project) {
+		return "", nil
+	}
+	return current, nil
+}
+
+// gitRedirect reads the "redirecting to" warning git prints when an HTTP
+// remote answers with a redirect
+func gitRedirect(repoPath string) (string, error) {
+	out, err := runGit(repoPath, "ls-remote", "origin", "HEAD")
+	for _, line := range strings.Split(out, "\n") {
+		if _, target, ok := strings.Cut(line, "redirecting to "); ok {
+			return strings.TrimSuffix(strings.TrimSpace(target), "/"), nil
+		}
+	}
+	return "", err
+}
+
+// detectMovedRemote checks one repo's origin, through the forge API when
+// the host is configured and otherwise by asking git
+func detectMovedRemote(repo GitStatus) movedRemote {
+	moved := movedRemote{repo: repo}
+	origin, err := runGit(repo.RepoPath, "remote", "get-url", "origin")
+	if err != nil {
+		return moved
+	}
+	moved.oldURL = origin
+
+	if forge, _, err := forgeForRemote(origin); err == nil {
+		project := forgeProjectPath(origin)
+		current, err := forgeProjectMoved(forge, project)
+		if err != nil || current == "" {
+			moved.err = err
+			return moved
+		}
+		// keep the URL's scheme, user and .git suffix, swap only the path
+		i := strings.LastIndex(origin, project)
+		moved.newURL = origin[:i] + current + origin[i+len(project):]
+		return moved
+	}
+
+	moved.newURL, moved.err = gitRedirect(repo.RepoPath)
+	return moved
+}
+
+// runRemapRemotes finds origins that moved and, unless dryRun, points
+// them at the new location
+func runRemapRemotes(baseDir string, dryRun, yes bool) {
+	repos := findGitReposOptimized(baseDir, config.Depth)
+
+	results := make([]movedRemote, len(repos))
+	semaphore := make(chan struct{}, min(runtime.NumCPU()*2, 16))
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		if !repo.HasRemote {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, repo GitStatus) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results[i] = detectMovedRemote(repo)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	var targets []movedRemote
+	skipped := 0
+	for _, moved := range results {
+		switch {
+		case moved.err != nil:
+			fmt.Printf("⚠ %-30s could not check origin: %v\n", displayName(moved.repo), moved.err)
+		case moved.newURL == "":
+		case isProtected(moved.repo.RepoPath):
+			fmt.Printf("- %-30s skipped: %s\n", displayName(moved.repo), protectedMessage)
+			skipped++
+		default:
+			fmt.Printf("⚠ %-30s %s → %s\n", displayName(moved.repo), moved.oldURL, moved.newURL)
+			targets = append(targets, moved)
+		}
+	}
+
+	if len(targets) == 0 {
+		fmt.Println("✓ No origin has moved")
+		return
+	}
+	if dryRun {
+		fmt.Printf("\nWould update origin in %d repositories, skipped %d\n", len(targets), skipped)
+		return
+	}
+
+	commands := make([]string, len(targets))
+	for i, moved := range targets {
+		commands[i] = fmt.Sprintf("git -C %s remote set-url origin %s", moved.repo.RepoPath, moved.newURL)
+	}
+	if !yes && !confirmBulk(fmt.Sprintf("Update origin in %d repositories?", len(targets)), commands) {
+		fmt.Println("Cancelled")
+		return
+	}
+
+	updated := 0
+	for _, moved := range targets {
+		if _, err := runGit(moved.repo.RepoPath, "remote", "set-url", "origin", moved.newURL); err != nil {
+			fmt.Printf("✗ %-30s %v\n", displayName(moved.repo), err)
+			skipped++
+			continue
+		}
+		fmt.Printf("✓ %-30s %s\n", displayName(moved.repo), moved.newURL)
+		updated++
+	}
+	fmt.Printf("\nUpdated %d remotes, skipped %d\n", updated, skipped)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unreachableFor is how long a host has to keep failing before its repos
+// count as gone; a VPN that is down for the day shouldn't flag anything
+const unreachableFor = 7 * 24 * time.Hour
+
+// remoteResult is the outcome of the last ls-remote against a repo's origin
+type remoteResult struct {
+	CheckedAt    time.Time `json:"checked_at"`
+	URL          string    `json:"url"`
+	Problem      string    `json:"problem,omitempty"`      // why ls-remote failed, empty when it worked
+	Missing      bool      `json:"missing,omitempty"`      // the host answered that the repo doesn't exist
+	FailingSince time.Time `json:"failing_since,omitzero"` // first of the failed checks in a row
+}
+
+// gone reports whether the origin is dead: the host said so, or it has
+// been unreachable for a week
+func (r remoteResult) gone() bool {
+	return r.Missing || (r.Problem != "" && time.Since(r.FailingSince) >= unreachableFor)
+}
+
+// missingMarkers are how git and the forges say a repository doesn't exist
+var missingMarkers = []string{
+	"repository not found",
+	"does not appear to be a git repository",
+	"project you were looking for could not be found",
+	"the requested url returned error: 404",
+}
+
+func remoteStatePath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "remotes.json"), nil
+}
+
+func loadRemoteState() map[string]remoteResult {
+	state := map[string]remoteResult{}
+	path, err := remoteStatePath()
+	if err != nil {
+		return state
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// remoteCache is fsckCache for remotes.json
+var remoteCache struct {
+	sync.Mutex
+	modified time.Time
+	state    map[string]remoteResult
+}
+
+func cachedRemoteState() map[string]remoteResult {
+	remoteCache.Lock()
+	defer remoteCache.Unlock()
+	path, err := remoteStatePath()
+	if err != nil {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if remoteCache.state == nil || !info.ModTime().Equal(remoteCache.modified) {
+		remoteCache.state, remoteCache.modified = loadRemoteState(), info.ModTime()
+	}
+	return remoteCache.state
+}
+
+// markRemoteGone puts a repo whose origin was found dead by the last
+// check-remotes in the remote-gone state, unless origin has been pointed
+// somewhere else since. Dirty, Ahead and Behind are kept.
+func markRemoteGone(status *GitStatus) {
+	result, ok := cachedRemoteState()[status.RepoPath]
+	if !ok || !status.HasRemote || !result.gone() {
+		return
+	}
+	if url, err := runGit(status.RepoPath, "remote", "get-url", "origin"); err != nil || url != result.URL {
+		return
+	}
+	status.RemoteGone = true
+	status.Symbol = "⚠"
+	status.Message = "Remote gone: " + result.Problem
+}
+
+// checkOrigin runs ls-remote against origin. The error is nil when the
+// remote answered; missing says the host answered that the repo isn't
+// there, and needsAuth that the check couldn't log in and says nothing.
+func checkOrigin(repoPath string) (missing, needsAuth bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	out, err := gitCommand(ctx, "-C", repoPath, "ls-remote", "--heads", "origin").CombinedOutput()
+	noteRemoteResult(repoPath, string(out), err)
+	if err == nil {
+		return false, false, nil
+	}
+	if ctx.Err() != nil {
+		return false, false, fmt.Errorf("timed out")
+	}
+	if reason := authFailure(string(out)); reason != "" {
+		return false, true, fmt.Errorf("%s", reason)
+	}
+	lower := strings.ToLower(string(out))
+	for _, marker := range missingMarkers {
+		if strings.Contains(lower, marker) {
+			missing = true
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "warning:") {
+			line = strings.TrimPrefix(strings.TrimPrefix(line, "remote: "), "fatal: ")
+			return missing, false, fmt.Errorf("%s", strings.TrimPrefix(line, "ERROR: "))
+		}
+	}
+	return missing, false, err
+}
+
+// runRemoteCheck asks every origin in the workspace whether it still
+// exists, records the results and reports whether none is gone
+func runRemoteCheck(baseDir string) (bool, error) {
+	if offline {
+		return false, fmt.Errorf("checking remotes needs the network; run without --offline")
+	}
+	repos := findGitReposOptimized(baseDir, config.Depth)
+	sortRepos(repos, "path")
+	previous := loadRemoteState()
+
+	type checked struct {
+		repo      GitStatus
+		result    remoteResult
+		needsAuth bool
+		err       error
+	}
+	var results []*checked
+	semaphore := make(chan struct{}, min(runtime.NumCPU()*2, 16))
+	var wg sync.WaitGroup
+	for _, repo := range repos {
+		if !repo.HasRemote {
+			continue
+		}
+		url, err := runGit(repo.RepoPath, "remote", "get-url", "origin")
+		if err != nil {
+			continue // remotes, but none called origin
+		}
+		c := &checked{repo: repo, result: remoteResult{URL: url}}
+		results = append(results, c)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			c.result.Missing, c.needsAuth, c.err = checkOrigin(c.repo.RepoPath)
+		}()
+	}
+	wg.Wait()
+
+	updates := map[string]remoteResult{}
+	var gone []GitStatus
+	for _, c := range results {
+		name := displayName(c.repo)
+		result := c.result
+		result.CheckedAt = time.Now().UTC()
+		switch {
+		case c.needsAuth:
+			// a private repo and a deleted one look the same without credentials
+			fmt.Printf("- %-30s not checked: %v\n", name, c.err)
+			continue
+		case c.err == nil:
+			fmt.Printf("✓ %-30s %s\n", name, result.URL)
+		default:
+			result.Problem = c.err.Error()
+			result.FailingSince = result.CheckedAt
+			if last, ok := previous[c.repo.RepoPath]; ok && last.Problem != "" && last.URL == result.URL {
+				result.FailingSince = last.FailingSince
+			}
+			switch {
+			case result.gone():
+				fmt.Printf("✗ %-30s %s: %s\n", name, result.URL, result.Problem)
+				gone = append(gone, c.repo)
+			default:
+				fmt.Printf("⚠ %-30s %s (unreachable since %s)\n", name, result.Problem, relativeTime(result.FailingSince))
+			}
+		}
+		updates[c.repo.RepoPath] = result
+	}
+
+	path, err := remoteStatePath()
+	if err != nil {
+		return len(gone) == 0, err
+	}
+	err = withLock("remotes", func() error {
+		state := loadRemoteState()
+		for repoPath, result := range updates {
+			state[repoPath] = result
+		}
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return writeFileAtomic(path, data, 0644)
+	})
+
+	fmt.Printf("\nChecked %d remotes, %d gone\n", len(updates), len(gone))
+	if len(gone) > 0 {
+		fmt.Println("\nFor each of them, either:")
+		fmt.Println("  git -C <repo> remote set-url origin <new-url>   if it moved (remap-remotes finds renames)")
+		fmt.Println("  git-status-dash archive <repo> --remove          if it is finished with")
+	}
+	return len(gone) == 0, err
+}
+
+// runRemoteReport lists each repo's last remote check without contacting
+// anything, reporting whether none is known to be gone
+func runRemoteReport(baseDir string) bool {
+	repos := findGitReposOptimized(baseDir, config.Depth)
+	sortRepos(repos, "path")
+	state := loadRemoteState()
+	ok := true
+	for _, repo := range repos {
+		if !repo.HasRemote {
+			continue
+		}
+		result, checked := state[repo.RepoPath]
+		switch {
+		case !checked:
+			fmt.Printf("- %-30s never checked\n", displayName(repo))
+		case result.gone():
+			fmt.Printf("✗ %-30s %s: %s (checked %s)\n", displayName(repo), result.URL, result.Problem, relativeTime(result.CheckedAt))
+			ok = false
+		case result.Problem != "":
+			fmt.Printf("⚠ %-30s %s (unreachable since %s)\n", displayName(repo), result.Problem, relativeTime(result.FailingSince))
+		default:
+			fmt.Printf("✓ %-30s %s (checked %s)\n", displayName(repo), result.URL, relativeTime(result.CheckedAt))
+		}
+	}
+	return ok
+}
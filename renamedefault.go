@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// defaultRename is a repo whose remote renamed its default branch (say
+// master to main) while the clone still has the old one
+type defaultRename struct {
+	repo GitStatus
+	old  string
+	new  string
+	err  error
+}
+
+// remoteDefaultBranch asks origin which branch its HEAD points at now
+func remoteDefaultBranch(repoPath string) (string, error) {
+	out, err := runGit(repoPath, "ls-remote", "--symref", "origin", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if ref, ok := strings.CutPrefix(line, "ref: refs/heads/"); ok {
+			branch, _, _ := strings.Cut(ref, "\t")
+			return branch, nil
+		}
+	}
+	return "", nil
+}
+
+// remoteHasBranch reports whether origin still has branch, so a branch
+// that was only moved off HEAD isn't mistaken for a rename
+func remoteHasBranch(repoPath, branch string) (bool, error) {
+	out, err := runGit(repoPath, "ls-remote", "--heads", "origin", "refs/heads/"+branch)
+	return out != "", err
+}
+
+func hasLocalBranch(repoPath, branch string) bool {
+	return gitCommand(context.Background(), "-C", repoPath, "show-ref", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil
+}
+
+// detectDefaultRename compares the remote's default branch with the one the
+// clone knows about. The old name is the clone's origin/HEAD, or when that
+// already moved, a leftover local master or main. It only counts as a
+// rename once origin no longer has the old branch.
+func detectDefaultRename(repo GitStatus) defaultRename {
+	rename := defaultRename{repo: repo}
+	remote, err := remoteDefaultBranch(repo.RepoPath)
+	if err != nil || remote == "" {
+		rename.err = err
+		return rename
+	}
+	if hasLocalBranch(repo.RepoPath, remote) {
+		return rename
+	}
+
+	candidates := []string{detectDefaultBranch(context.Background(), repo.RepoPath), "master", "main"}
+	for _, old := range candidates {
+		if old == "" || old == remote || !hasLocalBranch(repo.RepoPath, old) {
+			continue
+		}
+		stillThere, err := remoteHasBranch(repo.RepoPath, old)
+		if err != nil {
+			rename.err = err
+		} else if !stillThere {
+			rename.old, rename.new = old, remote
+		}
+		break
+	}
+	return rename
+}
+
+// fixupCommands are the steps the forges recommend after renaming a
+// default branch
+func (r defaultRename) fixupCommands() [][]string {
+	return [][]string{
+		{"fetch", "origin", "--prune"},
+		{"branch", "-m", r.old, r.new},
+		{"branch", "-u", "origin/" + r.new, r.new},
+		{"remote", "set-head", "origin", "-a"},
+	}
+}
+
+// runRenamedDefault finds repos still on a default branch their remote has
+// renamed and, unless dryRun, renames the local branch to match
+func runRenamedDefault(baseDir string, dryRun, yes bool) {
+	repos := findGitReposOptimized(baseDir, config.Depth)
+
+	results := make([]defaultRename, len(repos))
+	semaphore := make(chan struct{}, min(runtime.NumCPU()*2, 16))
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		if !repo.HasRemote {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, repo GitStatus) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results[i] = detectDefaultRename(repo)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	var targets []defaultRename
+	skipped := 0
+	for _, rename := range results {
+		switch {
+		case rename.err != nil:
+			fmt.Printf("⚠ %-30s could not ask origin: %v\n", displayName(rename.repo), rename.err)
+		case rename.old == "":
+		case isProtected(rename.repo.RepoPath):
+			fmt.Printf("- %-30s skipped: %s\n", displayName(rename.repo), protectedMessage)
+			skipped++
+		default:
+			fmt.Printf("⚠ %-30s origin renamed %s → %s\n", displayName(rename.repo), rename.old, rename.new)
+			targets = append(targets, rename)
+		}
+	}
+
+	if len(targets) == 0 {
+		fmt.Println("✓ Every local default branch matches its remote")
+		return
+	}
+	if dryRun {
+		fmt.Printf("\nWould rename the default branch in %d repositories, skipped %d\n", len(targets), skipped)
+		return
+	}
+
+	var commands []string
+	for _, rename := range targets {
+		for _, args := range rename.fixupCommands() {
+			commands = append(commands, fmt.Sprintf("git -C %s %s", rename.repo.RepoPath, strings.Join(args, " ")))
+		}
+	}
+	if !yes && !confirmBulk(fmt.Sprintf("Rename the default branch in %d repositories?", len(targets)), commands) {
+		fmt.Println("Cancelled")
+		return
+	}
+
+	fixed := 0
+	for _, rename := range targets {
+		var err error
+		for _, args := range rename.fixupCommands() {
+			if _, err = runGit(rename.repo.RepoPath, args...); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			fmt.Printf("✗ %-30s %v\n", displayName(rename.repo), err)
+			skipped++
+			continue
+		}
+		fmt.Printf("✓ %-30s %s → %s\n", displayName(rename.repo), rename.old, rename.new)
+		fixed++
+	}
+	fmt.Printf("\nRenamed %d default branches, skipped %d\n", fixed, skipped)
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func runReport() {
+	if config.Fetch && !offline {
+		fetchWorkspace(config.Directory, config.Depth)
+	}
+	repos := findGitReposOptimized(config.Directory, config.Depth)
+	unreadable := scanUnreadable.list()
+	saveSnapshot(config.Directory, repos)
+
+	var out bytes.Buffer
+	if config.Quiet {
+		fmt.Fprintln(&out, quietSummary(repos))
+		writeOutput(out.Bytes())
+		reportUnreadable(unreadable, false)
+		return
+	}
+
+	if config.Porcelain != "" {
+		selected := filterRepos(repos, config)
+		sortRepos(selected, config.Sort)
+		selected, _ = limitRepos(selected, config.Limit)
+		if err := writePorcelain(&out, config.Porcelain, selected); err != nil {
+			log.Fatal(err)
+		}
+		writeOutput(out.Bytes())
+		reportUnreadable(unreadable, false)
+		return
+	}
+
+	if config.Format == "junit" {
+		sortRepos(repos, "path")
+		if err := writeJUnit(&out, "git-status-dash "+config.Directory, repos); err != nil {
+			log.Fatal(err)
+		}
+		writeOutput(out.Bytes())
+		reportUnreadable(unreadable, false)
+		return
+	}
+
+	fmt.Fprintf(&out, "Found %d repositories, loading......\n", len(repos))
+
+	if config.Feed != "" {
+		if _, err := updateFeed(config.Feed, repos); err != nil {
+			log.Printf("Warning: Could not update feed: %v", err)
+		}
+	}
+
+	reposToShow := filterRepos(repos, config)
+	if needsTags() {
+		enrichTags(reposToShow)
+	}
+	sortRepos(reposToShow, config.Sort)
+	reposToShow, hidden := limitRepos(reposToShow, config.Limit)
+	color, width := false, 0
+	if config.Output == "" {
+		color = colorEnabled(os.Stdout)
+		width = terminalWidth()
+	}
+	if config.GroupBy != "" {
+		writeGroupedReport(&out, reposToShow, config.GroupBy, color, width)
+	} else {
+		writeReport(&out, reposToShow, color, width)
+	}
+	writeMoreLine(&out, hidden)
+	if len(unreadable) > 0 {
+		fmt.Fprintf(&out, "\n⚠ %s\n", unreadableSummary(len(unreadable)))
+	}
+	if foreign := scanForeign.Load(); foreign > 0 {
+		fmt.Fprintf(&out, "\n- %s\n", foreignSummary(foreign))
+	}
+	if offline {
+		fmt.Fprintf(&out, "\n⚠ %s\n", offlineNote)
+	}
+	if config.Output != "" {
+		writeOutput(out.Bytes())
+	} else if err := writePaged(out.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+
+	if config.Email != "" {
+		if err := emailReport(config.Email, reposToShow, config.EmailHTML); err != nil {
+			log.Fatalf("Could not send report: %v", err)
+		}
+		fmt.Printf("✓ Sent report to %s\n", config.Email)
+	}
+	reportUnreadable(unreadable, true)
+}
+
+// reportUnreadable mentions skipped directories on stderr, unless the
+// report already has a footer for them, and fails on them with --strict
+func reportUnreadable(paths []string, inReport bool) {
+	if len(paths) == 0 {
+		return
+	}
+	if config.Strict {
+		for _, path := range paths {
+			fmt.Fprintf(os.Stderr, "  %s\n", path)
+		}
+		log.Fatalf("%s (--strict)", unreadableSummary(len(paths)))
+	}
+	if !inReport {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", unreadableSummary(len(paths)))
+	}
+}
+
+// writeOutput sends finished output to --output (replaced atomically, so a
+// reader never sees half a report) or to stdout
+func writeOutput(output []byte) {
+	var err error
+	if config.Output != "" {
+		err = writeFileAtomic(config.Output, output, 0644)
+	} else {
+		_, err = os.Stdout.Write(output)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// reportRow is one repo's report entry. The branch is kept apart so it can
+// be styled on its own when display.show_branch is on.
+type reportRow struct {
+	Head, Branch, Tail string
+}
+
+func (r reportRow) String() string {
+	return r.Head + r.Branch + r.Tail
+}
+
+// reportLine is the normal layout. A branchWidth above 0 shows the branch
+// as its own column instead of the off-default ⎇ marker.
+func reportLine(repo GitStatus, branchWidth int) reportRow {
+	repoName := repo.RelativePath
+	if repoName == "" {
+		repoName = "."
+	}
+	if branchWidth > 0 {
+		return reportRow{
+			Head:   fmt.Sprintf("%s %-30s ", repo.Symbol, repoName),
+			Branch: fmt.Sprintf("%-*s", branchWidth, repo.Branch),
+			Tail:   " " + statusLabel(repo),
+		}
+	}
+	row := reportRow{Head: fmt.Sprintf("%s %-30s %s", repo.Symbol, repoName, statusLabel(repo))}
+	if repo.OffDefaultBranch() {
+		row.Tail = fmt.Sprintf(" ⎇ %s", repo.Branch)
+	}
+	return row
+}
+
+// onDefaultBranch reports whether the repo is on its default branch, assuming
+// main or master when the remote default is unknown
+func onDefaultBranch(repo GitStatus) bool {
+	if repo.DefaultBranch != "" {
+		return repo.Branch == repo.DefaultBranch
+	}
+	return repo.Branch == "main" || repo.Branch == "master"
+}
+
+// reportColumns are the fields --columns can pick from
+var reportColumns = []string{"symbol", "path", "status", "changes", "diffstat", "branch", "commit", "author", "activity", "ahead", "behind", "tag", "since-tag"}
+
+func validateColumns(columns []string) error {
+	for _, column := range columns {
+		found := false
+		for _, known := range reportColumns {
+			found = found || column == known
+		}
+		if !found {
+			return fmt.Errorf("unknown column %q (available: %s)", column, strings.Join(reportColumns, ", "))
+		}
+	}
+	return nil
+}
+
+func columnValue(repo GitStatus, column string) string {
+	switch column {
+	case "symbol":
+		return repo.Symbol
+	case "path":
+		if repo.RelativePath == "" {
+			return "."
+		}
+		return repo.RelativePath
+	case "status":
+		return repo.Message
+	case "diffstat":
+		if stat := diffstat(repo); stat != "" {
+			return stat
+		}
+		return "-"
+	case "changes":
+		if counts := changeCounts(repo); counts != "" {
+			return counts
+		}
+		return "-"
+	case "branch":
+		return repo.Branch
+	case "commit":
+		return repo.LastCommit
+	case "author":
+		if repo.LastAuthor == "" {
+			return "-"
+		}
+		return repo.LastAuthor
+	case "activity":
+		return formatTimestamp(repo.LastCommitAt, displayConfig().TimeFormat)
+	case "ahead":
+		if repo.HasUpstream {
+			return strconv.Itoa(repo.Ahead)
+		}
+		return "-"
+	case "behind":
+		if repo.HasUpstream {
+			return strconv.Itoa(repo.Behind)
+		}
+		return "-"
+	case "tag":
+		if repo.LatestTag == "" {
+			return "-"
+		}
+		return repo.LatestTag
+	case "since-tag":
+		if repo.LatestTag == "" {
+			return "-"
+		}
+		return strconv.Itoa(repo.SinceTag)
+	}
+	return ""
+}
+
+// columnLines lays out the chosen columns. Every column is as wide as its
+// longest value, and the path column at least display.column_width, so long
+// paths push the rest over instead of breaking the alignment.
+func columnLines(repos []GitStatus, columns []string) []string {
+	minPathWidth := 30
+	if width := displayConfig().ColumnWidth; width > 0 {
+		minPathWidth = width
+	}
+
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		if column == "path" {
+			widths[i] = minPathWidth
+		}
+		for _, repo := range repos {
+			if width := utf8.RuneCountInString(columnValue(repo, column)); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	lines := make([]string, len(repos))
+	for r, repo := range repos {
+		fields := make([]string, len(columns))
+		for i, column := range columns {
+			value := columnValue(repo, column)
+			if i < len(columns)-1 {
+				value += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(value))
+			}
+			fields[i] = value
+		}
+		lines[r] = strings.Join(fields, " ")
+	}
+	return lines
+}
+
+var loadedDisplayConfig *DisplayConfig
+
+// displayConfig returns the display settings, read from disk once per run
+func displayConfig() DisplayConfig {
+	if loadedDisplayConfig == nil {
+		loadedDisplayConfig = &DisplayConfig{}
+		if userConfig, err := loadConfig(); err == nil {
+			loadedDisplayConfig = &userConfig.Display
+		}
+	}
+	return *loadedDisplayConfig
+}
+
+// wideColumns is the layout used when the terminal has room for everything
+var wideColumns = []string{"symbol", "path", "branch", "status", "changes", "commit"}
+
+var reportLayouts = []string{"auto", "wide", "normal", "narrow"}
+
+func validateLayout(layout string) error {
+	for _, known := range reportLayouts {
+		if layout == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown layout %q (available: %s)", layout, strings.Join(reportLayouts, ", "))
+}
+
+// narrowLine puts the status on its own indented line
+func narrowLine(repo GitStatus) string {
+	name := repo.RelativePath
+	if name == "" {
+		name = "."
+	}
+	line := fmt.Sprintf("%s %s\n    %s", repo.Symbol, name, statusLabel(repo))
+	if repo.OffDefaultBranch() {
+		line += fmt.Sprintf(" ⎇ %s", repo.Branch)
+	}
+	return line
+}
+
+func longestLine(lines []string) int {
+	longest := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line); n > longest {
+			longest = n
+		}
+	}
+	return longest
+}
+
+// reportLines renders one entry per repo. With --layout auto and a known
+// terminal width, the widest layout that fits without wrapping wins.
+func reportLines(repos []GitStatus, width int) []reportRow {
+	rows := make([]reportRow, len(repos))
+	if len(config.Columns) > 0 {
+		for i, line := range columnLines(repos, config.Columns) {
+			rows[i].Head = line
+		}
+		return rows
+	}
+
+	display := displayConfig()
+	wideLayout := wideColumns
+	if !display.ShowBranch {
+		wideLayout = slices.DeleteFunc(slices.Clone(wideLayout), func(column string) bool { return column == "branch" })
+	}
+	if display.ShowDiffstat {
+		wideLayout = slices.Insert(slices.Clone(wideLayout), slices.Index(wideLayout, "changes")+1, "diffstat")
+	}
+	if display.ShowTimestamp {
+		wideLayout = append(append([]string(nil), wideLayout...), "activity")
+	}
+	branchWidth := 0
+	if display.ShowBranch {
+		for _, repo := range repos {
+			if n := utf8.RuneCountInString(repo.Branch); n > branchWidth {
+				branchWidth = n
+			}
+		}
+	}
+
+	layout := config.Layout
+	if layout == "auto" {
+		layout = "normal"
+		if width > 0 {
+			if wide := columnLines(repos, wideLayout); longestLine(wide) <= width {
+				for i, line := range wide {
+					rows[i].Head = line
+				}
+				return rows
+			}
+		}
+	}
+
+	switch layout {
+	case "wide":
+		for i, line := range columnLines(repos, wideLayout) {
+			rows[i].Head = line
+		}
+		return rows
+	case "narrow":
+		for i, repo := range repos {
+			rows[i].Head = narrowLine(repo)
+		}
+	default:
+		lines := make([]string, len(repos))
+		for i, repo := range repos {
+			rows[i] = reportLine(repo, branchWidth)
+			lines[i] = rows[i].String()
+		}
+		if config.Layout == "auto" && width > 0 && longestLine(lines) > width {
+			for i, repo := range repos {
+				rows[i] = reportRow{Head: narrowLine(repo)}
+			}
+		}
+	}
+
+	if display.ShowTimestamp {
+		for i, repo := range repos {
+			rows[i].Tail += " · " + formatTimestamp(repo.LastCommitAt, display.TimeFormat)
+		}
+	}
+	if display.ShowAuthor {
+		for i, repo := range repos {
+			rows[i].Tail += authorLabel(repo)
+		}
+	}
+	return rows
+}
+
+// renderLines styles each line of s separately, so multi-line entries
+// aren't padded into a block
+func renderLines(style lipgloss.Style, s string) string {
+	if s == "" {
+		return ""
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = style.Render(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeReport writes one entry per repo. width is the terminal width used by
+// --layout auto, or 0 when output doesn't go to a terminal.
+func writeReport(w io.Writer, repos []GitStatus, color bool, width int) {
+	if displayConfig().TreeView && len(config.Columns) == 0 {
+		writeTreeReport(w, repos, color)
+		return
+	}
+
+	rows := reportLines(repos, width)
+	if !color {
+		for _, row := range rows {
+			fmt.Fprintln(w, row)
+		}
+		return
+	}
+
+	renderer := colorRenderer(w)
+	theme := activeTheme()
+	defaultBranchStyle := renderer.NewStyle().Foreground(themeColor(theme.Colors["dim"]))
+	featureBranchStyle := renderer.NewStyle().Foreground(themeColor(theme.Colors["info"])).Bold(true)
+	for i, repo := range repos {
+		style := renderer.NewStyle().Foreground(themeColor(theme.Colors[symbolColorKey(repo.Symbol)]))
+		branchStyle := featureBranchStyle
+		if onDefaultBranch(repo) {
+			branchStyle = defaultBranchStyle
+		}
+		fmt.Fprintln(w, renderLines(style, rows[i].Head)+renderLines(branchStyle, rows[i].Branch)+renderLines(style, rows[i].Tail))
+	}
+}
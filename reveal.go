@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// expandCommand splits a configured command line and substitutes {path}.
+// Without a {path} placeholder the path is appended as the last argument
+// when appendPath is set.
+func expandCommand(command, path string, appendPath bool) []string {
+	args := strings.Fields(command)
+	found := false
+	for i, arg := range args {
+		if strings.Contains(arg, "{path}") {
+			args[i] = strings.ReplaceAll(arg, "{path}", path)
+			found = true
+		}
+	}
+	if !found && appendPath {
+		args = append(args, path)
+	}
+	return args
+}
+
+func fileManagerCommand(path string) []string {
+	if userConfig, err := loadConfig(); err == nil && userConfig.Behavior.FileManagerCommand != "" {
+		return expandCommand(userConfig.Behavior.FileManagerCommand, path, true)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open", path}
+	case "windows":
+		return []string{"explorer", path}
+	}
+	return []string{"xdg-open", path}
+}
+
+// terminalCommand opens a terminal in path. Terminals are started with
+// path as their working directory too, so a plain "alacritty" works.
+func terminalCommand(path string) ([]string, error) {
+	if userConfig, err := loadConfig(); err == nil && userConfig.Behavior.TerminalCommand != "" {
+		return expandCommand(userConfig.Behavior.TerminalCommand, path, false), nil
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open", "-a", "Terminal", path}, nil
+	case "windows":
+		if _, err := exec.LookPath("wt"); err == nil {
+			return []string{"wt", "-d", path}, nil
+		}
+		return []string{"cmd", "/c", "start", "cmd", "/k", "cd", "/d", path}, nil
+	}
+
+	if terminal := os.Getenv("TERMINAL"); terminal != "" {
+		return []string{terminal}, nil
+	}
+	candidates := [][]string{
+		{"x-terminal-emulator"},
+		{"gnome-terminal", "--working-directory=" + path},
+		{"konsole", "--workdir", path},
+		{"xfce4-terminal", "--working-directory=" + path},
+		{"kitty", "--directory", path},
+		{"alacritty", "--working-directory", path},
+		{"xterm"},
+	}
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate[0]); err == nil {
+			return candidate, nil
+		}
+	}
+	return nil, fmt.Errorf("no terminal found, set behavior.terminal_command")
+}
+
+// webURL turns an origin URL in scp-like, ssh:// or https:// form into the
+// repository's web page
+func webURL(remote string) (string, error) {
+	if u, err := url.Parse(remote); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		u.User = nil
+		u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), ".git")
+		return u.String(), nil
+	}
+	host, path, _ := strings.Cut(normalizeRemoteURL(remote), "/")
+	if host == "" || path == "" {
+		return "", fmt.Errorf("%s is not a hosted remote", remote)
+	}
+	return "https://" + host + "/" + path, nil
+}
+
+// browserCommand opens a URL in $BROWSER or the system default browser
+func browserCommand(link string) []string {
+	if browser := strings.Fields(os.Getenv("BROWSER")); len(browser) > 0 {
+		return append(browser, link)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open", link}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler", link}
+	}
+	return []string{"xdg-open", link}
+}
+
+// startDetached launches a GUI program in dir without waiting for it
+func startDetached(dir string, args []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+func revealCmd(repoPath string) tea.Cmd {
+	return func() tea.Msg {
+		if err := startDetached(repoPath, fileManagerCommand(repoPath)); err != nil {
+			return noticeMsg(fmt.Sprintf("✗ Could not open file manager: %v", err))
+		}
+		return noticeMsg(fmt.Sprintf("✓ Opened %s", repoPath))
+	}
+}
+
+func openTerminalCmd(repoPath string) tea.Cmd {
+	return func() tea.Msg {
+		args, err := terminalCommand(repoPath)
+		if err == nil {
+			err = startDetached(repoPath, args)
+		}
+		if err != nil {
+			return noticeMsg(fmt.Sprintf("✗ Could not open terminal: %v", err))
+		}
+		return noticeMsg(fmt.Sprintf("✓ Opened terminal in %s", repoPath))
+	}
+}
+
+// forgePages are the pages besides the repo home that can be opened
+var forgePages = []string{"pr", "issues", "pipeline"}
+
+// forgeType guesses which forge serves host: a configured forge's type, else
+// GitLab for hosts with "gitlab" in the name and GitHub for the rest
+func forgeType(host string) string {
+	if userConfig, err := loadConfig(); err == nil {
+		for _, forge := range userConfig.Forges {
+			if strings.EqualFold(forge.host(), host) {
+				return forge.Type
+			}
+		}
+	}
+	if strings.Contains(strings.ToLower(host), "gitlab") {
+		return "gitlab"
+	}
+	return "github"
+}
+
+// forgePageURL is the web address of page ("" for the repo home, "pr" for a
+// new pull/merge request from the current branch, "issues" or "pipeline"
+// for the current branch's latest pipeline)
+func forgePageURL(repo GitStatus, page string) (string, error) {
+	home, err := repoWebURL(repo)
+	if err != nil || page == "" {
+		return home, err
+	}
+	if page != "issues" && repo.Branch == "" {
+		return "", fmt.Errorf("no branch checked out")
+	}
+	u, _ := url.Parse(home)
+	gitlab := forgeType(u.Hostname()) == "gitlab"
+	branchPath := strings.ReplaceAll(url.PathEscape(repo.Branch), "%2F", "/")
+	switch page {
+	case "pr":
+		if !repo.OffDefaultBranch() {
+			return "", fmt.Errorf("%s is the default branch, switch to a feature branch first", repo.Branch)
+		}
+		if gitlab {
+			return home + "/-/merge_requests/new?" + url.Values{"merge_request[source_branch]": {repo.Branch}}.Encode(), nil
+		}
+		return home + "/compare/" + branchPath + "?expand=1", nil
+	case "issues":
+		if gitlab {
+			return home + "/-/issues", nil
+		}
+		return home + "/issues", nil
+	case "pipeline":
+		if gitlab {
+			return home + "/-/pipelines/" + branchPath + "/latest", nil
+		}
+		return home + "/actions?" + url.Values{"query": {"branch:" + repo.Branch}}.Encode(), nil
+	}
+	return "", fmt.Errorf("unknown page %q (available: %s)", page, strings.Join(forgePages, ", "))
+}
+
+func browseRepoCmd(repo GitStatus, page string) tea.Cmd {
+	return func() tea.Msg {
+		link, err := forgePageURL(repo, page)
+		if err == nil {
+			err = startDetached(repo.RepoPath, browserCommand(link))
+		}
+		if err != nil {
+			return noticeMsg(fmt.Sprintf("✗ Could not open %s in the browser: %v", displayName(repo), err))
+		}
+		return noticeMsg(fmt.Sprintf("✓ Opened %s", link))
+	}
+}
+
+// repoWebURL is the web page of the repo's origin
+func repoWebURL(repo GitStatus) (string, error) {
+	origin, err := runGit(repo.RepoPath, "remote", "get-url", "origin")
+	if err != nil || origin == "" {
+		return "", fmt.Errorf("no origin remote")
+	}
+	return webURL(origin)
+}
+
+// editorCommand opens path in the configured editor command, run from the
+// repo so "code ." works, else in $VISUAL or $EDITOR
+func editorCommand(path string) ([]string, error) {
+	if userConfig, err := loadConfig(); err == nil && userConfig.Behavior.EditorCommand != "" {
+		return expandCommand(userConfig.Behavior.EditorCommand, path, false), nil
+	}
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return expandCommand(editor, path, true), nil
+		}
+	}
+	return nil, fmt.Errorf("$VISUAL and $EDITOR are unset, set one or behavior.editor_command")
+}
+
+// shellCommand is the configured open command, else an interactive shell
+func shellCommand(path string) []string {
+	if userConfig, err := loadConfig(); err == nil && userConfig.Behavior.OpenCommand != "" {
+		return expandCommand(userConfig.Behavior.OpenCommand, path, false)
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return []string{shell}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"cmd"}
+	}
+	return []string{"/bin/sh"}
+}
+
+// execInRepoCmd suspends the TUI while args run in the repo, then rescans
+// it since whatever ran there probably changed its status
+func execInRepoCmd(repo GitStatus, baseDir, what string, args []string) tea.Cmd {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = repo.RepoPath
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		notice := fmt.Sprintf("✓ Closed %s for %s", what, displayName(repo))
+		if err != nil {
+			notice = fmt.Sprintf("✗ %s failed for %s: %v", strings.ToUpper(what[:1])+what[1:], displayName(repo), err)
+		}
+		return repoStatusMsg{status: getGitStatus(repo.RepoPath, baseDir, nil), notice: notice}
+	})
+}
+
+func editRepoCmd(repo GitStatus, baseDir string) tea.Cmd {
+	args, err := editorCommand(repo.RepoPath)
+	if err != nil {
+		return func() tea.Msg { return noticeMsg(fmt.Sprintf("✗ Could not open editor: %v", err)) }
+	}
+	return execInRepoCmd(repo, baseDir, "editor", args)
+}
+
+func shellRepoCmd(repo GitStatus, baseDir string) tea.Cmd {
+	return execInRepoCmd(repo, baseDir, "shell", shellCommand(repo.RepoPath))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// overrideRoots skips the root checks (--i-know-what-im-doing)
+var overrideRoots bool
+
+func addRootOverrideFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&overrideRoots, "i-know-what-im-doing", false, "Allow running against / or your home directory, or outside allowed_roots")
+}
+
+// checkMutationRoot keeps commands that change repos away from / and the
+// home directory itself, and inside allowed_roots when that is configured
+func checkMutationRoot(dir string) error {
+	if overrideRoots {
+		return nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	abs = filepath.Clean(abs)
+
+	home, _ := os.UserHomeDir()
+	if abs == filepath.Dir(abs) || home != "" && abs == filepath.Clean(home) {
+		return fmt.Errorf("refusing to change every repo under %s; point at a narrower directory or pass --i-know-what-im-doing", abs)
+	}
+
+	return checkAllowedRoots(abs)
+}
+
+// checkDashboardRoot keeps the TUI and line mode, whose actions change
+// repos, inside allowed_roots when that is configured. Unlike the commands
+// they may still open / or the home directory.
+func checkDashboardRoot(dir string) error {
+	if overrideRoots {
+		return nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	return checkAllowedRoots(filepath.Clean(abs))
+}
+
+// checkAllowedRoots refuses an absolute, clean path outside allowed_roots
+func checkAllowedRoots(abs string) error {
+	userConfig, err := loadConfig()
+	if err != nil || len(userConfig.AllowedRoots) == 0 {
+		return nil
+	}
+	for _, root := range userConfig.AllowedRoots {
+		root = filepath.Clean(root)
+		if abs == root || strings.HasPrefix(abs, root+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is outside allowed_roots (%s); pass --i-know-what-im-doing to run anyway", abs, strings.Join(userConfig.AllowedRoots, ", "))
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type newRepoOptions struct {
+	Template     string
+	Forge        string
+	CreateRemote bool
+	Private      bool
+	Description  string
+}
+
+func templatesDir() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "templates"), nil
+}
+
+// applyTemplate copies a template directory into a fresh repo. A top-level
+// hooks/ directory is installed into .git/hooks instead of the work tree, and
+// {{name}}, {{year}} and {{author}} placeholders are filled in.
+func applyTemplate(templateName, repoPath string) (int, error) {
+	dir, err := templatesDir()
+	if err != nil {
+		return 0, err
+	}
+	templateDir := filepath.Join(dir, templateName)
+	if info, err := os.Stat(templateDir); err != nil || !info.IsDir() {
+		return 0, fmt.Errorf("template '%s' not found in %s", templateName, dir)
+	}
+
+	author, _ := runGit(repoPath, "config", "user.name")
+	replacer := strings.NewReplacer(
+		"{{name}}", filepath.Base(repoPath),
+		"{{year}}", strconv.Itoa(time.Now().Year()),
+		"{{author}}", author,
+	)
+
+	copied := 0
+	err = filepath.WalkDir(templateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(templateDir, path)
+		if rel == "." {
+			return nil
+		}
+
+		target := filepath.Join(repoPath, rel)
+		if rel == "hooks" || strings.HasPrefix(rel, "hooks"+string(filepath.Separator)) {
+			target = filepath.Join(repoPath, ".git", rel)
+		}
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		copied++
+		return os.WriteFile(target, []byte(replacer.Replace(string(data))), info.Mode().Perm())
+	})
+
+	return copied, err
+}
+
+// runNewRepo scaffolds a repository inside the scan root so the dashboard
+// picks it up straight away
+func runNewRepo(baseDir, name string, opts newRepoOptions) error {
+	repoPath := filepath.Join(baseDir, name)
+	if _, err := os.Stat(repoPath); err == nil {
+		return fmt.Errorf("%s already exists", repoPath)
+	}
+
+	var forge ForgeConfig
+	if opts.CreateRemote {
+		var err error
+		if forge, err = selectForge(opts.Forge); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(repoPath, 0755); err != nil {
+		return err
+	}
+	if _, err := runGit(repoPath, "init"); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Initialized %s\n", repoPath)
+
+	if opts.Template != "" {
+		copied, err := applyTemplate(opts.Template, repoPath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Applied template '%s' (%d files)\n", opts.Template, copied)
+
+		if copied > 0 {
+			if _, err := runGit(repoPath, "add", "-A"); err != nil {
+				return err
+			}
+			if _, err := runGit(repoPath, "commit", "-m", "Initial commit from template "+opts.Template); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.CreateRemote {
+		remote, err := createForgeRepo(forge, name, opts.Private, opts.Description)
+		if err != nil {
+			return fmt.Errorf("creating remote: %v", err)
+		}
+		if _, err := runGit(repoPath, "remote", "add", "origin", forge.remoteURL(remote)); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Created %s\n", remote.WebURL)
+
+		if _, err := runGit(repoPath, "rev-parse", "--verify", "HEAD"); err == nil {
+			if _, err := runGit(repoPath, "push", "-u", "origin", "HEAD"); err != nil {
+				return fmt.Errorf("pushing: %v", err)
+			}
+			fmt.Println("✓ Pushed initial commit")
+		}
+	}
+
+	status := getGitStatusOptimized(repoPath, baseDir)
+	fmt.Printf("%s %s %s\n", status.Symbol, status.RelativePath, status.Message)
+	return nil
+}
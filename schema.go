@@ -0,0 +1,34 @@
+package main
+
+import "fmt"
+
+// version is stamped at build time with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// JSON output (--progress json, the debug bundle's scan.json) carries
+// schema_version and the tool version in every object. Within a schema
+// version, fields are only ever added: nothing is removed, renamed or given
+// a different type or meaning, so scripts should ignore fields they don't
+// know. Any other change bumps the schema version, and --schema keeps
+// emitting the older layouts for at least one release after that.
+const latestSchema = 1
+
+var supportedSchemas = map[string]int{"v1": 1}
+
+// outputSchema is the --schema flag; empty means the latest
+var outputSchema string
+
+func validateSchema(schema string) error {
+	if _, ok := supportedSchemas[schema]; schema != "" && !ok {
+		return fmt.Errorf("unsupported schema %q (supported: v1)", schema)
+	}
+	return nil
+}
+
+// schemaVersion is the layout JSON output should use
+func schemaVersion() int {
+	if v, ok := supportedSchemas[outputSchema]; ok {
+		return v
+	}
+	return latestSchema
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// listHeight is how many repo rows fit between the header and the footer,
+// never fewer than three however much the footer takes
+func (m model) listHeight() int {
+	used := strings.Count(m.viewHeader(""), "\n") + lipgloss.Height(m.viewFooter())
+	return max(3, m.termHeight-used)
+}
+
+// scrollToCursor moves the list's scroll offset just enough to keep the
+// cursor row visible
+func (m model) scrollToCursor() model {
+	height := m.listHeight()
+	rows := m.listRows()
+	row, top := m.cursorRow(rows), m.cursorRow(rows)
+	for top > 0 && !rows[top-1].isRepo() {
+		top-- // keep the section header or directories above the repo in view
+	}
+	switch {
+	case top < m.list.YOffset:
+		m.list.YOffset = top
+	case row >= m.list.YOffset+height:
+		m.list.YOffset = row - height + 1
+	}
+	m.list.YOffset = max(0, min(m.list.YOffset, len(rows)-height))
+	return m
+}
+
+// pageCursor moves the cursor a screenful up or down
+func (m model) pageCursor(down bool) model {
+	step := m.listHeight() - 1
+	if !down {
+		step = -step
+	}
+	m.cursor = max(0, min(len(m.repos)-1, m.cursor+step))
+	return m
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// matchesSearch fuzzy-matches query against a repo's path, branch and status
+func matchesSearch(repo GitStatus, query string) bool {
+	for _, field := range []string{repo.RelativePath, repo.Branch, repo.Message, repo.LastAuthor} {
+		if _, ok := fuzzyScore(query, field); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// tuiSorts are the orders s cycles through in the TUI
+var tuiSorts = []string{"mtime", "path", "status", "ahead", "behind"}
+
+func nextSort(current string) string {
+	for i, by := range tuiSorts {
+		if by == current {
+			return tuiSorts[(i+1)%len(tuiSorts)]
+		}
+	}
+	return tuiSorts[0]
+}
+
+// sortLabel names a sort order for the header
+func sortLabel(by string) string {
+	switch by {
+	case "mtime":
+		return "recently modified"
+	case "path":
+		return "name"
+	case "status":
+		return "status severity"
+	}
+	return by + " count"
+}
+
+// refilter applies a changed --all or --off-default to the last scan
+// without rescanning
+func (m model) refilter() model {
+	m.scanned = filterRepos(m.found, m.config)
+	return m.applySearch()
+}
+
+// applySearch sorts the scanned repos and narrows the visible list to the
+// ones matching the search, keeping the selection on the same repo when it
+// is still visible
+func (m model) applySearch() model {
+	selected := ""
+	if m.cursor < len(m.repos) {
+		selected = m.repos[m.cursor].RepoPath
+	}
+
+	var sorted []GitStatus
+	for _, repo := range m.scanned {
+		if !m.isHidden(repo) && statusTabs[m.tab].includes(repo) && (!m.triageOnly || m.triage[repo.RepoPath] != (triageCounts{})) {
+			sorted = append(sorted, repo)
+		}
+	}
+	sortRepos(sorted, m.sortBy)
+	pinnedFirst(sorted, m.pinned)
+	m.repos = sorted
+	if m.search != "" {
+		m.repos = nil
+		for _, repo := range sorted {
+			if matchesSearch(repo, m.search) {
+				m.repos = append(m.repos, repo)
+			}
+		}
+	}
+	if m.treeView {
+		m.repos, m.dirSizes = m.treeRepos(m.repos)
+	} else if m.grouping {
+		m.repos, m.groupSizes = m.groupRepos(m.repos)
+	}
+
+	m.cursor = 0
+	for i, repo := range m.repos {
+		if repo.RepoPath == selected {
+			m.cursor = i
+		}
+	}
+	return m
+}
+
+// updateSearch handles keys while the "/" prompt is open. enter keeps the
+// filter and returns to the list, esc drops it.
+func (m model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.searching = false
+		m.search = ""
+	case "enter":
+		m.searching = false
+		return m, m.detailCmd()
+	case "up", "ctrl+p":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		return m, nil
+	case "down", "ctrl+n":
+		if m.cursor < len(m.repos)-1 {
+			m.cursor++
+		}
+		return m, nil
+	case "backspace":
+		if search := []rune(m.search); len(search) > 0 {
+			m.search = string(search[:len(search)-1])
+		}
+	default:
+		if msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace {
+			return m, nil
+		}
+		m.search += string(msg.Runes)
+	}
+	return m.applySearch(), nil
+}
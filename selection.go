@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// visualRange is the span between the v anchor and the cursor, when a
+// visual selection is open
+func (m model) visualRange() (int, int, bool) {
+	if m.visualFrom == "" {
+		return 0, 0, false
+	}
+	for i, repo := range m.repos {
+		if repo.RepoPath == m.visualFrom {
+			return min(i, m.cursor), max(i, m.cursor), true
+		}
+	}
+	return 0, 0, false
+}
+
+// isMarked reports whether the visible repo at i is part of the selection
+func (m model) isMarked(i int) bool {
+	if from, to, ok := m.visualRange(); ok && i >= from && i <= to {
+		return true
+	}
+	return m.marked[m.repos[i].RepoPath]
+}
+
+// targets are the repos an action applies to: the visual range or the
+// marked repos when there are any, otherwise the one under the cursor
+func (m model) targets() []GitStatus {
+	var targets []GitStatus
+	for i, repo := range m.repos {
+		if m.isMarked(i) {
+			targets = append(targets, repo)
+		}
+	}
+	if len(targets) == 0 && m.cursor < len(m.repos) {
+		targets = append(targets, m.repos[m.cursor])
+	}
+	return targets
+}
+
+func (m model) toggleMark() model {
+	if m.cursor >= len(m.repos) {
+		return m
+	}
+	path := m.repos[m.cursor].RepoPath
+	if m.marked[path] {
+		delete(m.marked, path)
+	} else {
+		m.marked[path] = true
+	}
+	if m.cursor < len(m.repos)-1 {
+		m.cursor++
+	}
+	return m
+}
+
+// toggleVisual starts a visual selection at the cursor, or marks the
+// selected range and closes it
+func (m model) toggleVisual() model {
+	if from, to, ok := m.visualRange(); ok {
+		for _, repo := range m.repos[from : to+1] {
+			m.marked[repo.RepoPath] = true
+		}
+		m.visualFrom = ""
+	} else if m.cursor < len(m.repos) {
+		m.visualFrom = m.repos[m.cursor].RepoPath
+	}
+	return m
+}
+
+func (m model) clearSelection() model {
+	m.marked = make(map[string]bool)
+	m.visualFrom = ""
+	return m
+}
+
+// fetchTargets fetches the selection: one repo in place, several through
+// the fetch-all progress and rescan
+func (m model) fetchTargets() (model, tea.Cmd) {
+	targets := m.targets()
+	if len(targets) == 1 {
+		if m.busy[targets[0].RepoPath] {
+			return m, nil
+		}
+		m.busy[targets[0].RepoPath] = true
+		return m, fetchRepoCmd(targets[0], m.baseDir)
+	}
+	if m.fetchAll != nil || len(targets) == 0 {
+		return m, nil
+	}
+	paths := make([]string, len(targets))
+	for i, repo := range targets {
+		paths[i] = repo.RepoPath
+	}
+	var cmd tea.Cmd
+	m.fetchAll, cmd = fetchAllCmd(paths)
+	return m, cmd
+}
+
+// confirmFetch fetches the selection, asking first when it is large
+func (m model) confirmFetch() (model, tea.Cmd) {
+	var commands []string
+	for _, repo := range m.targets() {
+		commands = append(commands, "git fetch  "+displayName(repo))
+	}
+	return m.confirmTargets(fmt.Sprintf("Fetch %d repositories?", len(commands)), commands, model.fetchTargets)
+}
+
+// confirmPull pulls the selection, asking first when many repos would be
+// pulled
+func (m model) confirmPull() (model, tea.Cmd) {
+	var commands []string
+	for _, repo := range m.targets() {
+		if repo.Symbol == "↓" && !m.busy[repo.RepoPath] {
+			commands = append(commands, "git pull --ff-only  "+displayName(repo))
+		}
+	}
+	return m.confirmTargets(fmt.Sprintf("Pull %d repositories?", len(commands)), commands, model.pullTargets)
+}
+
+// pullPrecheckMsg carries the host check run before pulling several repos
+type pullPrecheckMsg struct {
+	repos []GitStatus
+	hosts []hostCheck
+}
+
+// pullTargets fast-forwards every selected repo that is behind, skipping
+// the rest. Several repos are pulled once their hosts pass the precheck.
+func (m model) pullTargets() (model, tea.Cmd) {
+	var pulls []GitStatus
+	var protected []string
+	skipped := 0
+	for _, repo := range m.targets() {
+		switch {
+		case m.busy[repo.RepoPath]:
+		case repo.Symbol != "↓":
+			skipped++
+		case isProtected(repo.RepoPath):
+			protected = append(protected, displayName(repo))
+			skipped++
+		default:
+			m.busy[repo.RepoPath] = true
+			pulls = append(pulls, repo)
+		}
+	}
+	switch {
+	case len(pulls) == 0:
+		m.notice = "Nothing to pull (only ↓ repos can be fast-forwarded)"
+	case len(pulls) == 1:
+	case skipped > 0:
+		m.notice = fmt.Sprintf("Pulling %d repositories, skipped %d that aren't behind or are protected", len(pulls), skipped)
+	default:
+		m.notice = fmt.Sprintf("Checking remote hosts before pulling %d repositories...", len(pulls))
+	}
+	if len(protected) > 0 {
+		verb := "is"
+		if len(protected) > 1 {
+			verb = "are"
+		}
+		skippedNotice := fmt.Sprintf("✗ %s %s %s", strings.Join(protected, ", "), verb, protectedMessage)
+		if len(pulls) <= 1 {
+			m.notice = skippedNotice
+		} else {
+			m.notice += " · " + skippedNotice
+		}
+	}
+	if len(pulls) == 1 {
+		return m, pullRepoCmd(pulls[0], m.baseDir)
+	}
+	if len(pulls) == 0 {
+		return m, nil
+	}
+	return m, func() tea.Msg {
+		paths := make([]string, len(pulls))
+		for i, repo := range pulls {
+			paths[i] = repo.RepoPath
+		}
+		return pullPrecheckMsg{repos: pulls, hosts: checkRemoteHosts(paths)}
+	}
+}
+
+// startPulls pulls the repos whose host passed the precheck
+func (m model) startPulls(msg pullPrecheckMsg) (model, tea.Cmd) {
+	blocked := unreachableRepos(msg.hosts)
+	var cmds []tea.Cmd
+	for _, repo := range msg.repos {
+		if blocked[repo.RepoPath] != nil {
+			delete(m.busy, repo.RepoPath)
+			continue
+		}
+		cmds = append(cmds, pullRepoCmd(repo, m.baseDir))
+	}
+	if hosts := failingHostNames(msg.hosts); len(hosts) > 0 {
+		m.notice = fmt.Sprintf("⚠ Skipped %d repositories, can't reach or log in to %s (see `auth check`)", len(blocked), strings.Join(hosts, ", "))
+	} else if len(blocked) > 0 {
+		m.notice = fmt.Sprintf("⚠ Skipped %d repositories whose origin can't be reached or logged in to (see `auth check`)", len(blocked))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// hideTargets drops the selection from the list until the TUI restarts
+func (m model) hideTargets() model {
+	targets := m.targets()
+	for _, repo := range targets {
+		m.hidden[repo.RepoPath] = true
+	}
+	m.notice = fmt.Sprintf("Hid %d repositories for this session (\"Show hidden repos\" in the : palette brings them back)", len(targets))
+	return m.clearSelection().applySearch()
+}
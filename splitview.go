@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// splitMinWidth is the narrowest terminal the split view is used on;
+// below it the details go back to the popup
+const splitMinWidth = 100
+
+// split reports whether the list and the details are shown side by side
+func (m model) split() bool {
+	return m.splitView && m.termWidth >= splitMinWidth
+}
+
+// listWidth is how wide the repo list is drawn
+func (m model) listWidth() int {
+	if m.split() {
+		return m.termWidth / 2
+	}
+	return m.termWidth
+}
+
+// toggleSplit switches the split view on or off and loads the details of
+// the highlighted repo for it
+func (m model) toggleSplit() (model, tea.Cmd) {
+	m.splitView = !m.splitView
+	m.notice = fmt.Sprintf("Split view: %t", m.splitView)
+	if m.splitView && m.termWidth < splitMinWidth {
+		m.notice = fmt.Sprintf("Split view needs a terminal at least %d columns wide", splitMinWidth)
+	}
+	return m, m.detailCmd()
+}
+
+// splitPane renders the list next to the details of the highlighted repo,
+// both height rows tall
+func (m model) splitPane(list string, height int) string {
+	left := lipgloss.NewStyle().Width(m.listWidth()).Height(height).MaxHeight(height).Render(list)
+	width := m.termWidth - m.listWidth()
+	borderColor := lipgloss.Color("238")
+	if m.showDetail {
+		borderColor = lipgloss.Color("62") // j/k and w act on the details
+	}
+	paneStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1).
+		Width(width - 2).
+		Height(height - 2).
+		MaxHeight(height)
+	content := ""
+	if m.cursor < len(m.repos) {
+		content = m.detailText(m.repos[m.cursor], width-6)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, paneStyle.Render(content))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type stashEntry struct {
+	Ref     string // stash@{0}
+	Time    time.Time
+	Message string // "WIP on main: 1a2b3c4 subject" or the message given to git stash push
+}
+
+// repoStashes is the stash list shown in the detail view, newest first
+type repoStashes struct {
+	RepoPath string
+	Entries  []stashEntry
+}
+
+type stashMsg repoStashes
+
+func loadStashesCmd(repoPath string) tea.Cmd {
+	return func() tea.Msg {
+		stashes := repoStashes{RepoPath: repoPath}
+		out, err := runGit(repoPath, "stash", "list", "--format=%gd%x09%ct%x09%gs")
+		if err != nil || out == "" {
+			return stashMsg(stashes)
+		}
+		for _, line := range strings.Split(out, "\n") {
+			fields := strings.SplitN(line, "\t", 3)
+			if len(fields) != 3 {
+				continue
+			}
+			entry := stashEntry{Ref: fields[0], Message: fields[2]}
+			if seconds, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				entry.Time = time.Unix(seconds, 0)
+			}
+			stashes.Entries = append(stashes.Entries, entry)
+		}
+		return stashMsg(stashes)
+	}
+}
+
+// preview lists the stashes with their age, or "" when there are none
+func (s repoStashes) preview() string {
+	if len(s.Entries) == 0 {
+		return ""
+	}
+	refStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	lines := []string{fmt.Sprintf("Stashes (%d)", len(s.Entries))}
+	for _, entry := range s.Entries {
+		lines = append(lines, fmt.Sprintf("%s %s %s",
+			refStyle.Render(entry.Ref), dimStyle.Render(fmt.Sprintf("%-8s", relativeTime(entry.Time))), entry.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stashPrompt asks before S stashes or U pops a stash in the TUI
+type stashPrompt struct {
+	repo GitStatus
+	pop  bool
+}
+
+func (p stashPrompt) question() string {
+	if p.pop {
+		return fmt.Sprintf("Pop the latest stash in %s? (y/N)", displayName(p.repo))
+	}
+	return fmt.Sprintf("Stash the uncommitted changes in %s? (y/N)", displayName(p.repo))
+}
+
+// startStash asks to stash (or with pop, to pop) in the repo under the cursor
+func (m model) startStash(pop bool) model {
+	if m.cursor >= len(m.repos) {
+		return m
+	}
+	repo := m.repos[m.cursor]
+	switch {
+	case m.busy[repo.RepoPath]:
+	case isProtected(repo.RepoPath):
+		m.notice = fmt.Sprintf("✗ %s is %s", displayName(repo), protectedMessage)
+	case !pop && !repo.Dirty:
+		m.notice = fmt.Sprintf("%s has no changes to stash", displayName(repo))
+	case pop && !hasStash(repo.RepoPath):
+		m.notice = fmt.Sprintf("%s has no stash to pop", displayName(repo))
+	default:
+		m.stashPrompt = &stashPrompt{repo: repo, pop: pop}
+	}
+	return m
+}
+
+func hasStash(repoPath string) bool {
+	out, err := runGit(repoPath, "stash", "list", "-n", "1")
+	return err == nil && out != ""
+}
+
+func (m model) updateStashPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	prompt := m.stashPrompt
+	m.stashPrompt = nil
+	if msg.String() != "y" && msg.String() != "Y" {
+		m.notice = "Cancelled"
+		return m, nil
+	}
+	m.busy[prompt.repo.RepoPath] = true
+	return m, stashCmd(prompt.repo, prompt.pop, m.baseDir)
+}
+
+// stashCmd runs git stash push (untracked files included, as the dirty
+// count includes them) or git stash pop and rescans the repo. A pop that
+// conflicts leaves the stash in place, as git always does.
+func stashCmd(repo GitStatus, pop bool, baseDir string) tea.Cmd {
+	return func() tea.Msg {
+		args, done := []string{"stash", "push", "--include-untracked"}, "✓ Stashed the changes in %s"
+		if pop {
+			args, done = []string{"stash", "pop"}, "✓ Popped the latest stash in %s"
+		}
+		notice := fmt.Sprintf(done, displayName(repo))
+		if out, err := runGit(repo.RepoPath, args...); err != nil {
+			lines := strings.Split(err.Error(), "\n")
+			notice = fmt.Sprintf("✗ git %s failed for %s: %s", strings.Join(args, " "), displayName(repo), lines[len(lines)-1])
+		} else if strings.Contains(out, "No local changes to save") {
+			notice = fmt.Sprintf("Nothing was stashed in %s, it has no local changes", displayName(repo))
+		}
+		return repoStatusMsg{status: getGitStatus(repo.RepoPath, baseDir, nil), notice: notice}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statusGroups are the TUI list sections for display.group_by_status, in
+// display order
+var statusGroups = []struct {
+	Key   string
+	Title string
+}{
+	{"integrity", "Integrity check failed"},
+	{"remote-gone", "Remote gone"},
+	{"error", "Errors"},
+	{"auth-required", "Auth required"},
+	{"dirty", "Uncommitted"},
+	{"diverged", "Diverged"},
+	{"behind", "Behind"},
+	{"ahead", "Ahead"},
+	{"no-upstream", "No upstream"},
+	{"no-remote", "No remote"},
+	{"clean", "Clean"},
+}
+
+// statusGroup is the section a repo is listed under. It follows the
+// symbol, so a dirty repo is Uncommitted even when it is also ahead.
+func statusGroup(repo GitStatus) string {
+	if repo.Symbol == "✗" {
+		return "dirty"
+	}
+	return repo.statusKey()
+}
+
+func statusGroupOrder(key string) int {
+	for i, group := range statusGroups {
+		if group.Key == key {
+			return i
+		}
+	}
+	return len(statusGroups)
+}
+
+// listRow is one line of the repo list: a section header when group is
+// set, a tree directory when dir is, otherwise the repo at index repo of
+// m.repos. label is the tree-drawn name in the tree view.
+type listRow struct {
+	group string
+	dir   string
+	repo  int
+	label string
+}
+
+func (r listRow) isRepo() bool {
+	return r.group == "" && r.dir == ""
+}
+
+// listRows lays out the list. Without grouping it is just the repos;
+// with it every non-empty section gets a header, and collapsed sections
+// show only that. The tree view takes precedence over grouping.
+func (m model) listRows() []listRow {
+	if m.treeView {
+		return m.treeRows()
+	}
+	rows := make([]listRow, 0, len(m.repos)+len(statusGroups))
+	if !m.grouping {
+		for i := range m.repos {
+			rows = append(rows, listRow{repo: i})
+		}
+		return rows
+	}
+	next := 0
+	for _, group := range statusGroups {
+		if m.groupSizes[group.Key] == 0 {
+			continue
+		}
+		rows = append(rows, listRow{group: group.Key})
+		for next < len(m.repos) && statusGroup(m.repos[next]) == group.Key {
+			rows = append(rows, listRow{repo: next})
+			next++
+		}
+	}
+	return rows
+}
+
+// cursorRow is the row the cursor is on
+func (m model) cursorRow(rows []listRow) int {
+	for i, row := range rows {
+		if row.isRepo() && row.repo == m.cursor {
+			return i
+		}
+	}
+	return 0
+}
+
+// groupHeader renders a section header line
+func (m model) groupHeader(key string) string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62"))
+	arrow := "▾"
+	if m.collapsed[key] {
+		arrow = "▸"
+	}
+	title := key
+	if i := statusGroupOrder(key); i < len(statusGroups) {
+		title = statusGroups[i].Title
+	}
+	return headerStyle.Render(fmt.Sprintf("%s %s (%d)", arrow, title, m.groupSizes[key]))
+}
+
+// collapseGroup folds the section the cursor is in, leaving the cursor on
+// the first repo after it
+func (m model) collapseGroup() model {
+	if !m.grouping || m.cursor >= len(m.repos) {
+		return m
+	}
+	m.collapsed[statusGroup(m.repos[m.cursor])] = true
+	cursor := m.cursor
+	m = m.applySearch()
+	m.cursor = max(0, min(cursor, len(m.repos)-1))
+	return m
+}
+
+// expandGroup unfolds the collapsed section nearest the cursor, looking up
+// the list first and then down
+func (m model) expandGroup() model {
+	if !m.grouping {
+		return m
+	}
+	rows := m.listRows()
+	row := m.cursorRow(rows)
+	if m.cursor >= len(m.repos) {
+		row = -1 // everything is collapsed
+	}
+	for _, step := range []int{-1, 1} {
+		for i := row + step; i >= 0 && i < len(rows); i += step {
+			if key := rows[i].group; m.collapsed[key] {
+				delete(m.collapsed, key)
+				return m.applySearch()
+			}
+		}
+	}
+	return m
+}
+
+// toggleAllGroups expands every collapsed section, or collapses them all
+// when none is
+func (m model) toggleAllGroups() model {
+	if !m.grouping {
+		return m
+	}
+	if len(m.collapsed) > 0 {
+		m.collapsed = make(map[string]bool)
+	} else {
+		for _, group := range statusGroups {
+			m.collapsed[group.Key] = true
+		}
+	}
+	return m.applySearch()
+}
+
+// groupRepos orders repos by section, keeping the sort within each, counts
+// the sections and drops the collapsed ones
+func (m model) groupRepos(repos []GitStatus) ([]GitStatus, map[string]int) {
+	sort.SliceStable(repos, func(i, j int) bool {
+		return statusGroupOrder(statusGroup(repos[i])) < statusGroupOrder(statusGroup(repos[j]))
+	})
+	sizes := make(map[string]int)
+	var open []GitStatus
+	for _, repo := range repos {
+		key := statusGroup(repo)
+		sizes[key]++
+		if !m.collapsed[key] {
+			open = append(open, repo)
+		}
+	}
+	return open, sizes
+}
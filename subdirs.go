@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --subdirs treats the top-level directories of one repo, a monorepo's
+// subprojects, the way a scan treats repos: each gets the dirtiness, the
+// commits to push or pull and the last commit of its own files.
+
+// subdirWorkers is how many directories are measured at once
+const subdirWorkers = 8
+
+// subdirRepo is what all of a repo's subdirectories share
+type subdirRepo struct {
+	prefix        string // baseDir relative to the top of the repo, with a trailing /
+	branch        string
+	defaultBranch string
+	hasRemote     bool
+	upstream      bool
+	changes       map[string][]string // porcelain lines by subdirectory
+}
+
+func loadSubdirRepo(baseDir string) (*subdirRepo, error) {
+	prefix, err := runGit(baseDir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("--subdirs needs a git repository: %s", lastLine(prefix))
+	}
+	repo := &subdirRepo{prefix: prefix, changes: map[string][]string{}}
+	if out, err := runGit(baseDir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		repo.branch = out
+	}
+	if out, err := runGit(baseDir, "remote"); err == nil {
+		repo.hasRemote = out != ""
+	}
+	_, err = runGit(baseDir, "rev-parse", "--verify", "--quiet", "@{u}")
+	repo.upstream = err == nil
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	repo.defaultBranch = detectDefaultBranch(ctx, baseDir)
+
+	// porcelain paths are relative to the top of the repo whatever the
+	// working directory. runGit would trim the first entry's status column.
+	out, err := gitCommand(ctx, "-C", baseDir, "status", "--porcelain", "-z", "--", ".").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git status failed: %v", err)
+	}
+	repo.changes = subdirChanges(string(out), prefix)
+	return repo, nil
+}
+
+// subdirChanges files git status --porcelain -z entries under the
+// top-level directory they are in, below prefix. Each becomes a one-line
+// "XY path" entry for classifyStatus, even when the name has a newline.
+func subdirChanges(porcelain, prefix string) map[string][]string {
+	changes := map[string][]string{}
+	entries := strings.Split(porcelain, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		code, path := entry[:2], entry[3:]
+		if code[0] == 'R' || code[0] == 'C' {
+			i++ // the next entry is the name it was renamed or copied from
+		}
+		path, ok := strings.CutPrefix(path, prefix)
+		if !ok {
+			continue
+		}
+		if dir, _, ok := strings.Cut(path, "/"); ok {
+			changes[dir] = append(changes[dir], code+" "+strings.ReplaceAll(path, "\n", `\n`))
+		}
+	}
+	return changes
+}
+
+// subdirStatus measures one subdirectory of baseDir
+func (r *subdirRepo) subdirStatus(baseDir, dir string) GitStatus {
+	status := GitStatus{
+		RepoPath:      filepath.Join(baseDir, dir),
+		RelativePath:  dir,
+		Branch:        r.branch,
+		DefaultBranch: r.defaultBranch,
+		HasRemote:     r.hasRemote,
+	}
+	ahead, behind := "", ""
+	if r.upstream {
+		if out, err := runGit(baseDir, "rev-list", "--count", "@{u}..HEAD", "--", dir); err == nil {
+			ahead = out
+		}
+		if out, err := runGit(baseDir, "rev-list", "--count", "HEAD..@{u}", "--", dir); err == nil {
+			behind = out
+		}
+	}
+	if out, err := runGit(baseDir, "log", "-1", "--pretty="+lastCommitFormat, "--", dir); err == nil {
+		status.LastCommit, status.LastAuthor, status.LastCommitAt = parseLastCommit(out)
+	}
+	status.ModTime = status.LastCommitAt // so the default sort puts recent activity first
+	classifyStatus(&status, strings.Join(r.changes[dir], "\n"), ahead, behind)
+	addDiffstat(context.Background(), &status)
+	return status
+}
+
+// validateSubdirsRoot checks that --subdirs was pointed at a repo
+func validateSubdirsRoot(dir string) error {
+	if out, err := runGit(dir, "rev-parse", "--is-inside-work-tree"); err != nil || out != "true" {
+		return fmt.Errorf("--subdirs needs a git repository, and %s isn't inside one", dir)
+	}
+	return nil
+}
+
+// scanSubdirs stands in for the repo scan under --subdirs
+func scanSubdirs(baseDir string) []GitStatus {
+	repo, err := loadSubdirRepo(baseDir)
+	if err != nil {
+		return []GitStatus{{RepoPath: baseDir, Symbol: "⚠", Message: err.Error()}}
+	}
+	dirs, err := runGitZ(baseDir, "ls-tree", "-z", "-d", "--name-only", "HEAD")
+	if err != nil {
+		return []GitStatus{{RepoPath: baseDir, Symbol: "⚠", Message: lastLine(err.Error())}}
+	}
+
+	statuses := make([]GitStatus, len(dirs))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, subdirWorkers)
+	for i, dir := range dirs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			statuses[i] = repo.subdirStatus(baseDir, dir)
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].ModTime.After(statuses[j].ModTime)
+	})
+	return statuses
+}
+
+// rescanSubdir stands in for getGitStatus under --subdirs, when one entry
+// is refreshed after a fetch or pull
+func rescanSubdir(path, baseDir string) GitStatus {
+	repo, err := loadSubdirRepo(baseDir)
+	if err != nil {
+		return GitStatus{RepoPath: path, Symbol: "⚠", Message: err.Error()}
+	}
+	dir, _ := filepath.Rel(baseDir, path)
+	return repo.subdirStatus(baseDir, dir)
+}
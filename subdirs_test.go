@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubdirChanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		porcelain string
+		prefix    string
+		want      map[string][]string
+	}{
+		{"clean", "", "", map[string][]string{}},
+		{
+			"modified and untracked",
+			" M api/main.go\x00?? web/new.js\x00",
+			"",
+			map[string][]string{"api": {" M api/main.go"}, "web": {"?? web/new.js"}},
+		},
+		{
+			"top-level files belong to no subdirectory",
+			" M README.md\x00A  api/x.go\x00",
+			"",
+			map[string][]string{"api": {"A  api/x.go"}},
+		},
+		{
+			"rename source is not an entry of its own",
+			"R  web/new.js\x00api/old.js\x00 M api/y.go\x00",
+			"",
+			map[string][]string{"web": {"R  web/new.js"}, "api": {" M api/y.go"}},
+		},
+		{
+			"copy source skipped too",
+			"C  web/copy.js\x00web/orig.js\x00",
+			"",
+			map[string][]string{"web": {"C  web/copy.js"}},
+		},
+		{
+			"below a prefix",
+			" M services/api/main.go\x00 M tools/lint.go\x00",
+			"services/",
+			map[string][]string{"api": {" M api/main.go"}},
+		},
+		{
+			"newlines in names stay on one line",
+			"?? api/odd\nname\x00",
+			"",
+			map[string][]string{"api": {`?? api/odd\nname`}},
+		},
+		{
+			"spaces and arrows are not renames",
+			" M api/a -> b.txt\x00",
+			"",
+			map[string][]string{"api": {" M api/a -> b.txt"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := subdirChanges(tt.porcelain, tt.prefix); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: subdirChanges = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statusTab narrows the list to one class of problem. States use the names
+// --only accepts, so a diverged repo shows under both Ahead and Behind.
+type statusTab struct {
+	Name   string
+	States []string
+}
+
+var statusTabs = []statusTab{
+	{"All", nil},
+	{"Dirty", []string{"dirty"}},
+	{"Ahead", []string{"ahead"}},
+	{"Behind", []string{"behind"}},
+	{"Errors", []string{"error", "auth-required", "remote-gone", "integrity"}},
+}
+
+func (t statusTab) includes(repo GitStatus) bool {
+	return t.States == nil || repoInStates(repo, t.States)
+}
+
+// switchTab shows tab i, wrapping around at either end
+func (m model) switchTab(i int) model {
+	m.tab = (i + len(statusTabs)) % len(statusTabs)
+	return m.applySearch()
+}
+
+// tabBar is the header line listing the tabs with their repo counts. Counts
+// ignore the search so they say what each tab holds.
+func (m model) tabBar() string {
+	activeStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("62")).Padding(0, 1)
+	tabStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Padding(0, 1)
+
+	var tabs []string
+	for i, tab := range statusTabs {
+		count := 0
+		for _, repo := range m.scanned {
+			if !m.isHidden(repo) && tab.includes(repo) {
+				count++
+			}
+		}
+		label := fmt.Sprintf("%d %s %d", i+1, tab.Name, count)
+		if i == m.tab {
+			tabs = append(tabs, activeStyle.Render(label))
+		} else {
+			tabs = append(tabs, tabStyle.Render(label))
+		}
+	}
+	return lipgloss.NewStyle().MaxWidth(m.termWidth).Render(strings.Join(tabs, " "))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// describeTag splits `git describe --tags --long` output (v1.2.0-4-gabc1234)
+// into the tag and the number of commits since it
+func describeTag(described string) (string, int) {
+	parts := strings.Split(described, "-")
+	if len(parts) < 3 {
+		return "", 0
+	}
+	count, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil {
+		return "", 0
+	}
+	return strings.Join(parts[:len(parts)-2], "-"), count
+}
+
+// enrichTags fills in LatestTag and SinceTag. It costs an extra git call
+// per repo, so it only runs when a tag column or sort asks for it.
+func enrichTags(repos []GitStatus) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i := range repos {
+		if repos[i].Symbol == "⚠" || repos[i].Corrupt {
+			continue
+		}
+		wg.Add(1)
+		go func(repo *GitStatus) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if out, err := runGit(repo.RepoPath, "describe", "--tags", "--long", "--abbrev=7"); err == nil {
+				repo.LatestTag, repo.SinceTag = describeTag(out)
+			}
+		}(&repos[i])
+	}
+	wg.Wait()
+}
+
+// needsTags reports whether the current report options use tag information
+func needsTags() bool {
+	if config.Sort == "since-tag" {
+		return true
+	}
+	for _, column := range config.Columns {
+		if column == "tag" || column == "since-tag" {
+			return true
+		}
+	}
+	return false
+}
+
+var reportSorts = []string{"path", "since-tag", "mtime", "status", "ahead", "behind"}
+
+func validateSort(by string) error {
+	for _, known := range reportSorts {
+		if by == "" || by == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown sort %q (available: %s)", by, strings.Join(reportSorts, ", "))
+}
+
+// statusSeverity ranks symbols from most to least in need of attention
+var statusSeverity = map[string]int{"☠": 0, "⚠": 1, "✗": 2, "↕": 3, "↓": 4, "↑": 5, "✓": 6}
+
+// sortRepos orders the report. since-tag puts the most commits since the
+// last release first and untagged repos last; ahead and behind put the
+// largest counts first.
+func sortRepos(repos []GitStatus, by string) {
+	byCount := func(count func(GitStatus) int) func(i, j int) bool {
+		return func(i, j int) bool {
+			if a, b := count(repos[i]), count(repos[j]); a != b {
+				return a > b
+			}
+			return repos[i].RelativePath < repos[j].RelativePath
+		}
+	}
+
+	switch by {
+	case "mtime":
+		sort.SliceStable(repos, func(i, j int) bool { return repos[i].ModTime.After(repos[j].ModTime) })
+	case "status":
+		sort.SliceStable(repos, byCount(func(repo GitStatus) int { return -statusSeverity[repo.Symbol] }))
+	case "ahead":
+		sort.SliceStable(repos, byCount(func(repo GitStatus) int { return repo.Ahead }))
+	case "behind":
+		sort.SliceStable(repos, byCount(func(repo GitStatus) int { return repo.Behind }))
+	case "path":
+		sort.SliceStable(repos, func(i, j int) bool { return repos[i].RelativePath < repos[j].RelativePath })
+	case "since-tag":
+		sort.SliceStable(repos, func(i, j int) bool {
+			a, b := repos[i], repos[j]
+			if (a.LatestTag == "") != (b.LatestTag == "") {
+				return b.LatestTag == ""
+			}
+			if a.SinceTag != b.SinceTag {
+				return a.SinceTag > b.SinceTag
+			}
+			return a.RelativePath < b.RelativePath
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TeamConfig says where to push scans for the shared team dashboard that
+// `team serve` runs, and as whom
+type TeamConfig struct {
+	URL      string `json:"url,omitempty"`  // e.g. https://dash.example.com:8420
+	Name     string `json:"name,omitempty"` // defaults to the login name
+	Token    string `json:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+}
+
+func (t TeamConfig) token() string {
+	if t.Token != "" {
+		return t.Token
+	}
+	if t.TokenEnv != "" {
+		return os.Getenv(t.TokenEnv)
+	}
+	return ""
+}
+
+func (t TeamConfig) name() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// teamReport is one machine's scan of one directory, as pushed to the team
+// dashboard. Who sent it comes from the credentials, not the report.
+type teamReport struct {
+	Name      string      `json:"name"`
+	Host      string      `json:"host"`
+	Root      string      `json:"root"`
+	ScannedAt time.Time   `json:"scanned_at"`
+	Repos     []GitStatus `json:"repos"`
+}
+
+// maxTeamReport caps the size of a pushed report
+const maxTeamReport = 8 << 20
+
+// maxTeamReports caps how many machine and directory reports each teammate
+// keeps on the dashboard; a new one replaces their oldest
+const maxTeamReports = 20
+
+// teamStaleAfter is when a report is marked as old on the dashboard
+const teamStaleAfter = 2 * time.Hour
+
+// teamDir holds the dashboard's teammates and the reports they pushed
+func teamDir() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "team"), nil
+}
+
+// teamTokens are the teammates allowed to push, by name, with the SHA-256
+// of their token. The tokens themselves are never stored.
+func loadTeamTokens() (map[string]string, error) {
+	dir, err := teamDir()
+	if err != nil {
+		return nil, err
+	}
+	tokens := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(dir, "tokens.json"))
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tokens, json.Unmarshal(data, &tokens)
+}
+
+func hashTeamToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// runTeamAdd lets name push to the dashboard served from this machine,
+// printing a new token for them. Adding a name again replaces its token.
+func runTeamAdd(name string) error {
+	if name == "" || strings.ContainsAny(name, ":/") {
+		return fmt.Errorf("%q can't be used as a name", name)
+	}
+	tokens, err := loadTeamTokens()
+	if err != nil {
+		return err
+	}
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(secret)
+	tokens[name] = hashTeamToken(token)
+
+	dir, err := teamDir()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "tokens.json"), data, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("✓ %s can push to the team dashboard. Their token, shown only this once:\n\n  %s\n\n", name, token)
+	fmt.Println("On their machine:")
+	fmt.Println("  git-status-dash config set team.url <this dashboard's URL>")
+	fmt.Printf("  git-status-dash config set team.name %s\n", name)
+	fmt.Println("  git-status-dash config set team.token_env GSD_TEAM_TOKEN   # and export the token as GSD_TEAM_TOKEN")
+	return nil
+}
+
+// teamServer is `team serve`: it keeps the latest report per teammate,
+// machine and directory, on disk so a restart doesn't lose them. Tokens
+// are read on every request, so `team add` and removing a teammate from
+// tokens.json take effect without a restart.
+type teamServer struct {
+	mu      sync.Mutex
+	reports map[string]teamReport
+	path    string
+}
+
+func reportKey(r teamReport) string {
+	return r.Name + "\x00" + r.Host + "\x00" + r.Root
+}
+
+// authenticate checks HTTP basic auth: the teammate's name and token
+func (s *teamServer) authenticate(r *http.Request) (string, bool) {
+	name, token, ok := r.BasicAuth()
+	if !ok {
+		return "", false
+	}
+	tokens, err := loadTeamTokens()
+	if err != nil {
+		return "", false
+	}
+	want, known := tokens[name]
+	got := hashTeamToken(token)
+	if !known || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+		return "", false
+	}
+	return name, true
+}
+
+func (s *teamServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := s.authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="git-status-dash team"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.URL.Path == "/api/reports" && r.Method == http.MethodPost:
+		s.receive(w, r, name)
+	case r.URL.Path == "/" && r.Method == http.MethodGet:
+		s.render(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *teamServer) receive(w http.ResponseWriter, r *http.Request, name string) {
+	var report teamReport
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTeamReport)).Decode(&report); err != nil {
+		http.Error(w, "bad report: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	report.Name = name
+	if report.ScannedAt.IsZero() {
+		report.ScannedAt = time.Now().UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := reportKey(report)
+	if _, known := s.reports[key]; !known {
+		s.dropOldest(name, maxTeamReports-1)
+	}
+	s.reports[key] = report
+	if data, err := json.Marshal(s.reports); err == nil {
+		writeFileAtomic(s.path, data, 0600)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dropOldest removes name's oldest reports until at most keep are left
+func (s *teamServer) dropOldest(name string, keep int) {
+	var keys []string
+	for key, report := range s.reports {
+		if report.Name == name {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return s.reports[keys[i]].ScannedAt.After(s.reports[keys[j]].ScannedAt) })
+	for _, key := range keys[min(keep, len(keys)):] {
+		delete(s.reports, key)
+	}
+}
+
+// teamRow is one repo with work that hasn't reached its remote
+type teamRow struct {
+	Repo, Name, Host, Branch, Work, Reported string
+	Stale                                    bool
+}
+
+// pendingWork reports whether a repo has something only its machine has:
+// uncommitted changes, commits to push or a branch never pushed
+func pendingWork(repo GitStatus) bool {
+	return repo.Dirty || repo.Ahead > 0 || (repo.HasRemote && !repo.HasUpstream)
+}
+
+var teamPage = template.Must(template.New("team").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta http-equiv="refresh" content="60">
+<title>Team git status</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: .3em .8em; border-bottom: 1px solid #ddd; }
+.stale { color: #999; }
+</style></head><body>
+<h1>Unpushed work across the team</h1>
+{{if .Rows}}<table>
+<tr><th>Repository</th><th>Who</th><th>Machine</th><th>Branch</th><th>Work</th><th>Reported</th></tr>
+{{range .Rows}}<tr{{if .Stale}} class="stale"{{end}}><td>{{.Repo}}</td><td>{{.Name}}</td><td>{{.Host}}</td><td>{{.Branch}}</td><td>{{.Work}}</td><td>{{.Reported}}</td></tr>
+{{end}}</table>{{else}}<p>Nobody has unpushed work.</p>{{end}}
+<p>{{.Machines}} machine(s) reporting.</p>
+</body></html>
+`))
+
+func (s *teamServer) render(w http.ResponseWriter) {
+	tokens, _ := loadTeamTokens()
+	s.mu.Lock()
+	var rows []teamRow
+	machines := 0
+	for _, report := range s.reports {
+		if _, known := tokens[report.Name]; !known {
+			continue // removed from the team
+		}
+		machines++
+		for _, repo := range report.Repos {
+			if !pendingWork(repo) {
+				continue
+			}
+			rows = append(rows, teamRow{
+				Repo:     displayName(repo),
+				Name:     report.Name,
+				Host:     report.Host,
+				Branch:   repo.Branch,
+				Work:     statusLabel(repo),
+				Reported: relativeTime(report.ScannedAt),
+				Stale:    time.Since(report.ScannedAt) > teamStaleAfter,
+			})
+		}
+	}
+	s.mu.Unlock()
+
+	// by repo, so everyone holding work on the same repo is listed together
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Repo != rows[j].Repo {
+			return rows[i].Repo < rows[j].Repo
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	teamPage.Execute(w, struct {
+		Rows     []teamRow
+		Machines int
+	}{rows, machines})
+}
+
+// runTeamServe serves the team dashboard on addr, over TLS when a
+// certificate is given
+func runTeamServe(addr, certFile, keyFile string) error {
+	tokens, err := loadTeamTokens()
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("nobody can push yet; add teammates with `git-status-dash team add <name>`")
+	}
+	dir, err := teamDir()
+	if err != nil {
+		return err
+	}
+	server := &teamServer{reports: map[string]teamReport{}, path: filepath.Join(dir, "reports.json")}
+	if data, err := os.ReadFile(server.path); err == nil {
+		json.Unmarshal(data, &server.reports)
+	}
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		WriteTimeout:      time.Minute,
+		IdleTimeout:       2 * time.Minute,
+	}
+	if certFile != "" {
+		fmt.Printf("Serving the team dashboard on https://%s\n", addr)
+		return httpServer.ListenAndServeTLS(certFile, keyFile)
+	}
+	fmt.Printf("Serving the team dashboard on http://%s (tokens travel in the clear; use --cert or a TLS proxy)\n", addr)
+	return httpServer.ListenAndServe()
+}
+
+// pushTeamReport sends a scan of root to the team dashboard
+func pushTeamReport(team TeamConfig, root string, repos []GitStatus) error {
+	if team.token() == "" {
+		return fmt.Errorf("no token; set team.token_env (or team.token)")
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	body, err := json.Marshal(teamReport{Host: host, Root: root, ScannedAt: time.Now().UTC(), Repos: repos})
+	if err != nil {
+		return err
+	}
+	client, err := httpClient()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(team.URL, "/")+"/api/reports", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(team.name(), team.token())
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// runTeamPush scans dir and pushes the result to team.url
+func runTeamPush(dir string) error {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if userConfig.Team.URL == "" {
+		return fmt.Errorf("team.url isn't set")
+	}
+	repos := findGitReposOptimized(dir, config.Depth)
+	if err := pushTeamReport(userConfig.Team, dir, repos); err != nil {
+		return err
+	}
+	pending := 0
+	for _, repo := range repos {
+		if pendingWork(repo) {
+			pending++
+		}
+	}
+	fmt.Printf("✓ Pushed %d repositories (%d with unpushed work) to %s\n", len(repos), pending, userConfig.Team.URL)
+	return nil
+}
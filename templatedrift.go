@@ -0,0 +1,325 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// templateMarker says which template a repo was generated from and at
+// which version: a tag, or a commit for generators that record one
+type templateMarker struct {
+	File     string // the marker it was read from, relative to the repo
+	Template string // URL or path of the template repo
+	Version  string
+}
+
+// .template-version is this tool's own marker:
+//
+//	template: https://github.com/acme/service-template
+//	version: v1.4.0
+//
+// copier and cruft record the same thing in their own files. Files named
+// under "template_markers" in the config are read like .template-version.
+var templateMarkerFiles = []string{".template-version", ".copier-answers.yml", ".cruft.json"}
+
+// semverTag matches the release tags the latest template version is picked
+// from
+var semverTag = regexp.MustCompile(`^v?\d+\.\d+\.\d+$`)
+
+// readTemplateMarker looks for a marker in repoPath. It returns nil when the
+// repo wasn't made from a template.
+func readTemplateMarker(repoPath string, extra []string) (*templateMarker, error) {
+	for _, name := range append(append([]string(nil), templateMarkerFiles...), extra...) {
+		data, err := os.ReadFile(filepath.Join(repoPath, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		marker := &templateMarker{File: name}
+		switch name {
+		case ".cruft.json":
+			var cruft struct {
+				Template string `json:"template"`
+				Commit   string `json:"commit"`
+			}
+			if err := json.Unmarshal(data, &cruft); err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			marker.Template, marker.Version = cruft.Template, cruft.Commit
+		case ".copier-answers.yml":
+			fields := markerFields(string(data))
+			marker.Template, marker.Version = fields["_src_path"], fields["_commit"]
+		default:
+			fields := markerFields(string(data))
+			marker.Template, marker.Version = fields["template"], fields["version"]
+		}
+		if marker.Template == "" || marker.Version == "" {
+			return nil, fmt.Errorf("%s doesn't say both the template and its version", name)
+		}
+		if strings.HasPrefix(marker.Template, "-") || strings.HasPrefix(marker.Version, "-") {
+			return nil, fmt.Errorf("%s names a template or version starting with -", name)
+		}
+		marker.Template = expandTemplateSource(marker.Template)
+		return marker, nil
+	}
+	return nil, nil
+}
+
+// markerFields reads "key: value" (or key = value) lines, ignoring comments
+// and quotes
+func markerFields(text string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if eq, after, found := strings.Cut(line, "="); found && (!ok || len(eq) < len(key)) {
+			key, value, ok = eq, after, true
+		}
+		if !ok {
+			continue
+		}
+		fields[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return fields
+}
+
+// expandTemplateSource turns copier's gh: and gl: shorthands into URLs
+func expandTemplateSource(source string) string {
+	switch {
+	case strings.HasPrefix(source, "gh:"):
+		return "https://github.com/" + strings.TrimPrefix(source, "gh:")
+	case strings.HasPrefix(source, "gl:"):
+		return "https://gitlab.com/" + strings.TrimPrefix(source, "gl:")
+	}
+	return source
+}
+
+// templateHosts are the hosts a template may be fetched from: github.com,
+// gitlab.com, the configured forges' and template_hosts
+func templateHosts(userConfig *UserConfig) map[string]bool {
+	hosts := map[string]bool{"github.com": true, "gitlab.com": true}
+	for _, forge := range userConfig.Forges {
+		hosts[strings.ToLower(forge.host())] = true
+	}
+	for _, host := range userConfig.TemplateHosts {
+		hosts[strings.ToLower(host)] = true
+	}
+	return hosts
+}
+
+// checkTemplateSource refuses templates that aren't an https or ssh URL on
+// an allowed host. The source comes from a file in the repo, so it could
+// otherwise point git at any URL, local path or transport.
+func checkTemplateSource(template string, hosts map[string]bool) error {
+	_, host, _ := strings.Cut(remoteHostName(template), " ")
+	if host == "" || !hosts[host] {
+		return fmt.Errorf("%s isn't on an allowed host (see `config set template_hosts`)", template)
+	}
+	return nil
+}
+
+// templateCache is a bare clone of a template, kept in the config directory
+// so later runs only fetch what's new
+func templateCache(template string) (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(template))
+	return filepath.Join(configDir, "templates", hex.EncodeToString(sum[:8])+".git"), nil
+}
+
+// fetchTemplate brings the cached clone of template up to date. When the
+// fetch fails but an older copy exists, that copy is used and the error is
+// returned as a warning.
+func fetchTemplate(template string) (cache string, warning error, err error) {
+	cache, err = templateCache(template)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, statErr := os.Stat(cache); os.IsNotExist(statErr) {
+		if err := os.MkdirAll(cache, 0755); err != nil {
+			return "", nil, err
+		}
+		if out, err := runGit(cache, "init", "--bare", "--quiet"); err != nil {
+			os.RemoveAll(cache)
+			return "", nil, fmt.Errorf("%s", out)
+		}
+	}
+	out, fetchErr := runGit(cache, "fetch", "--quiet", "--force", "--prune", "--tags", "--", template, "+refs/heads/*:refs/heads/*")
+	if fetchErr == nil {
+		return cache, nil, nil
+	}
+	if fetchErr != errOffline {
+		fetchErr = fmt.Errorf("%s", lastLine(out))
+	}
+	if _, err := runGit(cache, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil || hasTags(cache) {
+		return cache, fetchErr, nil
+	}
+	return "", nil, fetchErr
+}
+
+func hasTags(cache string) bool {
+	out, err := runGit(cache, "tag", "--list")
+	return err == nil && out != ""
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return lines[len(lines)-1]
+}
+
+// latestTemplateTag is the highest release tag of the template
+func latestTemplateTag(cache string) (string, error) {
+	out, err := runGit(cache, "tag", "--list", "--sort=-v:refname")
+	if err != nil {
+		return "", fmt.Errorf("%s", out)
+	}
+	for _, tag := range strings.Split(out, "\n") {
+		if semverTag.MatchString(tag) {
+			return tag, nil
+		}
+	}
+	return "", fmt.Errorf("the template has no release tags (like v1.2.3)")
+}
+
+// templateDrift is how far a repo's template version is behind the latest
+type templateDrift struct {
+	Latest   string
+	Commits  []string // one line each, newest first
+	Releases []string // tags between the two versions, oldest first
+	Files    string   // git's --shortstat summary
+}
+
+func measureTemplateDrift(cache, version string) (*templateDrift, error) {
+	latest, err := latestTemplateTag(cache)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runGit(cache, "rev-parse", "--verify", "--quiet", version+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("version %s isn't in the template", version)
+	}
+	drift := &templateDrift{Latest: latest}
+	out, err := runGit(cache, "log", "--no-merges", "--format=%h %s", version+".."+latest)
+	if err != nil {
+		return nil, fmt.Errorf("%s", out)
+	}
+	if out != "" {
+		drift.Commits = strings.Split(out, "\n")
+	}
+	if len(drift.Commits) == 0 {
+		return drift, nil
+	}
+	if out, err := runGit(cache, "tag", "--list", "--sort=v:refname", "--contains", version, "--merged", latest); err == nil {
+		for _, tag := range strings.Split(out, "\n") {
+			if semverTag.MatchString(tag) && tag != version {
+				drift.Releases = append(drift.Releases, tag)
+			}
+		}
+	}
+	drift.Files, _ = runGit(cache, "diff", "--shortstat", version, latest)
+	return drift, nil
+}
+
+// maxDriftCommits is how many of the template's commits --changes lists
+// per repo
+const maxDriftCommits = 15
+
+// runTemplateDrift checks every repo made from a template against the
+// template's latest release. It reports whether any repo is behind.
+func runTemplateDrift(baseDir string, changes bool) (bool, error) {
+	userConfig, err := loadConfig()
+	if err != nil {
+		return false, err
+	}
+
+	type fetched struct {
+		cache   string
+		warning error
+		err     error
+	}
+	caches := map[string]fetched{} // templates are shared, so each is fetched once
+	hosts := templateHosts(userConfig)
+	checked, behind := 0, 0
+	for _, repo := range findGitReposOptimized(baseDir, config.Depth) {
+		name := displayName(repo)
+		marker, err := readTemplateMarker(repo.RepoPath, userConfig.TemplateMarkers)
+		if err != nil {
+			fmt.Printf("⚠ %-30s %v\n", name, err)
+			continue
+		}
+		if marker == nil {
+			continue
+		}
+		checked++
+
+		if err := checkTemplateSource(marker.Template, hosts); err != nil {
+			fmt.Printf("⚠ %-30s %v\n", name, err)
+			continue
+		}
+		f, ok := caches[marker.Template]
+		if !ok {
+			f.cache, f.warning, f.err = fetchTemplate(marker.Template)
+			caches[marker.Template] = f
+			if f.warning != nil {
+				fmt.Printf("⚠ Couldn't fetch %s, using the copy from an earlier run: %v\n", marker.Template, f.warning)
+			}
+		}
+		if f.err != nil {
+			fmt.Printf("⚠ %-30s cannot read %s: %v\n", name, marker.Template, f.err)
+			continue
+		}
+
+		drift, err := measureTemplateDrift(f.cache, marker.Version)
+		if err != nil {
+			fmt.Printf("⚠ %-30s %v\n", name, err)
+			continue
+		}
+		if len(drift.Commits) == 0 {
+			fmt.Printf("✓ %-30s %s is the latest template version\n", name, marker.Version)
+			continue
+		}
+
+		behind++
+		summary := strconv.Itoa(len(drift.Commits)) + " commit(s)"
+		if len(drift.Releases) > 0 {
+			summary = fmt.Sprintf("%d release(s), %s", len(drift.Releases), summary)
+		}
+		fmt.Printf("✗ %-30s %s → %s, %s behind (%s)\n", name, marker.Version, drift.Latest, summary, marker.File)
+		if !changes {
+			continue
+		}
+		if len(drift.Releases) > 0 {
+			fmt.Printf("    releases: %s\n", strings.Join(drift.Releases, ", "))
+		}
+		if drift.Files != "" {
+			fmt.Printf("    %s\n", drift.Files)
+		}
+		for i, commit := range drift.Commits {
+			if i == maxDriftCommits {
+				fmt.Printf("    … and %d more\n", len(drift.Commits)-maxDriftCommits)
+				break
+			}
+			fmt.Printf("    %s\n", commit)
+		}
+	}
+
+	if checked == 0 {
+		fmt.Printf("No repos made from a template found (looked for %s).\n", strings.Join(append(append([]string(nil), templateMarkerFiles...), userConfig.TemplateMarkers...), ", "))
+		return false, nil
+	}
+	fmt.Printf("\n%d of %d repos behind their template\n", behind, checked)
+	return behind > 0, nil
+}
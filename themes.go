@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -598,9 +597,16 @@ func downloadTheme(sourceName string) error {
 		return fmt.Errorf("theme source '%s' not found", sourceName)
 	}
 	
+	if offline {
+		return errOffline
+	}
 	fmt.Printf("Downloading theme from %s...\n", source.Name)
 	
-	resp, err := http.Get(source.URL)
+	client, err := httpClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.Get(source.URL)
 	if err != nil {
 		return fmt.Errorf("failed to download theme: %v", err)
 	}
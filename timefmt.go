@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// strftimeLayouts maps strftime directives to Go layout fragments
+var strftimeLayouts = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'p': "PM",
+	'b': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday",
+	'Z': "MST", 'z': "-0700", 'F': "2006-01-02", 'T': "15:04:05",
+}
+
+// strftime formats t with a strftime-style format such as "%Y-%m-%d %H:%M"
+func strftime(t time.Time, format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		if format[i] == '%' {
+			b.WriteByte('%')
+		} else if layout, ok := strftimeLayouts[format[i]]; ok {
+			b.WriteString(t.Format(layout))
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(format[i])
+		}
+	}
+	return b.String()
+}
+
+func relativeTime(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	}
+	return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
+}
+
+// formatTimestamp renders t per display.time_format: "relative" (or empty)
+// for "2h ago", a strftime format when it contains %, else a Go layout
+func formatTimestamp(t time.Time, format string) string {
+	switch {
+	case t.IsZero():
+		return "-"
+	case format == "" || format == "relative":
+		return relativeTime(t)
+	case strings.Contains(format, "%"):
+		return strftime(t, format)
+	}
+	return t.Format(format)
+}
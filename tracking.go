@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type trackedRemote struct {
+	Name  string
+	Fetch string
+	Push  string // only set when it differs from Fetch
+}
+
+// repoTracking is what the detail view shows about where a repo's branch
+// syncs with
+type repoTracking struct {
+	RepoPath string
+	Branch   string
+	Upstream string   // "origin/main", empty without one
+	Config   []string // branch.<name>.* and remote.pushDefault settings, "key value"
+	Remotes  []trackedRemote
+}
+
+type trackingMsg repoTracking
+
+func loadTrackingCmd(repoPath string) tea.Cmd {
+	return func() tea.Msg {
+		tracking := repoTracking{RepoPath: repoPath}
+		// runGit hands back git's complaint as the output when it fails
+		if branch, err := runGit(repoPath, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+			tracking.Branch = branch
+		}
+		if upstream, err := runGit(repoPath, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}"); err == nil {
+			tracking.Upstream = upstream
+		}
+
+		pattern := `^remote\.pushdefault$`
+		if tracking.Branch != "" {
+			pattern = `^(branch\.` + regexpQuote(tracking.Branch) + `\.|remote\.pushdefault$)`
+		}
+		if out, err := runGit(repoPath, "config", "--get-regexp", pattern); err == nil && out != "" {
+			tracking.Config = strings.Split(out, "\n")
+		}
+
+		if out, err := runGit(repoPath, "remote", "-v"); err == nil {
+			tracking.Remotes = parseRemotes(out)
+		}
+		return trackingMsg(tracking)
+	}
+}
+
+// parseRemotes reads git remote -v, one line per remote and direction:
+//
+//	origin	git@github.com:me/x.git (fetch)
+//
+// The push URL is only kept when it differs from the fetch URL.
+func parseRemotes(out string) []trackedRemote {
+	remotes := map[string]*trackedRemote{}
+	for _, line := range strings.Split(out, "\n") {
+		name, rest, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		// the direction comes last, local paths may hold spaces
+		url, kind := rest, ""
+		if i := strings.LastIndex(rest, " ("); i >= 0 {
+			url, kind = rest[:i], rest[i+1:]
+		}
+		if remotes[name] == nil {
+			remotes[name] = &trackedRemote{Name: name}
+		}
+		if kind == "(push)" {
+			remotes[name].Push = url
+		} else {
+			remotes[name].Fetch = url
+		}
+	}
+	var parsed []trackedRemote
+	for _, remote := range remotes {
+		if remote.Push == remote.Fetch {
+			remote.Push = ""
+		}
+		parsed = append(parsed, *remote)
+	}
+	sort.Slice(parsed, func(i, j int) bool { return parsed[i].Name < parsed[j].Name })
+	return parsed
+}
+
+// regexpQuote escapes a branch name for git config --get-regexp, which
+// uses POSIX extended regexps
+func regexpQuote(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`\.+*?()|[]{}^$`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (t repoTracking) preview() string {
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	lines := []string{"Tracking"}
+	switch {
+	case t.Branch == "":
+		lines = append(lines, "  Upstream: "+dimStyle.Render("none, HEAD is detached"))
+	case t.Upstream == "":
+		lines = append(lines, "  Upstream: "+dimStyle.Render(fmt.Sprintf("none (git push -u <remote> %s sets one)", t.Branch)))
+	default:
+		lines = append(lines, "  Upstream: "+t.Upstream)
+	}
+	for _, setting := range t.Config {
+		lines = append(lines, "  "+dimStyle.Render(setting))
+	}
+	if len(t.Remotes) == 0 {
+		lines = append(lines, "  Remotes: "+dimStyle.Render("none"))
+	}
+	for _, remote := range t.Remotes {
+		line := fmt.Sprintf("  %s %s", remote.Name, remote.Fetch)
+		if remote.Push != "" {
+			line += dimStyle.Render(" (push: " + remote.Push + ")")
+		}
+		lines = append(lines, line)
+	}
+	if result, ok := cachedRemoteState()[t.RepoPath]; ok && result.gone() {
+		lines = append(lines, "  "+dimStyle.Render("origin is gone: re-point it with git remote set-url origin <url>, or archive the repo"))
+	}
+	return strings.Join(lines, "\n")
+}
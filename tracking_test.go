@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRemotes(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want []trackedRemote
+	}{
+		{"none", "", nil},
+		{
+			"same push and fetch",
+			"origin\tgit@github.com:me/x.git (fetch)\norigin\tgit@github.com:me/x.git (push)",
+			[]trackedRemote{{Name: "origin", Fetch: "git@github.com:me/x.git"}},
+		},
+		{
+			"separate push url",
+			"origin\thttps://example.com/x.git (fetch)\norigin\tgit@example.com:x.git (push)",
+			[]trackedRemote{{Name: "origin", Fetch: "https://example.com/x.git", Push: "git@example.com:x.git"}},
+		},
+		{
+			"sorted by name",
+			"upstream\thttps://example.com/up.git (fetch)\nupstream\thttps://example.com/up.git (push)\n" +
+				"fork\thttps://example.com/fork.git (fetch)\nfork\thttps://example.com/fork.git (push)",
+			[]trackedRemote{{Name: "fork", Fetch: "https://example.com/fork.git"}, {Name: "upstream", Fetch: "https://example.com/up.git"}},
+		},
+		{
+			"url with spaces",
+			"local\t/home/me/my repo (fetch)\nlocal\t/home/me/my repo (push)",
+			[]trackedRemote{{Name: "local", Fetch: "/home/me/my repo"}},
+		},
+		{"junk lines skipped", "warning: something\n", nil},
+	}
+	for _, tt := range tests {
+		if got := parseRemotes(tt.out); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseRemotes = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegexpQuote(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"main", "main"},
+		{"release/1.2", `release/1\.2`},
+		{"fix(a)+b", `fix\(a\)\+b`},
+		{"[wip]*", `\[wip\]\*`},
+		{"a^b$c|d", `a\^b\$c\|d`},
+	}
+	for _, tt := range tests {
+		if got := regexpQuote(tt.in); got != tt.want {
+			t.Errorf("regexpQuote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type treeNode struct {
+	repo     *GitStatus
+	children map[string]*treeNode
+	pinned   bool // a pinned repo is at or below this node
+}
+
+// treeEntry is one rendered line: the indented name, plus the repo when
+// the line is a repo rather than an intermediate directory
+type treeEntry struct {
+	label string
+	repo  *GitStatus
+}
+
+// buildRepoTree nests repos under their directories. Nodes holding one of
+// the pinned repos (absolute paths, may be nil) are marked so they sort first.
+func buildRepoTree(repos []GitStatus, pinned map[string]bool) *treeNode {
+	root := &treeNode{children: map[string]*treeNode{}}
+	for i := range repos {
+		isPinned := pinned[configRepoPath(repos[i].RepoPath)]
+		node := root
+		node.pinned = node.pinned || isPinned
+		if path := filepath.ToSlash(repos[i].RelativePath); path != "" && path != "." {
+			for _, part := range strings.Split(path, "/") {
+				child, ok := node.children[part]
+				if !ok {
+					child = &treeNode{children: map[string]*treeNode{}}
+					node.children[part] = child
+				}
+				node = child
+				node.pinned = node.pinned || isPinned
+			}
+		}
+		node.repo = &repos[i]
+	}
+	return root
+}
+
+// childNames lists a node's children by name, those holding a pinned repo
+// first
+func (n *treeNode) childNames() []string {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if a, b := n.children[names[i]].pinned, n.children[names[j]].pinned; a != b {
+			return a
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func (n *treeNode) entries(prefix string, out []treeEntry) []treeEntry {
+	names := n.childNames()
+	for i, name := range names {
+		connector, indent := "├── ", "│   "
+		if i == len(names)-1 {
+			connector, indent = "└── ", "    "
+		}
+		child := n.children[name]
+		out = append(out, treeEntry{label: prefix + connector + name, repo: child.repo})
+		out = child.entries(prefix+indent, out)
+	}
+	return out
+}
+
+// treeLines renders repos nested under their parent directories, headed by
+// the scanned directory. Statuses line up after the longest name.
+func treeLines(repos []GitStatus, rootName string) []treeEntry {
+	root := buildRepoTree(repos, nil)
+	entries := root.entries("", []treeEntry{{label: rootName, repo: root.repo}})
+
+	width := 0
+	for _, entry := range entries {
+		if n := utf8.RuneCountInString(entry.label); entry.repo != nil && n > width {
+			width = n
+		}
+	}
+	display := displayConfig()
+	for i, entry := range entries {
+		if entry.repo == nil {
+			continue
+		}
+		repo := entry.repo
+		label := entry.label + strings.Repeat(" ", width-utf8.RuneCountInString(entry.label))
+		line := fmt.Sprintf("%s  %s %s", label, repo.Symbol, statusLabel(*repo))
+		if repo.OffDefaultBranch() {
+			line += fmt.Sprintf(" ⎇ %s", repo.Branch)
+		}
+		if display.ShowTimestamp {
+			line += " · " + formatTimestamp(repo.LastCommitAt, display.TimeFormat)
+		}
+		if display.ShowAuthor {
+			line += authorLabel(*repo)
+		}
+		entries[i].label = line
+	}
+	return entries
+}
+
+// writeTreeReport is the report with display.tree_view on
+func writeTreeReport(w io.Writer, repos []GitStatus, color bool) {
+	rootName := filepath.Base(config.Directory)
+	if abs, err := filepath.Abs(config.Directory); err == nil {
+		rootName = filepath.Base(abs)
+	}
+
+	entries := treeLines(repos, rootName)
+	if !color {
+		for _, entry := range entries {
+			fmt.Fprintln(w, entry.label)
+		}
+		return
+	}
+
+	renderer := colorRenderer(w)
+	theme := activeTheme()
+	dirStyle := renderer.NewStyle().Foreground(themeColor(theme.Colors["dim"]))
+	for _, entry := range entries {
+		style := dirStyle
+		if entry.repo != nil {
+			style = renderer.NewStyle().Foreground(themeColor(theme.Colors[symbolColorKey(entry.repo.Symbol)]))
+		}
+		fmt.Fprintln(w, style.Render(entry.label))
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// treePath is a repo's path relative to the scan root with forward slashes,
+// "" for the root itself
+func treePath(repo GitStatus) string {
+	if path := filepath.ToSlash(repo.RelativePath); path != "." {
+		return path
+	}
+	return ""
+}
+
+// parentDirs lists every directory above path, outermost first
+func parentDirs(path string) []string {
+	var dirs []string
+	for i, c := range path {
+		if c == '/' {
+			dirs = append(dirs, path[:i])
+		}
+	}
+	return dirs
+}
+
+// treeRepos puts repos in the order the tree shows them, comparing paths
+// one directory at a time with pinned repos and the directories holding
+// them first, counts the repos under every directory and drops those
+// inside collapsed ones
+func (m model) treeRepos(repos []GitStatus) ([]GitStatus, map[string]int) {
+	pinnedPaths := make(map[string]bool)
+	for _, repo := range repos {
+		if m.pinned[configRepoPath(repo.RepoPath)] {
+			path := treePath(repo)
+			pinnedPaths[path] = true
+			for _, dir := range parentDirs(path) {
+				pinnedPaths[dir] = true
+			}
+		}
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		a, b := strings.Split(treePath(repos[i]), "/"), strings.Split(treePath(repos[j]), "/")
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				pa, pb := pinnedPaths[strings.Join(a[:k+1], "/")], pinnedPaths[strings.Join(b[:k+1], "/")]
+				if pa != pb {
+					return pa
+				}
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	sizes := make(map[string]int)
+	var open []GitStatus
+	for _, repo := range repos {
+		hidden := false
+		for _, dir := range parentDirs(treePath(repo)) {
+			sizes[dir]++
+			hidden = hidden || m.collapsedDirs[dir]
+		}
+		if !hidden {
+			open = append(open, repo)
+		}
+	}
+	return open, sizes
+}
+
+// treeRows lays the visible repos out under their directories. Directories
+// that only hold other repos get a row of their own; a collapsed one keeps
+// its row and hides everything below it.
+func (m model) treeRows() []listRow {
+	index := make(map[string]int, len(m.repos))
+	for i, repo := range m.repos {
+		index[repo.RepoPath] = i
+	}
+	root := buildRepoTree(m.repos, m.pinned)
+	for dir := range m.collapsedDirs {
+		if m.dirSizes[dir] == 0 {
+			continue
+		}
+		node := root
+		for _, part := range strings.Split(dir, "/") {
+			if node.children[part] == nil {
+				node.children[part] = &treeNode{children: map[string]*treeNode{}}
+			}
+			node = node.children[part]
+		}
+	}
+
+	var rows []listRow
+	if root.repo != nil {
+		rows = append(rows, listRow{repo: index[root.repo.RepoPath], label: "."})
+	}
+	var walk func(n *treeNode, path, prefix string)
+	walk = func(n *treeNode, path, prefix string) {
+		names := n.childNames()
+		for i, name := range names {
+			connector, indent := "├── ", "│   "
+			if i == len(names)-1 {
+				connector, indent = "└── ", "    "
+			}
+			child, childPath := n.children[name], strings.TrimPrefix(path+"/"+name, "/")
+			if child.repo != nil {
+				rows = append(rows, listRow{repo: index[child.repo.RepoPath], label: prefix + connector + name})
+			} else {
+				rows = append(rows, listRow{dir: childPath, label: prefix + connector + name})
+			}
+			if !m.collapsedDirs[childPath] {
+				walk(child, childPath, prefix+indent)
+			}
+		}
+	}
+	walk(root, "", "")
+	return rows
+}
+
+// orderLabel says how the list is ordered, for the header
+func (m model) orderLabel() string {
+	if m.treeView {
+		return "  tree view"
+	}
+	return "  sorted by " + sortLabel(m.sortBy)
+}
+
+// treeLabelWidth is the widest repo label, so statuses line up after it
+func treeLabelWidth(rows []listRow) int {
+	width := 0
+	for _, row := range rows {
+		if row.isRepo() {
+			width = max(width, utf8.RuneCountInString(row.label))
+		}
+	}
+	return width
+}
+
+// dirLine renders a directory row with its repo count
+func (m model) dirLine(row listRow) string {
+	dirStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	arrow := "▾"
+	if m.collapsedDirs[row.dir] {
+		arrow = "▸"
+	}
+	return "   " + dirStyle.Render(row.label+"/") + dimStyle.Render(fmt.Sprintf(" %s %d", arrow, m.dirSizes[row.dir]))
+}
+
+// collapseDir folds the directory holding the cursor's repo, leaving the
+// cursor on the first repo after it
+func (m model) collapseDir() model {
+	if m.cursor >= len(m.repos) {
+		return m
+	}
+	dirs := parentDirs(treePath(m.repos[m.cursor]))
+	if len(dirs) == 0 {
+		return m
+	}
+	m.collapsedDirs[dirs[len(dirs)-1]] = true
+	cursor := m.cursor
+	m = m.applySearch()
+	m.cursor = max(0, min(cursor, len(m.repos)-1))
+	return m
+}
+
+// expandDir unfolds the nearest collapsed directory above the cursor, or
+// below it when there is none above
+func (m model) expandDir() model {
+	rows := m.listRows()
+	row := m.cursorRow(rows)
+	if m.cursor >= len(m.repos) {
+		row = -1 // everything is collapsed
+	}
+	for _, step := range []int{-1, 1} {
+		for i := row + step; i >= 0 && i < len(rows); i += step {
+			if m.collapsedDirs[rows[i].dir] {
+				delete(m.collapsedDirs, rows[i].dir)
+				return m.applySearch()
+			}
+		}
+	}
+	return m
+}
+
+// toggleAllDirs expands every collapsed directory, or collapses the top
+// level when none is
+func (m model) toggleAllDirs() model {
+	if len(m.collapsedDirs) > 0 {
+		m.collapsedDirs = make(map[string]bool)
+	} else {
+		for dir := range m.dirSizes {
+			if !strings.Contains(dir, "/") {
+				m.collapsedDirs[dir] = true
+			}
+		}
+	}
+	return m.applySearch()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// triageCounts is the forge work waiting on the user in one repo
+type triageCounts struct {
+	Reviews int // open pull requests requesting their review
+	Issues  int // open issues assigned to them
+}
+
+type triageMsg struct {
+	counts map[string]triageCounts // by repo path
+	err    error
+}
+
+// forgeTriage asks one forge for the user's review requests and assigned
+// issues, counted per lower-cased project path. Both are a single query per
+// forge however many repos it hosts.
+func forgeTriage(f ForgeConfig) (reviews, issues map[string]int, err error) {
+	reviews, issues = map[string]int{}, map[string]int{}
+
+	if f.Type == "gitlab" {
+		var user struct {
+			ID int `json:"id"`
+		}
+		if err := forgeRequest(f, "GET", "/user", nil, &user); err != nil {
+			return nil, nil, err
+		}
+		var items []struct {
+			References struct {
+				Full string `json:"full"` // group/project!12 or group/project#3
+			} `json:"references"`
+		}
+		id := fmt.Sprint(user.ID)
+		if err := forgeRequest(f, "GET", "/merge_requests?state=opened&scope=all&per_page=100&reviewer_id="+id, nil, &items); err != nil {
+			return nil, nil, err
+		}
+		for _, item := range items {
+			project, _, _ := strings.Cut(item.References.Full, "!")
+			reviews[strings.ToLower(project)]++
+		}
+		items = nil
+		if err := forgeRequest(f, "GET", "/issues?state=opened&scope=all&per_page=100&assignee_id="+id, nil, &items); err != nil {
+			return nil, nil, err
+		}
+		for _, item := range items {
+			project, _, _ := strings.Cut(item.References.Full, "#")
+			issues[strings.ToLower(project)]++
+		}
+		return reviews, issues, nil
+	}
+
+	for _, query := range []struct {
+		q      string
+		counts map[string]int
+	}{
+		{"is:open is:pr review-requested:@me", reviews},
+		{"is:open is:issue assignee:@me", issues},
+	} {
+		var result struct {
+			Items []struct {
+				RepositoryURL string `json:"repository_url"` // <api>/repos/owner/name
+			} `json:"items"`
+		}
+		if err := forgeRequest(f, "GET", "/search/issues?per_page=100&q="+url.QueryEscape(query.q), nil, &result); err != nil {
+			return nil, nil, err
+		}
+		for _, item := range result.Items {
+			if i := strings.Index(item.RepositoryURL, "/repos/"); i >= 0 {
+				query.counts[strings.ToLower(item.RepositoryURL[i+len("/repos/"):])]++
+			}
+		}
+	}
+	return reviews, issues, nil
+}
+
+// loadTriage counts review requests and assigned issues for every repo
+// whose origin is on a configured forge. A forge that fails is reported
+// but doesn't stop the others.
+func loadTriage(repos []GitStatus) (map[string]triageCounts, error) {
+	projects := map[ForgeConfig]map[string]string{} // forge -> repo path -> project
+	for _, repo := range repos {
+		if !repo.HasRemote {
+			continue
+		}
+		origin, err := runGit(repo.RepoPath, "remote", "get-url", "origin")
+		if err != nil {
+			continue
+		}
+		forge, project, err := forgeForRemote(origin)
+		if err != nil {
+			continue
+		}
+		if projects[forge] == nil {
+			projects[forge] = map[string]string{}
+		}
+		projects[forge][repo.RepoPath] = strings.ToLower(project)
+	}
+
+	counts := map[string]triageCounts{}
+	var firstErr error
+	for forge, repoProjects := range projects {
+		reviews, issues, err := forgeTriage(forge)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %v", forge.host(), err)
+			}
+			continue
+		}
+		for repoPath, project := range repoProjects {
+			if c := (triageCounts{Reviews: reviews[project], Issues: issues[project]}); c != (triageCounts{}) {
+				counts[repoPath] = c
+			}
+		}
+	}
+	return counts, firstErr
+}
+
+// loadTriageCmd looks up triage counts in the background; without any
+// forges configured there is nothing to ask
+func loadTriageCmd(repos []GitStatus) tea.Cmd {
+	if offline {
+		return nil
+	}
+	if userConfig, err := loadConfig(); err != nil || len(userConfig.Forges) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		counts, err := loadTriage(repos)
+		return triageMsg{counts: counts, err: err}
+	}
+}
+
+// triageBadge is the list annotation for a repo's triage counts
+func triageBadge(c triageCounts) string {
+	var parts []string
+	if c.Reviews > 0 {
+		parts = append(parts, fmt.Sprintf("%d to review", c.Reviews))
+	}
+	if c.Issues > 0 {
+		parts = append(parts, fmt.Sprintf("%d assigned", c.Issues))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// triageSummary totals the counts for the header
+func (m model) triageSummary() string {
+	var total triageCounts
+	for _, c := range m.triage {
+		total.Reviews += c.Reviews
+		total.Issues += c.Issues
+	}
+	if badge := triageBadge(total); badge != "" {
+		return " · " + badge
+	}
+	return ""
+}
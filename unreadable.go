@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"sync"
+)
+
+// unreadableDirs collects the directories a scan had no permission to read.
+// The walkers run in parallel, hence the mutex.
+type unreadableDirs struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+var scanUnreadable unreadableDirs
+
+func (u *unreadableDirs) reset() {
+	u.mu.Lock()
+	u.paths = nil
+	u.mu.Unlock()
+}
+
+// add records path when err is a permission error; other read errors
+// (a directory deleted mid-scan, say) aren't worth reporting
+func (u *unreadableDirs) add(path string, err error) {
+	if !errors.Is(err, fs.ErrPermission) {
+		return
+	}
+	u.mu.Lock()
+	u.paths = append(u.paths, path)
+	u.mu.Unlock()
+}
+
+func (u *unreadableDirs) list() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	paths := append([]string(nil), u.paths...)
+	sort.Strings(paths)
+	return paths
+}
+
+func unreadableSummary(count int) string {
+	if count == 1 {
+		return "1 directory skipped due to permissions"
+	}
+	return fmt.Sprintf("%d directories skipped due to permissions", count)
+}
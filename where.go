@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// --where takes a small expression over each repo's status:
+//
+//	dirty || (ahead > 3 && branch != "main")
+//
+// Operands are the fields in whereFields, every state name (no-remote as
+// no_remote), numbers, "strings" and true/false. Operators, loosest first:
+// ||, &&, !, then == != < <= > >= and =~ (regular expression match).
+
+type whereKind int
+
+const (
+	whereBool whereKind = iota
+	whereInt
+	whereString
+)
+
+func (k whereKind) String() string {
+	return [...]string{"boolean", "number", "string"}[k]
+}
+
+// whereExpr is a compiled piece of an expression: its type and how to
+// evaluate it for a repo
+type whereExpr struct {
+	kind whereKind
+	eval func(GitStatus) any
+}
+
+// whereFields are the fields an expression can use besides the states
+var whereFields = map[string]whereExpr{
+	"ahead":          {whereInt, func(s GitStatus) any { return s.Ahead }},
+	"behind":         {whereInt, func(s GitStatus) any { return s.Behind }},
+	"since_tag":      {whereInt, func(s GitStatus) any { return s.SinceTag }},
+	"age":            {whereInt, func(s GitStatus) any { return int(time.Since(s.LastCommitAt).Hours() / 24) }},
+	"branch":         {whereString, func(s GitStatus) any { return s.Branch }},
+	"default_branch": {whereString, func(s GitStatus) any { return s.DefaultBranch }},
+	"path":           {whereString, func(s GitStatus) any { return displayName(s) }},
+	"status":         {whereString, func(s GitStatus) any { return s.statusKey() }},
+	"message":        {whereString, func(s GitStatus) any { return s.Message }},
+	"tag":            {whereString, func(s GitStatus) any { return s.LatestTag }},
+	"author":         {whereString, func(s GitStatus) any { return s.LastAuthor }},
+	"off_default":    {whereBool, func(s GitStatus) any { return s.OffDefaultBranch() }},
+}
+
+// whereOperand looks up a field or state by name
+func whereOperand(name string) (whereExpr, bool) {
+	if field, ok := whereFields[name]; ok {
+		return field, true
+	}
+	state := strings.ReplaceAll(name, "_", "-")
+	for _, known := range filterStates {
+		if state == known {
+			return whereExpr{whereBool, func(s GitStatus) any { return repoInStates(s, []string{state}) }}, true
+		}
+	}
+	return whereExpr{}, false
+}
+
+func whereNames() string {
+	names := make([]string, 0, len(whereFields)+len(filterStates))
+	for name := range whereFields {
+		names = append(names, name)
+	}
+	for _, state := range filterStates {
+		if name := strings.ReplaceAll(state, "-", "_"); whereFields[name].eval == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// whereToken is a token of an expression; text is the operator, the name,
+// the number or the unquoted string
+type whereToken struct {
+	text   string
+	quoted bool
+	pos    int
+}
+
+func tokenizeWhere(src string) ([]whereToken, error) {
+	var tokens []whereToken
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at %d", i+1)
+			}
+			tokens = append(tokens, whereToken{text: b.String(), quoted: true, pos: i})
+			i = j + 1
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, whereToken{text: string(runes[i:j]), pos: i})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"||", "&&", "==", "!=", "<=", ">=", "=~", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", r, i+1)
+			}
+			tokens = append(tokens, whereToken{text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+type whereParser struct {
+	tokens []whereToken
+	next   int
+}
+
+func (p *whereParser) peek() (whereToken, bool) {
+	if p.next >= len(p.tokens) {
+		return whereToken{}, false
+	}
+	return p.tokens[p.next], true
+}
+
+// accept consumes the next token if it is the operator op
+func (p *whereParser) accept(op string) bool {
+	if t, ok := p.peek(); ok && !t.quoted && t.text == op {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *whereParser) parseOr() (whereExpr, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("||") {
+		var right whereExpr
+		if right, err = p.parseAnd(); err == nil {
+			left, err = logical("||", left, right)
+		}
+	}
+	return left, err
+}
+
+func (p *whereParser) parseAnd() (whereExpr, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.accept("&&") {
+		var right whereExpr
+		if right, err = p.parseUnary(); err == nil {
+			left, err = logical("&&", left, right)
+		}
+	}
+	return left, err
+}
+
+func logical(op string, left, right whereExpr) (whereExpr, error) {
+	if left.kind != whereBool || right.kind != whereBool {
+		return whereExpr{}, fmt.Errorf("%s needs true/false on both sides", op)
+	}
+	if op == "||" {
+		return whereExpr{whereBool, func(s GitStatus) any { return left.eval(s).(bool) || right.eval(s).(bool) }}, nil
+	}
+	return whereExpr{whereBool, func(s GitStatus) any { return left.eval(s).(bool) && right.eval(s).(bool) }}, nil
+}
+
+func (p *whereParser) parseUnary() (whereExpr, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return operand, err
+		}
+		if operand.kind != whereBool {
+			return whereExpr{}, fmt.Errorf("! needs true/false, not a %s", operand.kind)
+		}
+		return whereExpr{whereBool, func(s GitStatus) any { return !operand.eval(s).(bool) }}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *whereParser) parseComparison() (whereExpr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return left, err
+	}
+	t, ok := p.peek()
+	if !ok || t.quoted {
+		return left, nil
+	}
+	op := t.text
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "=~":
+		p.next++
+	default:
+		return left, nil
+	}
+	literal := p.next < len(p.tokens) && p.tokens[p.next].quoted
+	right, err := p.parsePrimary()
+	if err != nil {
+		return right, err
+	}
+	if left.kind != right.kind {
+		return whereExpr{}, fmt.Errorf("can't compare a %s with a %s at %d", left.kind, right.kind, t.pos+1)
+	}
+
+	switch op {
+	case "==":
+		return whereExpr{whereBool, func(s GitStatus) any { return left.eval(s) == right.eval(s) }}, nil
+	case "!=":
+		return whereExpr{whereBool, func(s GitStatus) any { return left.eval(s) != right.eval(s) }}, nil
+	case "=~":
+		if left.kind != whereString || !literal {
+			return whereExpr{}, fmt.Errorf("=~ needs a string on the left and a quoted pattern on the right")
+		}
+		pattern, err := regexp.Compile(right.eval(GitStatus{}).(string))
+		if err != nil {
+			return whereExpr{}, err
+		}
+		return whereExpr{whereBool, func(s GitStatus) any { return pattern.MatchString(left.eval(s).(string)) }}, nil
+	}
+	if left.kind == whereBool {
+		return whereExpr{}, fmt.Errorf("%s compares numbers or strings, not true/false", op)
+	}
+	compare := func(s GitStatus) int {
+		if left.kind == whereInt {
+			return left.eval(s).(int) - right.eval(s).(int)
+		}
+		return strings.Compare(left.eval(s).(string), right.eval(s).(string))
+	}
+	return whereExpr{whereBool, func(s GitStatus) any {
+		c := compare(s)
+		switch op {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		}
+		return c >= 0
+	}}, nil
+}
+
+func (p *whereParser) parsePrimary() (whereExpr, error) {
+	t, ok := p.peek()
+	if !ok {
+		return whereExpr{}, fmt.Errorf("expression ends too soon")
+	}
+	p.next++
+	switch {
+	case t.quoted:
+		return whereExpr{whereString, func(GitStatus) any { return t.text }}, nil
+	case t.text == "(":
+		inner, err := p.parseOr()
+		if err == nil && !p.accept(")") {
+			err = fmt.Errorf("missing ) for the ( at %d", t.pos+1)
+		}
+		return inner, err
+	case t.text == "true" || t.text == "false":
+		value := t.text == "true"
+		return whereExpr{whereBool, func(GitStatus) any { return value }}, nil
+	case unicode.IsDigit(rune(t.text[0])):
+		n, err := strconv.Atoi(t.text)
+		if err != nil {
+			return whereExpr{}, fmt.Errorf("bad number %q at %d", t.text, t.pos+1)
+		}
+		return whereExpr{whereInt, func(GitStatus) any { return n }}, nil
+	case unicode.IsLetter(rune(t.text[0])) || t.text[0] == '_':
+		if operand, ok := whereOperand(t.text); ok {
+			return operand, nil
+		}
+		return whereExpr{}, fmt.Errorf("unknown field %q (available: %s)", t.text, whereNames())
+	}
+	return whereExpr{}, fmt.Errorf("unexpected %q at %d", t.text, t.pos+1)
+}
+
+// compileWhere turns a --where expression into a filter
+func compileWhere(src string) (func(GitStatus) bool, error) {
+	tokens, err := tokenizeWhere(src)
+	if err != nil {
+		return nil, fmt.Errorf("--where: %v", err)
+	}
+	p := &whereParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err == nil && p.next < len(tokens) {
+		err = fmt.Errorf("unexpected %q at %d", tokens[p.next].text, tokens[p.next].pos+1)
+	}
+	if err == nil && expr.kind != whereBool {
+		err = fmt.Errorf("the expression is a %s, not true/false", expr.kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("--where: %v", err)
+	}
+	return func(s GitStatus) bool { return expr.eval(s).(bool) }, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestCompileWhere(t *testing.T) {
+	dirtyFeature := GitStatus{Symbol: "✗", Dirty: true, HasRemote: true, HasUpstream: true, Ahead: 4, Branch: "feature/login", DefaultBranch: "main"}
+	cleanMain := GitStatus{Symbol: "✓", HasRemote: true, HasUpstream: true, Branch: "main", DefaultBranch: "main", LatestTag: "v1.2.0"}
+	diverged := GitStatus{Symbol: "↕", HasRemote: true, HasUpstream: true, Ahead: 1, Behind: 2, Branch: "main", DefaultBranch: "main"}
+	noRemote := GitStatus{Symbol: "↑", Branch: "main"}
+
+	tests := []struct {
+		expr string
+		repo GitStatus
+		want bool
+	}{
+		{"dirty", dirtyFeature, true},
+		{"dirty", cleanMain, false},
+		{"!dirty", cleanMain, true},
+		{"clean", cleanMain, true},
+		{"ahead > 3", dirtyFeature, true},
+		{"ahead > 3", diverged, false},
+		{"ahead >= 1 && behind <= 2", diverged, true},
+		{"dirty || (ahead > 3 && branch != \"main\")", dirtyFeature, true},
+		{"dirty || (ahead > 3 && branch != \"main\")", diverged, false},
+		{"diverged", diverged, true},
+		{"no_remote", noRemote, true},
+		{"no_remote", cleanMain, false},
+		{"off_default", dirtyFeature, true},
+		{"off_default == false", cleanMain, true},
+		{"branch == default_branch", cleanMain, true},
+		{"branch =~ '^feature/'", dirtyFeature, true},
+		{"branch =~ '^feature/'", cleanMain, false},
+		{"tag == \"v1.2.0\"", cleanMain, true},
+		{"status == 'diverged'", diverged, true},
+		{"branch < \"main\"", dirtyFeature, true},
+		{"true && !false", noRemote, true},
+		{"dirty || ahead > 0 && behind > 0", diverged, true}, // && binds tighter than ||
+	}
+	for _, tt := range tests {
+		filter, err := compileWhere(tt.expr)
+		if err != nil {
+			t.Errorf("compileWhere(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := filter(tt.repo); got != tt.want {
+			t.Errorf("%q on %s = %v, want %v", tt.expr, tt.repo.Branch, got, tt.want)
+		}
+	}
+}
+
+func TestCompileWhereErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"ahead",             // a number, not true/false
+		"ahead > \"3\"",     // number against string
+		"dirty < clean",     // ordering booleans
+		"branch =~ branch",  // pattern must be quoted
+		"branch =~ '('",     // bad pattern
+		"(dirty",            // missing )
+		"dirty)",            // trailing token
+		"branch == 'main",   // unterminated string
+		"nosuchfield",       // unknown operand
+		"ahead > 3 $ dirty", // unknown character
+		"dirty &&",          // ends too soon
+	} {
+		if _, err := compileWhere(expr); err == nil {
+			t.Errorf("compileWhere(%q) succeeded, want an error", expr)
+		}
+	}
+}
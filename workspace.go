@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type resetOptions struct {
+	ToDefault bool
+	OnlyClean bool
+	DryRun    bool
+	Yes       bool
+}
+
+// runResetWorkspace fetches every repo and fast-forwards it (optionally after
+// checking out the default branch). Repos that can't be reset safely, dirty
+// ones among them, are left untouched and listed at the end with the reason.
+func runResetWorkspace(baseDir string, opts resetOptions) {
+	repos := findGitReposOptimized(baseDir, config.Depth)
+
+	var untouched []string
+	var targets []GitStatus
+	var branches []string
+	for _, repo := range repos {
+		if reason := resetBlocker(repo, opts); reason != "" {
+			untouched = append(untouched, fmt.Sprintf("%-30s %s", displayName(repo), reason))
+			continue
+		}
+		target := repo.Branch
+		if opts.ToDefault && repo.DefaultBranch != "" {
+			target = repo.DefaultBranch
+		}
+		targets = append(targets, repo)
+		branches = append(branches, target)
+	}
+
+	reset := 0
+	if opts.DryRun {
+		for i, repo := range targets {
+			fmt.Printf("~ %-30s would fetch and fast-forward %s\n", displayName(repo), branches[i])
+		}
+		reset = len(targets)
+	} else {
+		commands := make([]string, len(targets))
+		for i, repo := range targets {
+			steps := []string{"git -C " + repo.RepoPath + " fetch --prune"}
+			if branches[i] != repo.Branch {
+				steps = append(steps, "git checkout "+branches[i])
+			}
+			commands[i] = strings.Join(append(steps, "git merge --ff-only @{u}"), " && ")
+		}
+		if !opts.Yes && !confirmBulk(fmt.Sprintf("Reset %d repositories?", len(targets)), commands) {
+			fmt.Println("Cancelled")
+			return
+		}
+
+		for i, repo := range targets {
+			result, err := resetRepo(repo, branches[i])
+			if err != nil {
+				untouched = append(untouched, fmt.Sprintf("%-30s %v", displayName(repo), err))
+				continue
+			}
+			fmt.Printf("✓ %-30s %s\n", displayName(repo), result)
+			reset++
+		}
+	}
+
+	if len(untouched) > 0 {
+		fmt.Printf("\nLeft untouched (%d):\n", len(untouched))
+		for _, line := range untouched {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	verb := "Reset"
+	if opts.DryRun {
+		verb = "Would reset"
+	}
+	fmt.Printf("\n%s %d of %d repositories\n", verb, reset, len(repos))
+}
+
+// resetBlocker explains why a repo must not be touched, based on the scan
+func resetBlocker(repo GitStatus, opts resetOptions) string {
+	switch {
+	case isProtected(repo.RepoPath):
+		return protectedMessage
+	case repo.Symbol == "⚠" || repo.Corrupt:
+		return repo.Message
+	case repo.Symbol == "↕":
+		return "diverged from upstream"
+	case repo.Dirty:
+		return "uncommitted changes"
+	case opts.OnlyClean && repo.Ahead > 0:
+		return "unpushed commits"
+	case repo.Branch == "" || repo.Branch == "HEAD":
+		return "detached HEAD"
+	}
+	return ""
+}
+
+func resetRepo(repo GitStatus, target string) (string, error) {
+	if _, err := runGit(repo.RepoPath, "fetch", "--prune"); err != nil {
+		return "", fmt.Errorf("fetch failed: %v", err)
+	}
+
+	// A default branch never checked out here only exists as origin's;
+	// checking it out creates it at origin's tip, so there is nothing to
+	// fast-forward.
+	if _, err := runGit(repo.RepoPath, "show-ref", "--verify", "--quiet", "refs/heads/"+target); err != nil {
+		if _, err := runGit(repo.RepoPath, "show-ref", "--verify", "--quiet", "refs/remotes/origin/"+target); err != nil {
+			return "", fmt.Errorf("%s exists neither here nor on origin", target)
+		}
+		if _, err := runGit(repo.RepoPath, "checkout", "--track", "origin/"+target); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s → %s (new from origin/%s)", repo.Branch, target, target), nil
+	}
+
+	// Check the target branch against its upstream before switching to it,
+	// so a diverged default branch doesn't leave the repo half-reset.
+	counts, err := runGit(repo.RepoPath, "rev-list", "--left-right", "--count", target+"..."+target+"@{u}")
+	if err != nil {
+		return "", fmt.Errorf("%s has no upstream branch", target)
+	}
+	fields := strings.Fields(counts)
+	if len(fields) == 2 && fields[0] != "0" && fields[1] != "0" {
+		return "", fmt.Errorf("%s diverged from upstream", target)
+	}
+
+	var steps []string
+	if target != repo.Branch {
+		if _, err := runGit(repo.RepoPath, "checkout", target); err != nil {
+			return "", err
+		}
+		steps = append(steps, fmt.Sprintf("%s → %s", repo.Branch, target))
+	}
+
+	if len(fields) == 2 && fields[1] != "0" {
+		if _, err := runGit(repo.RepoPath, "merge", "--ff-only", "@{u}"); err != nil {
+			return "", err
+		}
+		steps = append(steps, fmt.Sprintf("fast-forwarded %s commit(s)", fields[1]))
+	}
+
+	if len(steps) == 0 {
+		return "already up to date", nil
+	}
+	return strings.Join(steps, ", "), nil
+}